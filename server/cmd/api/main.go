@@ -9,141 +9,467 @@
 // @BasePath /api
 // @schemes http
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key; writes need the editor or admin role.
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description API key sent as "Bearer <key>"; writes need the editor or admin role.
+
 package main
 
 import (
 	_ "bf-api/docs" // Required for Swagger
+	"bf-api/internal/app/grpcserver"
 	"bf-api/internal/app/handlers"
+	bfMiddleware "bf-api/internal/app/middleware"
 	"bf-api/internal/app/routes"
+	"bf-api/internal/config"
+	"bf-api/internal/domain/repositories"
 	"bf-api/internal/domain/services"
+	"bf-api/internal/infrastructure/auth"
+	"bf-api/internal/infrastructure/db/memory"
 	"bf-api/internal/infrastructure/db/postgres"
+	"bf-api/internal/infrastructure/fieldcrypt"
 	"bf-api/internal/infrastructure/logger"
-	"bufio"
+	"bf-api/internal/infrastructure/messaging"
+	"bf-api/internal/infrastructure/objectstore"
+	"bf-api/internal/infrastructure/webhook"
 	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
-	"strings"
 	"syscall"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
 )
 
-type DBConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
-}
-
 func main() {
-	logger.Init(false)
+	cfg := config.Load()
+
+	if err := logger.Init(cfg.Log); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
 	zap.ReplaceGlobals(logger.Logger)
 	defer logger.Logger.Sync()
 
-	cfg := postgres.DBConfig{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnvAsInt("DB_PORT", 5432),
-		User:     getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", "postgres"),
-		DBName:   getEnv("DB_NAME", "bookdb"),
-		SSLMode:  getEnv("DB_SSLMODE", "disable"),
+	fieldcrypt.SetDefault(cfg.FieldKeys)
+	if cfg.HTTP.LogBodies {
+		logger.SetLevel(zapcore.DebugLevel)
+		logger.Logger.Warn("request and response bodies are being logged; disable HTTP_LOG_BODIES in production")
+	}
+
+	if cfg.Production() && cfg.DBBackend == config.DBBackendPostgres && cfg.DB.SSLMode == "disable" {
+		logger.Logger.Warn("database connections are not encrypted; set DB_SSLMODE to verify-full in production")
 	}
 
 	ctx := context.Background()
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	pgPool, err := postgres.NewPostgresDB(ctx, cfg)
+	var pgPool *pgxpool.Pool
+	if cfg.DBBackend == config.DBBackendPostgres {
+		var err error
+		pgPool, err = postgres.NewPostgresDB(ctx, cfg.DB)
+		if err != nil {
+			logger.Logger.Fatal("failed to connect to database", zap.Error(err))
+		}
+	} else {
+		logger.Logger.Warn("books are kept in memory and lost on restart; set DB_BACKEND to postgres in production")
+	}
+
+	// the service and the notifier share one clock so events carry the
+	// times the service acted at
+	clock := services.RealClock{}
+
+	var notifier services.BookNotifier
+	if len(cfg.Webhook.URLs) > 0 {
+		webhookNotifier := webhook.NewNotifier(cfg.Webhook, clock, logger.Logger)
+		defer webhookNotifier.Close()
+		notifier = webhookNotifier
+	}
+
+	var publisher services.EventPublisher
+	if cfg.NATS.URL != "" {
+		natsPublisher, err := messaging.NewNATSPublisher(cfg.NATS)
+		if err != nil {
+			logger.Logger.Fatal("failed to connect to event broker", zap.Error(err))
+		}
+		defer natsPublisher.Close()
+		publisher = natsPublisher
+	}
+
+	bookRepo, auditRepo, txManager := newRepositories(cfg, pgPool)
+	coverStore, err := objectstore.New(cfg.Covers)
 	if err != nil {
-		logger.Logger.Fatal("failed to connect to database", zap.Error(err))
+		logger.Logger.Fatal("failed to set up cover storage", zap.Error(err))
+	}
+	svcOpts := []services.BookServiceOption{
+		services.WithMinPages(cfg.BookMinPages),
+		services.WithMaxPages(cfg.BookMaxPages),
+		services.WithAuthorCheck(cfg.BookAuthorCheck),
+		services.WithCoverStore(coverStore),
+		services.WithRequestInfo(auth.RequestInfo{}),
+		services.WithConfidentialFields(cfg.FieldKeys != nil),
+		services.WithClock(clock),
+	}
+
+	// closed once the database is migrated and warmed up
+	started := make(chan struct{})
+
+	// events reach the broker through the outbox so none are lost while it is down
+	var relayDone chan struct{}
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+	if publisher != nil {
+		svcOpts = append(svcOpts, services.WithOutbox())
+		relay := services.NewOutboxRelay(cfg.Outbox, txManager, publisher)
+		relayDone = make(chan struct{})
+		go func() {
+			defer close(relayDone)
+			// the outbox table may not exist before migrations have run
+			select {
+			case <-started:
+				relay.Run(relayCtx)
+			case <-relayCtx.Done():
+			}
+		}()
+	}
+
+	bookSvc := services.NewBookService(bookRepo, auditRepo, txManager, notifier, publisher, svcOpts...)
+
+	e := echo.New()
+	e.HideBanner = true
+	e.IPExtractor = ipExtractor(cfg.HTTP.TrustedProxies)
+
+	inFlight := bfMiddleware.NewInFlight()
+	e.Use(inFlight.Middleware)
+
+	bookHandler := handlers.NewBookHandler(bookSvc, logger.Logger, handlers.WithPagination(handlers.Pagination{
+		DefaultLimit: cfg.HTTP.ListDefaultLimit,
+		MaxLimit:     cfg.HTTP.ListMaxLimit,
+	}))
+
+	// the database is checked in the background so /readyz notices it
+	// degrading before requests start failing
+	var healthMonitor *postgres.HealthMonitor
+	var healthOpts []handlers.HealthHandlerOption
+	if pgPool != nil && cfg.DB.HealthCheckInterval > 0 {
+		healthMonitor = postgres.NewHealthMonitor(pgPool, cfg.DB.HealthCheckQuery, cfg.DB.HealthCheckInterval)
+		healthOpts = append(healthOpts, handlers.WithHealthMonitor(healthMonitor))
+	}
+	monitorCtx, stopMonitor := context.WithCancel(context.Background())
+	defer stopMonitor()
+	healthHandler := handlers.NewHealthHandler(pgPool, logger.Logger, healthOpts...)
+
+	var debugHandler *handlers.DebugHandler
+	if cfg.DebugEndpoints && pgPool != nil {
+		debugHandler = handlers.NewDebugHandler(pgPool)
+	}
+
+	rateLimit := bfMiddleware.NewRateLimitStore(cfg.HTTP.RateLimit)
+	routes.APIRouter(e, cfg.HTTP, cfg.Auth, rateLimit, bookHandler, healthHandler, debugHandler, bookSvc, logger.Logger)
+
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != "" {
+		grpcServer = grpcserver.NewServer(bookSvc, cfg.Auth, logger.Logger)
+	}
+
+	// HTTP is served while the database is prepared, so a startup probe can
+	// tell a slow start from a dead process; /readyz fails until then
+	go func() {
+		begin := time.Now()
+		if pgPool != nil {
+			prepareDatabase(pgPool)
+		}
+		if healthMonitor != nil {
+			go healthMonitor.Run(monitorCtx)
+		}
+		if grpcServer != nil {
+			startGRPCServer(grpcServer, cfg.GRPCPort)
+		}
+		healthHandler.MarkStarted()
+		close(started)
+		logger.Logger.Info("startup complete", zap.Duration("elapsed", time.Since(begin)))
+	}()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go reloadConfig(hup, cfg, rateLimit, pgPool)
+
+	startServer(e, grpcServer, cfg.Port, cfg.HTTP, inFlight)
+	signal.Stop(hup)
+
+	// undelivered events stay in the outbox for the next start
+	stopRelay()
+	if relayDone != nil {
+		<-relayDone
+	}
+
+	// only once the servers have drained, so in-flight queries can finish
+	stopMonitor()
+	if pgPool != nil {
+		pgPool.Close()
+	}
+}
+
+// reloadConfig re-reads the configuration on every SIGHUP and applies the
+// rate limit, the log level and the slow query threshold while serving. A
+// configuration that fails to load is logged and the current settings are
+// kept; changes to any other setting are logged as needing a restart.
+func reloadConfig(hup <-chan os.Signal, running config.Config, rateLimit *bfMiddleware.RateLimitStore, pgPool *pgxpool.Pool) {
+	applied := running
+	for range hup {
+		next, err := config.Read()
+		if err != nil {
+			logger.Logger.Error("config reload failed; keeping the current settings", zap.Error(err))
+			continue
+		}
+
+		// clients keep their budgets unless the rate actually changed
+		if next.HTTP.RateLimit != applied.HTTP.RateLimit {
+			rateLimit.SetRate(next.HTTP.RateLimit)
+		}
+		// bodies are logged at debug level, so it stays on while they are
+		if !running.HTTP.LogBodies {
+			logger.SetLevel(next.Log.Level)
+		}
+		if pgPool != nil {
+			postgres.SetSlowQueryThreshold(pgPool, next.DB.SlowQueryThreshold)
+		}
+		applied = next
 
+		logger.Logger.Info("config reloaded",
+			zap.Int("rate_limit", next.HTTP.RateLimit),
+			zap.Stringer("log_level", next.Log.Level),
+			zap.Duration("slow_query_threshold", next.DB.SlowQueryThreshold),
+		)
+		if changed := config.ColdChanges(running, next); len(changed) > 0 {
+			logger.Logger.Warn("changed settings take effect after a restart", zap.Strings("settings", changed))
+		}
 	}
-	defer pgPool.Close()
+}
+
+// newRepositories builds the repositories of the configured backend; pgPool
+// is nil unless that is postgres.
+func newRepositories(cfg config.Config, pgPool *pgxpool.Pool) (repositories.BookRepository, repositories.AuditRepository, repositories.TxManager) {
+	if cfg.DBBackend == config.DBBackendMemory {
+		store := memory.NewStore()
+		repoOpts := []memory.BookRepositoryOption{
+			memory.WithImport(cfg.DB.ImportMode, cfg.DB.ImportBatchSize),
+		}
+		return memory.NewBookRepository(store, repoOpts...), memory.NewAuditRepository(store), memory.NewTxManager(store, repoOpts...)
+	}
+
+	repoOpts := []postgres.BookRepositoryOption{
+		postgres.WithSeparateCount(cfg.DB.SeparateCount),
+		postgres.WithImport(cfg.DB.ImportMode, cfg.DB.ImportBatchSize),
+	}
+	return postgres.NewBookRepository(pgPool, repoOpts...), postgres.NewAuditRepository(pgPool), postgres.NewTxManager(pgPool, repoOpts...)
+}
+
+// prepareDatabase checks the database, warms up the pool and runs pending
+// migrations, exiting the process if any of that fails, then warns about
+// required extensions the migrations could not provide.
+func prepareDatabase(pgPool *pgxpool.Pool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	if err := postgres.HealthCheck(ctx, pgPool); err != nil {
 		log.Fatalf("Database health check failed: %v", err)
 	}
 
-	bookRepo := postgres.NewBookRepository(pgPool)
-	bookSvc := services.NewBookService(bookRepo)
+	warmUp, err := postgres.WarmUp(ctx, pgPool)
+	if err != nil {
+		logger.Logger.Fatal("database pool warm-up failed", zap.Error(err), zap.Duration("elapsed", warmUp))
+	}
+	logger.Logger.Info("database pool warmed up",
+		zap.Int32("min_conns", pgPool.Config().MinConns),
+		zap.Int32("total_conns", pgPool.Stat().TotalConns()),
+		zap.Duration("elapsed", warmUp),
+	)
 
-	e := echo.New()
-	e.HideBanner = true
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelMigrate()
+	if err := postgres.Migrate(migrateCtx, pgPool); err != nil {
+		logger.Logger.Fatal("failed to run database migrations", zap.Error(err))
+	}
 
-	bookHandler := handlers.NewBookHandler(bookSvc, logger.Logger)
-	routes.APIRouter(e, bookHandler, bookSvc, logger.Logger)
-	startServer(e)
+	// after migrating, since a migration creates pg_trgm; ctx may have run
+	// out during a long migration
+	infoCtx, cancelInfo := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelInfo()
+	if info, err := postgres.ServerInfo(infoCtx, pgPool); err != nil {
+		logger.Logger.Warn("failed to read database server info", zap.Error(err))
+	} else if missing := info.Missing(); len(missing) > 0 {
+		logger.Logger.Warn("database is missing required extensions; /readyz will fail",
+			zap.String("server_version", info.Version),
+			zap.Strings("missing", missing),
+		)
+	} else {
+		logger.Logger.Info("connected to database", zap.String("server_version", info.Version))
+	}
+}
 
+// startGRPCServer serves srv on port in the background.
+func startGRPCServer(srv *grpc.Server, port string) {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		logger.Logger.Fatal("failed to listen for gRPC", zap.String("port", port), zap.Error(err))
+	}
+	go func() {
+		logger.Logger.Info("Starting gRPC server", zap.String("port", port))
+		// a shutdown during startup stops srv before it serves
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			logger.Logger.Fatal("shutting down the gRPC server", zap.Error(err))
+		}
+	}()
 }
 
-func startServer(e *echo.Echo) {
+// startServer serves until SIGINT or SIGTERM, then shuts down gracefully and
+// returns once in-flight requests have drained or the grace period expired.
+// grpcServer, when not nil, is drained alongside the HTTP server.
+func startServer(e *echo.Echo, grpcServer *grpc.Server, port string, cfg config.HTTPConfig, inFlight *bfMiddleware.InFlight) {
+	for _, srv := range []*http.Server{e.Server, e.TLSServer} {
+		applyServerTimeouts(srv, cfg)
+	}
+
 	go func() {
-		port := getEnv("PORT", "8080")
-		logger.Logger.Info("Starting server", zap.String("port", port))
-		if err := e.Start(":" + port); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.TLSEnabled() {
+			logger.Logger.Info("Starting HTTPS server", zap.String("port", cfg.TLSPort))
+			err = e.StartTLS(":"+cfg.TLSPort, cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			logger.Logger.Info("Starting server", zap.String("port", port))
+			err = e.Start(":" + port)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Logger.Fatal("shutting down the server", zap.Error(err))
 		}
 	}()
 
+	var redirect *echo.Echo
+	if cfg.TLSEnabled() && cfg.RedirectToHTTPS {
+		redirect = newHTTPSRedirect(cfg)
+		go func() {
+			logger.Logger.Info("Starting HTTP to HTTPS redirect", zap.String("port", port))
+			if err := redirect.Start(":" + port); err != nil && err != http.ErrServerClosed {
+				logger.Logger.Fatal("shutting down the redirect server", zap.Error(err))
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// requests arriving from now on, e.g. on kept-alive connections, are
+	// turned away with 503 rather than cut off when the grace period ends
+	inFlight.Drain()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
-	logger.Logger.Info("Shutting down server...")
+	logger.Logger.Info("Shutting down server...",
+		zap.Int64("in_flight_requests", inFlight.Count()),
+		zap.Duration("grace_period", cfg.ShutdownTimeout),
+	)
+	shutdown(ctx, e, redirect, grpcServer)
+}
+
+// shutdown stops the servers gracefully, giving in-flight requests until ctx
+// expires to finish. redirect and grpcServer may be nil. gRPC streams still
+// open when ctx expires are closed.
+func shutdown(ctx context.Context, e, redirect *echo.Echo, grpcServer *grpc.Server) {
+	if redirect != nil {
+		if err := redirect.Shutdown(ctx); err != nil {
+			logger.Logger.Error("Redirect server shutdown failed", zap.Error(err))
+		}
+	}
+
+	if grpcServer == nil {
+		if err := e.Shutdown(ctx); err != nil {
+			logger.Logger.Error("Server shutdown failed", zap.Error(err))
+		}
+		return
+	}
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+
 	if err := e.Shutdown(ctx); err != nil {
 		logger.Logger.Error("Server shutdown failed", zap.Error(err))
 	}
+
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		logger.Logger.Error("gRPC server shutdown timed out; closing open streams")
+		grpcServer.Stop()
+	}
 }
 
-func getEnv(key, defaultValue string) string {
-	if err := loadEnvFile(".env"); err != nil {
-		log.Println("No .env file found, using system environment variables")
+// ipExtractor derives the client IP used for logging and rate limiting.
+// X-Forwarded-For is only honored for hops from trustedProxies, so clients
+// cannot spoof their address; echo's default trust of private ranges is
+// turned off for the same reason.
+func ipExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
 	}
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+	for _, ipNet := range trustedProxies {
+		opts = append(opts, echo.TrustIPRange(ipNet))
 	}
-	return defaultValue
+	return echo.ExtractIPFromXFFHeader(opts...)
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value, exists := os.LookupEnv(key); exists {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
+// applyServerTimeouts bounds how long a client may take to send a request so
+// slow connections (slowloris) cannot pin server resources.
+func applyServerTimeouts(srv *http.Server, cfg config.HTTPConfig) {
+	srv.ReadHeaderTimeout = cfg.ReadHeaderTimeout
+	srv.ReadTimeout = cfg.ReadTimeout
+	srv.WriteTimeout = cfg.WriteTimeout
+	srv.IdleTimeout = cfg.IdleTimeout
+	srv.MaxHeaderBytes = cfg.MaxHeaderBytes
 }
 
-func loadEnvFile(path string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+// newHTTPSRedirect returns a bare server that permanently redirects every
+// request to the same host and URI on the TLS port.
+func newHTTPSRedirect(cfg config.HTTPConfig) *echo.Echo {
+	redirect := echo.New()
+	redirect.HideBanner = true
+	redirect.HidePort = true
+	applyServerTimeouts(redirect.Server, cfg)
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
-			continue // Skip empty lines and comments
+	redirect.Any("/*", func(c echo.Context) error {
+		host, _, err := net.SplitHostPort(c.Request().Host)
+		if err != nil {
+			host = c.Request().Host
 		}
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue // Skip malformed lines
+		if cfg.TLSPort != "443" {
+			host = net.JoinHostPort(host, cfg.TLSPort)
 		}
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		os.Setenv(key, value) // Set in system environment
-	}
-	return scanner.Err()
+		return c.Redirect(http.StatusMovedPermanently, "https://"+host+c.Request().RequestURI)
+	})
+
+	return redirect
 }
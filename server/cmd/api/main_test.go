@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bf-api/internal/config"
+	"bf-api/internal/infrastructure/logger"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// TestGracefulShutdown serves on an ephemeral port with the configured server
+// timeouts and checks that shutting down lets an in-flight request finish
+// and then stops accepting connections.
+func TestGracefulShutdown(t *testing.T) {
+	cfg := config.HTTPConfig{
+		ReadHeaderTimeout: time.Second,
+		ReadTimeout:       time.Second,
+		WriteTimeout:      5 * time.Second,
+		IdleTimeout:       time.Second,
+		MaxHeaderBytes:    1 << 20,
+	}
+
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	applyServerTimeouts(e.Server, cfg)
+
+	started := make(chan struct{})
+	e.GET("/slow", func(c echo.Context) error {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		return c.String(http.StatusOK, "done")
+	})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Listener = lis
+	served := make(chan error, 1)
+	go func() { served <- e.Start("") }()
+
+	url := "http://" + lis.Addr().String() + "/slow"
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			inFlight <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		inFlight <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown(ctx, e, nil, nil)
+
+	res := <-inFlight
+	if res.err != nil || res.status != http.StatusOK || res.body != "done" {
+		t.Errorf("in-flight request = %d %q, %v; want 200 \"done\"", res.status, res.body, res.err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Start() error = %v, want ErrServerClosed", err)
+	}
+	if _, err := http.Get(url); err == nil {
+		t.Error("request after shutdown succeeded, want the connection refused")
+	}
+}
+
+func TestShutdownExpiredWithoutGRPC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// must not touch the missing gRPC server once the grace period is over
+	shutdown(ctx, echo.New(), nil, nil)
+}
+
+func TestShutdownExpiredWithGRPC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	grpcServer := grpc.NewServer()
+	shutdown(ctx, echo.New(), nil, grpcServer)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a stopped server refuses to serve again
+	if err := grpcServer.Serve(lis); err != grpc.ErrServerStopped {
+		t.Errorf("Serve() after shutdown error = %v, want ErrServerStopped", err)
+	}
+}
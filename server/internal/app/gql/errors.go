@@ -0,0 +1,57 @@
+package gql
+
+import (
+	"bf-api/internal/domain/services"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+// Error is a resolver error. Code matches the REST API's error codes and is
+// reported, with any field details, in the GraphQL error's extensions.
+type Error struct {
+	Code    string
+	Message string
+	Details []services.FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": e.Code}
+	if len(e.Details) > 0 {
+		details := make([]map[string]string, len(e.Details))
+		for i, fe := range e.Details {
+			details[i] = map[string]string{"field": fe.Field, "message": fe.Message}
+		}
+		ext["details"] = details
+	}
+	return ext
+}
+
+// serviceError maps a BookService error onto a resolver error.
+func serviceError(err error) error {
+	var unknownAuthor *services.UnknownAuthorError
+	var valErrs services.ValidationErrors
+	switch {
+	case errors.As(err, &unknownAuthor):
+		return &Error{Code: "unknown_author", Message: "Unknown author", Details: unknownAuthor.Fields}
+	case errors.As(err, &valErrs):
+		return &Error{Code: "validation_error", Message: "Validation failed", Details: valErrs}
+	case errors.Is(err, services.ErrNotFound):
+		return &Error{Code: "not_found", Message: err.Error()}
+	case errors.Is(err, services.ErrInvalidInput):
+		return &Error{Code: "invalid_input", Message: err.Error()}
+	case errors.Is(err, services.ErrConflict):
+		return &Error{Code: "conflict", Message: err.Error()}
+	case errors.Is(err, services.ErrPermissionDenied):
+		return &Error{Code: "forbidden", Message: err.Error()}
+	case errors.Is(err, services.ErrUnavailable):
+		return &Error{Code: "service_unavailable", Message: "Service temporarily unavailable"}
+	}
+
+	zap.L().Error("unexpected graphql resolver error", zap.Error(err))
+	return &Error{Code: "internal_error", Message: "An unexpected server error occurred"}
+}
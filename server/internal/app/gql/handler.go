@@ -0,0 +1,68 @@
+package gql
+
+import (
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/labstack/echo/v4"
+)
+
+type request struct {
+	Query         string                 `json:"query" query:"query"`
+	OperationName string                 `json:"operationName" query:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Handler executes GraphQL requests sent as a JSON POST body or, for
+// queries only, as GET query parameters. Only the selected fields are
+// resolved and serialized.
+func Handler(schema graphql.Schema) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req request
+		if err := c.Bind(&req); err != nil || req.Query == "" {
+			return c.JSON(http.StatusBadRequest, errorResult("A query is required"))
+		}
+		if c.Request().Method == http.MethodGet && isMutation(req) {
+			return c.JSON(http.StatusMethodNotAllowed, errorResult("Mutations must be sent with POST"))
+		}
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        c.Request().Context(),
+		})
+		return c.JSON(http.StatusOK, result)
+	}
+}
+
+// isMutation reports whether the operation req selects is a mutation. Parse
+// errors are left for graphql.Do to report.
+func isMutation(req request) bool {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return false
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if req.OperationName != "" && (op.Name == nil || op.Name.Value != req.OperationName) {
+			continue
+		}
+		if op.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+	return false
+}
+
+func errorResult(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"errors": []map[string]string{{"message": message}},
+	}
+}
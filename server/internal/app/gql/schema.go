@@ -0,0 +1,286 @@
+// Package gql serves the book API over GraphQL. Resolvers only translate
+// arguments and delegate to services.BookService, so business rules live in
+// one place for REST and GraphQL alike.
+package gql
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/services"
+	"bf-api/internal/infrastructure/auth"
+	"errors"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// bookType mirrors the REST representation; field names match its JSON keys.
+var bookType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Book",
+	Fields: graphql.Fields{
+		"id":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"title":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"author": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"published": &graphql.Field{
+			Type:        graphql.String,
+			Description: "Publication date as YYYY-MM-DD",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				published := p.Source.(*models.Book).Published
+				if published.IsZero() {
+					return nil, nil
+				}
+				return published.String(), nil
+			},
+		},
+		"isbn":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"pages":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"stock":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"created_at": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"updated_at": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+	},
+})
+
+var bookPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "BookPage",
+	Fields: graphql.Fields{
+		"data":        &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookType)))},
+		"page":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"limit":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"total_pages": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"total_items": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+type bookPage struct {
+	Data       []*models.Book `json:"data"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	TotalPages int            `json:"total_pages"`
+	TotalItems int            `json:"total_items"`
+}
+
+var bookFilterInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "BookFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"search":         &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"search_mode":    &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "fulltext (default) or prefix"},
+		"author_exact":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"created_after":  &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"created_before": &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"updated_after":  &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"updated_before": &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+	},
+})
+
+var bookCreateInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "BookCreateInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"author":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"published": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String), Description: "YYYY-MM-DD"},
+		"isbn":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"pages":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var bookUpdateInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "BookUpdateInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"author":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"published": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "YYYY-MM-DD"},
+		"isbn":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"pages":     &graphql.InputObjectFieldConfig{Type: graphql.Int},
+	},
+})
+
+// NewSchema builds the GraphQL schema over service.
+func NewSchema(service *services.BookService) (graphql.Schema, error) {
+	r := &resolver{service: service}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"book": &graphql.Field{
+				Type: bookType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: r.book,
+			},
+			"books": &graphql.Field{
+				Type: graphql.NewNonNull(bookPageType),
+				Args: graphql.FieldConfigArgument{
+					"page":   &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: services.DefaultPageSize},
+					"filter": &graphql.ArgumentConfig{Type: bookFilterInput},
+				},
+				Resolve: r.books,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createBook": &graphql.Field{
+				Type: graphql.NewNonNull(bookType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(bookCreateInput)},
+				},
+				Resolve: r.requireEditor(r.createBook),
+			},
+			"updateBook": &graphql.Field{
+				Type: graphql.NewNonNull(bookType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(bookUpdateInput)},
+				},
+				Resolve: r.requireEditor(r.updateBook),
+			},
+			"deleteBook": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: r.requireEditor(r.deleteBook),
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+type resolver struct {
+	service *services.BookService
+}
+
+// requireEditor guards mutations the way the REST routes are guarded.
+func (r *resolver) requireEditor(next graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		principal, ok := auth.PrincipalFromContext(p.Context)
+		if !ok {
+			return nil, &Error{Code: "unauthorized", Message: "A valid API key is required"}
+		}
+		if !principal.HasRole(auth.RoleEditor) {
+			return nil, &Error{Code: "forbidden", Message: "Insufficient permissions"}
+		}
+		return next(p)
+	}
+}
+
+func (r *resolver) book(p graphql.ResolveParams) (interface{}, error) {
+	book, err := r.service.GetByBookID(p.Context, p.Args["id"].(int))
+	if errors.Is(err, services.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	return book, nil
+}
+
+func (r *resolver) books(p graphql.ResolveParams) (interface{}, error) {
+	page, limit := p.Args["page"].(int), p.Args["limit"].(int)
+	if page < 1 || limit < 1 {
+		return nil, &Error{Code: "invalid_pagination", Message: "page and limit must be positive"}
+	}
+	limit = min(limit, services.MaxPageSize)
+
+	var filter models.BookFilter
+	if f, ok := p.Args["filter"].(map[string]interface{}); ok {
+		filter.Search, _ = f["search"].(string)
+		mode, _ := f["search_mode"].(string)
+		filter.SearchMode = models.SearchMode(mode)
+		filter.AuthorExact, _ = f["author_exact"].(string)
+		filter.CreatedAfter = timeArg(f["created_after"])
+		filter.CreatedBefore = timeArg(f["created_before"])
+		filter.UpdatedAfter = timeArg(f["updated_after"])
+		filter.UpdatedBefore = timeArg(f["updated_before"])
+	}
+
+	books, total, err := r.service.FetchAllBook(p.Context, page, limit, filter)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &bookPage{
+		Data:       books,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (total + limit - 1) / limit,
+		TotalItems: total,
+	}, nil
+}
+
+func (r *resolver) createBook(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	published, err := dateArg(input["published"])
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := r.service.CreateBook(p.Context, &models.BookCreateRequest{
+		Title:     input["title"].(string),
+		Author:    input["author"].(string),
+		Published: published,
+		ISBN:      input["isbn"].(string),
+		Pages:     input["pages"].(int),
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	return book, nil
+}
+
+func (r *resolver) updateBook(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	published, err := dateArg(input["published"])
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.BookUpdateRequest{Published: published}
+	req.Title, _ = input["title"].(string)
+	req.Author, _ = input["author"].(string)
+	req.ISBN, _ = input["isbn"].(string)
+	req.Pages, _ = input["pages"].(int)
+
+	book, err := r.service.UpdateBook(p.Context, p.Args["id"].(int), req)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	return book, nil
+}
+
+func (r *resolver) deleteBook(p graphql.ResolveParams) (interface{}, error) {
+	if err := r.service.DeleteBook(p.Context, p.Args["id"].(int), time.Time{}); err != nil {
+		return nil, serviceError(err)
+	}
+	return true, nil
+}
+
+func timeArg(v interface{}) *time.Time {
+	if t, ok := v.(time.Time); ok {
+		return &t
+	}
+	return nil
+}
+
+func dateArg(v interface{}) (models.Date, error) {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return models.Date{}, nil
+	}
+	d, err := models.ParseDate(s)
+	if err != nil {
+		return models.Date{}, &Error{
+			Code:    "validation_error",
+			Message: "Validation failed",
+			Details: []services.FieldError{{Field: "published", Message: "Must be a date in 2006-01-02 format"}},
+		}
+	}
+	return d, nil
+}
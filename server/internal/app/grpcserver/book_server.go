@@ -0,0 +1,143 @@
+// Package grpcserver serves the book API over gRPC for internal callers. Like
+// the REST handlers, it only translates requests and delegates to
+// services.BookService.
+package grpcserver
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/services"
+	bookv1 "bf-api/proto/book/v1"
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type BookServer struct {
+	bookv1.UnimplementedBookServiceServer
+	service *services.BookService
+}
+
+func NewBookServer(service *services.BookService) *BookServer {
+	return &BookServer{service: service}
+}
+
+func (s *BookServer) GetBook(ctx context.Context, req *bookv1.GetBookRequest) (*bookv1.Book, error) {
+	book, err := s.service.GetByBookID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, statusFromError(ctx, err)
+	}
+	return toProto(book), nil
+}
+
+func (s *BookServer) ListBooks(ctx context.Context, req *bookv1.ListBooksRequest) (*bookv1.ListBooksResponse, error) {
+	page, limit := int(req.GetPage()), int(req.GetLimit())
+	if page < 0 || limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page and limit must not be negative")
+	}
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = services.DefaultPageSize
+	}
+	limit = min(limit, services.MaxPageSize)
+
+	books, total, err := s.service.FetchAllBook(ctx, page, limit, models.BookFilter{
+		Search:      req.GetSearch(),
+		AuthorExact: req.GetAuthorExact(),
+	})
+	if err != nil {
+		return nil, statusFromError(ctx, err)
+	}
+
+	resp := &bookv1.ListBooksResponse{
+		Books:      make([]*bookv1.Book, len(books)),
+		Page:       int32(page),
+		Limit:      int32(limit),
+		TotalItems: int32(total),
+		TotalPages: int32((total + limit - 1) / limit),
+	}
+	for i, book := range books {
+		resp.Books[i] = toProto(book)
+	}
+	return resp, nil
+}
+
+func (s *BookServer) CreateBook(ctx context.Context, req *bookv1.CreateBookRequest) (*bookv1.Book, error) {
+	published, err := parsePublished(req.GetPublished())
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.service.CreateBook(ctx, &models.BookCreateRequest{
+		Title:     req.GetTitle(),
+		Author:    req.GetAuthor(),
+		Published: published,
+		ISBN:      req.GetIsbn(),
+		Pages:     int(req.GetPages()),
+	})
+	if err != nil {
+		return nil, statusFromError(ctx, err)
+	}
+	return toProto(book), nil
+}
+
+func (s *BookServer) UpdateBook(ctx context.Context, req *bookv1.UpdateBookRequest) (*bookv1.Book, error) {
+	published, err := parsePublished(req.GetPublished())
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := s.service.UpdateBook(ctx, int(req.GetId()), &models.BookUpdateRequest{
+		Title:     req.GetTitle(),
+		Author:    req.GetAuthor(),
+		Published: published,
+		ISBN:      req.GetIsbn(),
+		Pages:     int(req.GetPages()),
+	})
+	if err != nil {
+		return nil, statusFromError(ctx, err)
+	}
+	return toProto(book), nil
+}
+
+func (s *BookServer) DeleteBook(ctx context.Context, req *bookv1.DeleteBookRequest) (*bookv1.DeleteBookResponse, error) {
+	if err := s.service.DeleteBook(ctx, int(req.GetId()), time.Time{}); err != nil {
+		return nil, statusFromError(ctx, err)
+	}
+	return &bookv1.DeleteBookResponse{}, nil
+}
+
+// parsePublished parses an optional YYYY-MM-DD date; empty leaves it unset.
+func parsePublished(s string) (models.Date, error) {
+	if s == "" {
+		return models.Date{}, nil
+	}
+	d, err := models.ParseDate(s)
+	if err != nil {
+		return models.Date{}, validationStatus(services.ValidationErrors{
+			{Field: "published", Message: "Must be a date in 2006-01-02 format"},
+		})
+	}
+	return d, nil
+}
+
+func toProto(b *models.Book) *bookv1.Book {
+	pb := &bookv1.Book{
+		Id:        int64(b.ID),
+		Title:     b.Title,
+		Author:    b.Author,
+		Isbn:      b.ISBN,
+		Pages:     int32(b.Pages),
+		Stock:     int32(b.Stock),
+		CreatedAt: timestamppb.New(b.CreatedAt),
+		UpdatedAt: timestamppb.New(b.UpdatedAt),
+	}
+	if !b.Published.IsZero() {
+		pb.Published = b.Published.String()
+	}
+	return pb
+}
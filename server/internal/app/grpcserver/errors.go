@@ -0,0 +1,54 @@
+package grpcserver
+
+import (
+	"bf-api/internal/domain/services"
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusFromError maps a BookService error onto the matching gRPC status.
+// Unexpected errors are logged and reported as Internal without detail.
+func statusFromError(ctx context.Context, err error) error {
+	var valErrs services.ValidationErrors
+	switch {
+	case errors.As(err, &valErrs):
+		return validationStatus(valErrs)
+	case errors.Is(err, services.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidInput):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, services.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, services.ErrPrecondition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, services.ErrUnavailable):
+		return status.Error(codes.Unavailable, "Service temporarily unavailable")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "The request did not complete in time")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "The request was canceled")
+	}
+
+	zap.L().Error("unexpected grpc service error", zap.Error(err))
+	return status.Error(codes.Internal, "An unexpected server error occurred")
+}
+
+// validationStatus reports every rejected field as a BadRequest detail.
+func validationStatus(errs services.ValidationErrors) error {
+	st := status.New(codes.InvalidArgument, "Validation failed")
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(errs))
+	for i, fe := range errs {
+		violations[i] = &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message}
+	}
+	if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,114 @@
+package grpcserver
+
+import (
+	"bf-api/internal/domain/services"
+	"bf-api/internal/domain/tenant"
+	"bf-api/internal/infrastructure/auth"
+	bookv1 "bf-api/proto/book/v1"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// writeMethods need the editor role, as the REST write routes do.
+var writeMethods = map[string]bool{
+	bookv1.BookService_CreateBook_FullMethodName: true,
+	bookv1.BookService_UpdateBook_FullMethodName: true,
+	bookv1.BookService_DeleteBook_FullMethodName: true,
+}
+
+// NewServer returns a gRPC server with BookService registered behind the
+// recovery, logging and authentication interceptors.
+func NewServer(service *services.BookService, authCfg auth.Config, logger *zap.Logger) *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		recoverInterceptor(logger),
+		logInterceptor(logger),
+		authInterceptor(authCfg),
+	))
+	bookv1.RegisterBookServiceServer(srv, NewBookServer(service))
+	return srv
+}
+
+func recoverInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("grpc handler panicked", zap.String("method", info.FullMethod), zap.Any("panic", r))
+				err = status.Error(codes.Internal, "An unexpected server error occurred")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func logInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logger.Info("grpc request",
+			zap.String("method", info.FullMethod),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("latency", time.Since(start)),
+		)
+		return resp, err
+	}
+}
+
+// authInterceptor resolves the API key sent as "authorization: Bearer <key>"
+// or "x-api-key" metadata. Reads are open to anonymous callers; an unknown
+// key is always rejected. The tenant is resolved as for HTTP, from the key or
+// "x-tenant-id" metadata.
+func authInterceptor(cfg auth.Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var key, requestedTenant string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("x-api-key"); len(v) > 0 {
+				key = v[0]
+			}
+			if v := md.Get("x-tenant-id"); len(v) > 0 {
+				requestedTenant = v[0]
+			}
+			if v := md.Get("authorization"); len(v) > 0 {
+				if bearer, ok := strings.CutPrefix(v[0], "Bearer "); ok {
+					key = bearer
+				}
+			}
+		}
+
+		if key != "" {
+			principal, ok := cfg.Lookup(key)
+			if !ok {
+				return nil, status.Error(codes.Unauthenticated, "A valid API key is required")
+			}
+			ctx = auth.WithPrincipal(ctx, principal)
+		}
+
+		principal, _ := auth.PrincipalFromContext(ctx)
+		tenantID, err := tenant.Resolve(principal.Tenant, principal.MayChooseTenant(), requestedTenant)
+		switch {
+		case errors.Is(err, tenant.ErrMismatch):
+			return nil, status.Error(codes.PermissionDenied, "The caller may not act on the requested tenant")
+		case err != nil:
+			return nil, status.Error(codes.InvalidArgument, "Invalid x-tenant-id metadata")
+		}
+		ctx = tenant.WithTenant(ctx, tenantID)
+
+		if writeMethods[info.FullMethod] {
+			principal, ok := auth.PrincipalFromContext(ctx)
+			if !ok {
+				return nil, status.Error(codes.Unauthenticated, "A valid API key is required")
+			}
+			if !principal.HasRole(auth.RoleEditor) {
+				return nil, status.Error(codes.PermissionDenied, "Insufficient permissions")
+			}
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bf-api/internal/domain/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SuggestAuthors godoc
+// @Summary Suggest authors
+// @Description Get distinct author names starting with a prefix, ignoring case, for type-ahead search
+// @Tags authors
+// @Produce json
+// @Param prefix query string true "Start of the author name" example(rowl)
+// @Param limit query int false "Maximum number of names; capped at 50" default(10)
+// @Success 200 {object} models.AuthorSuggestResponse
+// @Header 200 {string} Cache-Control "max-age=60, public"
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 429 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Router /authors/suggest [get]
+func (h *BookHandler) SuggestAuthors(c echo.Context) error {
+	limit := 0
+	if raw := c.QueryParam("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 0 {
+			return RespondError(c, ErrorResponse{
+				Error:   ErrCodeInvalidPagination,
+				Code:    http.StatusBadRequest,
+				Message: "Invalid pagination parameters",
+				Details: []ValidationError{{
+					Field:   "limit",
+					Message: "Must be a non-negative integer",
+				}},
+			})
+		}
+	}
+
+	authors, err := h.service.SuggestAuthors(c.Request().Context(), c.QueryParam("prefix"), limit)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "max-age=60, public")
+	if enveloped(c) || negotiateJSONAPI(c) {
+		return respond(c, http.StatusOK, authors, nil)
+	}
+	return c.JSON(http.StatusOK, models.AuthorSuggestResponse{Data: authors})
+}
+
+// CreateAuthor godoc
+// @Summary Add a known author
+// @Description Register an author as known to the tenant, so books by them pass a strict author check before any is stored. The authors of stored books are known without this.
+// @Tags authors
+// @Accept json
+// @Produce json
+// @Param author body models.AuthorCreateRequest true "Author name"
+// @Success 200 {object} models.AuthorResponse "The author was already known"
+// @Success 201 {object} models.AuthorResponse "The author was added"
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
+// @Failure 413 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 429 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /authors [post]
+func (h *BookHandler) CreateAuthor(c echo.Context) error {
+	var req models.AuthorCreateRequest
+	if err := c.Bind(&req); err != nil {
+		if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+			return err
+		}
+
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidRequest,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request payload",
+		})
+	}
+
+	name, created, err := h.service.AddAuthor(c.Request().Context(), &req)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	return respond(c, status, models.AuthorResponse{Name: name}, nil)
+}
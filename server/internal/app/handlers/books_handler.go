@@ -1,14 +1,24 @@
 package handlers
 
 import (
-	"bf-api/internal/app/middleware"
+	"bf-api/internal/app/pagination"
+	"bf-api/internal/domain/filterexpr"
 	"bf-api/internal/domain/models"
 	"bf-api/internal/domain/services"
+	"bf-api/internal/domain/tenant"
+	"bf-api/internal/infrastructure/auth"
+	"bf-api/internal/infrastructure/tracing"
 
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
+	"path"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -18,24 +28,49 @@ import (
 
 type (
 	BookHandler struct {
-		service   *services.BookService
-		validator *validator.Validate
-		logger    *zap.Logger
+		service    *services.BookService
+		validator  *validator.Validate
+		logger     *zap.Logger
+		pagination Pagination
 	}
 
+	// Pagination bounds the page size of list endpoints.
+	Pagination = pagination.Limits
+
+	BookHandlerOption func(*BookHandler)
+
 	ValidationError struct {
 		Field   string `json:"field"`
 		Message string `json:"message"`
 	}
 )
 
-func NewBookHandler(s *services.BookService, logger *zap.Logger) *BookHandler {
+// WithPagination overrides the default list page sizes. MaxLimit is capped
+// at services.MaxPageSize.
+func WithPagination(p Pagination) BookHandlerOption {
+	return func(h *BookHandler) {
+		if p.MaxLimit > 0 {
+			h.pagination.MaxLimit = min(p.MaxLimit, services.MaxPageSize)
+		}
+		if p.DefaultLimit > 0 {
+			h.pagination.DefaultLimit = p.DefaultLimit
+		}
+		h.pagination.DefaultLimit = min(h.pagination.DefaultLimit, h.pagination.MaxLimit)
+	}
+}
 
-	return &BookHandler{
-		service:   s,
-		validator: validator.New(),
-		logger:    logger,
+func NewBookHandler(s *services.BookService, logger *zap.Logger, opts ...BookHandlerOption) *BookHandler {
+	h := &BookHandler{
+		service:    s,
+		validator:  validator.New(),
+		logger:     logger,
+		pagination: pagination.DefaultLimits,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 func (h *BookHandler) LogRequest(next echo.HandlerFunc) echo.HandlerFunc {
@@ -69,22 +104,41 @@ func (h *BookHandler) LogRequest(next echo.HandlerFunc) echo.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Param book body models.BookCreateRequest true "Book data"
-// @Success 201 {object} models.Book
+// @Param Idempotency-Key header string false "Replays the original response when a request is retried within 24h"
+// @Param Prefer header string false "return=minimal sends 201 with no body; return=representation (default) the created book" Enums(return=minimal, return=representation)
+// @Param dry_run query bool false "Validate without storing; returns the book that would be created"
+// @Param warn_duplicates query bool false "Also return warnings about existing books with the same title and author, ignoring case; creation is never blocked"
+// @Success 200 {object} models.BookDryRunResponse "Dry run result"
+// @Success 201 {object} models.BookCreateResponse "The created book; warnings are only included with warn_duplicates or when a lenient author check flags the author"
+// @Header 201 {string} Location "URL of the created book"
+// @Header 201 {string} ETag "Entity tag of the created book"
+// @Header 201 {string} Idempotent-Replayed "true when the response was replayed for a repeated Idempotency-Key"
+// @Header 201 {string} Preference-Applied "The return preference honored, if one was sent"
 // @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
 // @Failure 409 {object} handlers.ErrorResponse
+// @Failure 413 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
 // @Failure 429 {object} handlers.ErrorResponse
 // @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /books [post]
 func (h *BookHandler) CreateBook(c echo.Context) error {
 	var req models.BookCreateRequest
 	if err := c.Bind(&req); err != nil {
+		if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+			return err
+		}
+
 		h.logger.Warn("failed to bind request",
 			zap.Error(err),
-			zap.Any("request_body", c.Request().Body),
+			zap.String("trace_id", getTraceID(c.Request().Context())),
 		)
 
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_request",
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidRequest,
 			Code:    http.StatusBadRequest,
 			Message: "Invalid request payload",
 			Details: []ValidationError{{
@@ -94,40 +148,74 @@ func (h *BookHandler) CreateBook(c echo.Context) error {
 		})
 	}
 
-	// invalidate request using validator
-	if err := h.validator.Struct(req); err != nil {
-		var validationErrors []ValidationError
-		for _, err := range err.(validator.ValidationErrors) {
-			validationErrors = append(validationErrors, ValidationError{
-				Field:   err.Field(),
-				Message: validationMessage(err),
-			})
+	if isDryRun(c) {
+		book, err := h.service.PreviewCreateBook(c.Request().Context(), &req)
+		if err != nil {
+			return handleServiceError(c, h.logger, err)
 		}
-
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "validation_error",
-			Code:    http.StatusUnprocessableEntity,
-			Message: "Validation failed",
-			Details: validationErrors,
-		})
+		return respond(c, http.StatusOK, models.BookDryRunResponse{Book: book, DryRun: true}, nil)
 	}
 
-	h.logger.Debug("create book request validated",
-		zap.Any("request", req),
-	)
+	// looked up before the book is stored so it is not matched against
+	// itself; a failed lookup only costs the warnings
+	warnings, err := h.service.AuthorWarnings(c.Request().Context(), req.Author)
+	if err != nil {
+		h.logger.Warn("failed to match the author against known authors",
+			zap.Error(err),
+			zap.String("trace_id", getTraceID(c.Request().Context())),
+		)
+	}
 
-	book, err := h.service.CreateBook(c.Request().Context(), &req)
+	var book *models.Book
+	var replayed bool
+	if key := c.Request().Header.Get("Idempotency-Key"); key != "" {
+		book, replayed, err = h.service.CreateBookIdempotent(c.Request().Context(), key, &req)
+	} else {
+		book, err = h.service.CreateBook(c.Request().Context(), &req)
+	}
 	if err != nil {
 		return handleServiceError(c, h.logger, err)
 	}
 
 	c.Response().Header().Set("Cache-Control", "no-store")
+	// relative to the request so v2 clients are sent to the v2 resource
+	c.Response().Header().Set(echo.HeaderLocation, path.Join(c.Request().URL.Path, strconv.Itoa(book.ID)))
+	c.Response().Header().Set("ETag", generateETag(book))
+	minimal := applyPreferReturn(c)
 
-	h.logger.Info("book created successfully",
-		zap.Int("book_id", book.ID),
-		zap.String("isbn", book.ISBN),
-	)
-	return c.JSON(http.StatusCreated, book)
+	if replayed {
+		c.Response().Header().Set("Idempotent-Replayed", "true")
+	} else {
+		h.logger.Info("book created successfully",
+			zap.Int("book_id", book.ID),
+			zap.String("isbn", book.ISBN),
+		)
+	}
+
+	if minimal {
+		return c.NoContent(http.StatusCreated)
+	}
+
+	warnDuplicates, _ := strconv.ParseBool(c.QueryParam("warn_duplicates"))
+	if warnDuplicates {
+		// the book is already stored, so a failed lookup only costs the warnings
+		dupes, err := h.service.DuplicateTitleWarnings(c.Request().Context(), book)
+		if err != nil {
+			h.logger.Warn("failed to look up duplicate titles",
+				zap.Int("book_id", book.ID),
+				zap.Error(err),
+				zap.String("trace_id", getTraceID(c.Request().Context())),
+			)
+		}
+		warnings = append(warnings, dupes...)
+	}
+	if warnDuplicates || len(warnings) > 0 {
+		if warnings == nil {
+			warnings = []string{}
+		}
+		return respond(c, http.StatusCreated, models.BookCreateResponse{Book: book, Warnings: warnings}, nil)
+	}
+	return respond(c, http.StatusCreated, book, nil)
 }
 
 // GetBook godoc
@@ -137,6 +225,7 @@ func (h *BookHandler) CreateBook(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Book ID"
+// @Param fields query string false "Comma-separated list of fields to return" example(title,author)
 // @Success 200 {object} models.Book
 // @Header 200 {string} Cache-Control "max-age=3600, public"
 // @Failure 400 {object} handlers.ErrorResponse
@@ -147,9 +236,9 @@ func (h *BookHandler) CreateBook(c echo.Context) error {
 func (h *BookHandler) GetBook(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil || id <= 0 {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_id",
-			Code:    http.StatusNotFound,
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidID,
+			Code:    http.StatusBadRequest,
 			Message: "Invalid book ID",
 			Details: []ValidationError{{
 				Field:   "id",
@@ -158,6 +247,11 @@ func (h *BookHandler) GetBook(c echo.Context) error {
 		})
 	}
 
+	fields, fieldErrs := parseFields(c.QueryParam("fields"))
+	if fieldErrs != nil {
+		return invalidFieldsResponse(c, fieldErrs)
+	}
+
 	book, err := h.service.GetByBookID(c.Request().Context(), id)
 	if err != nil {
 		return handleServiceError(c, h.logger, err)
@@ -166,7 +260,195 @@ func (h *BookHandler) GetBook(c echo.Context) error {
 	c.Response().Header().Set("Cache-Control", "max-age=3600, public")
 	c.Response().Header().Set("ETag", generateETag(book))
 
-	return c.JSON(http.StatusOK, book)
+	if fields != nil {
+		return respond(c, http.StatusOK, bookProjection{book, fields}, nil)
+	}
+	return respond(c, http.StatusOK, book, nil)
+}
+
+// GetBookByISBN godoc
+// @Summary Get a book by ISBN
+// @Description Get a single book by its ISBN; hyphens, spaces and a lowercase check digit are accepted
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param isbn path string true "ISBN" example(978-3-16-148410-0)
+// @Param fields query string false "Comma-separated list of fields to return" example(title,author)
+// @Success 200 {object} models.Book
+// @Header 200 {string} Cache-Control "max-age=3600, public"
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 404 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 429 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Router /books/by-isbn/{isbn} [get]
+func (h *BookHandler) GetBookByISBN(c echo.Context) error {
+	fields, fieldErrs := parseFields(c.QueryParam("fields"))
+	if fieldErrs != nil {
+		return invalidFieldsResponse(c, fieldErrs)
+	}
+
+	book, err := h.service.GetByISBN(c.Request().Context(), c.Param("isbn"))
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "max-age=3600, public")
+	c.Response().Header().Set("ETag", generateETag(book))
+
+	if fields != nil {
+		return respond(c, http.StatusOK, bookProjection{book, fields}, nil)
+	}
+	return respond(c, http.StatusOK, book, nil)
+}
+
+// ISBNAvailable godoc
+// @Summary Check whether an ISBN is available
+// @Description Tell whether no active book has the ISBN yet, so a new book can use it, and otherwise which book has it. Hyphens, spaces and a lowercase check digit are accepted.
+// @Tags books
+// @Produce json
+// @Param isbn query string true "ISBN" example(978-3-16-148410-0)
+// @Success 200 {object} models.ISBNAvailabilityResponse
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 429 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Router /books/isbn-available [get]
+func (h *BookHandler) ISBNAvailable(c echo.Context) error {
+	isbn := c.QueryParam("isbn")
+	if strings.TrimSpace(isbn) == "" {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidParameter,
+			Code:    http.StatusBadRequest,
+			Message: "Missing isbn parameter",
+			Details: []ValidationError{{Field: "isbn", Message: "This parameter is required"}},
+		})
+	}
+
+	availability, err := h.service.ISBNAvailability(c.Request().Context(), isbn)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	return respond(c, http.StatusOK, availability, nil)
+}
+
+// BookHistory godoc
+// @Summary Get a book's change history
+// @Description List the audit trail of a book, oldest change first, including changes made before it was deleted. Needs the editor role since entries name their actors and carry every past value.
+// @Tags books
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {object} models.BookHistoryResponse
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
+// @Failure 404 {object} handlers.ErrorResponse
+// @Failure 429 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /books/{id}/history [get]
+func (h *BookHandler) BookHistory(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidID,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid book ID",
+			Details: []ValidationError{{
+				Field:   "id",
+				Message: "Must be a positive integer",
+			}},
+		})
+	}
+
+	entries, err := h.service.BookHistory(c.Request().Context(), id)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	if negotiateJSONAPI(c) {
+		// audit entries are not resources; meta must be an object
+		return respondJSONAPI(c, http.StatusOK, JSONAPIDocument{Meta: models.BookHistoryResponse{Data: entries}})
+	}
+	if enveloped(c) {
+		return respond(c, http.StatusOK, entries, nil)
+	}
+	return c.JSON(http.StatusOK, models.BookHistoryResponse{Data: entries})
+}
+
+// GetBookConfidential godoc
+// @Summary Get a book's confidential fields
+// @Description Get the decrypted acquisition cost and supplier notes of a book, which other reads never include. Admin only.
+// @Tags books
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {object} models.BookConfidentialResponse
+// @Header 200 {string} Cache-Control "no-store"
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
+// @Failure 404 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /books/{id}/confidential [get]
+func (h *BookHandler) GetBookConfidential(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidID,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid book ID",
+			Details: []ValidationError{{
+				Field:   "id",
+				Message: "Must be a positive integer",
+			}},
+		})
+	}
+
+	confidential, err := h.service.GetConfidential(c.Request().Context(), id)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	// decrypted values must not linger in any cache
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return respond(c, http.StatusOK, confidential, nil)
+}
+
+// HeadBook godoc
+// @Summary Check a book exists
+// @Description Report a book's existence and freshness via headers, without a body
+// @Tags books
+// @Param id path int true "Book ID"
+// @Success 200
+// @Header 200 {string} ETag "Entity tag of the current version"
+// @Header 200 {string} Last-Modified "Time of the last update"
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /books/{id} [head]
+func (h *BookHandler) HeadBook(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	meta, err := h.service.GetBookMeta(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return c.NoContent(http.StatusNotFound)
+		}
+		return handleServiceError(c, h.logger, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "max-age=3600, public")
+	c.Response().Header().Set("ETag", etag(meta.ID, meta.UpdatedAt))
+	c.Response().Header().Set("Last-Modified", meta.UpdatedAt.UTC().Format(http.TimeFormat))
+
+	return c.NoContent(http.StatusOK)
 }
 
 // FetchAllBook godoc
@@ -176,50 +458,257 @@ func (h *BookHandler) GetBook(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number" default(1)
-// @Param limit query int false "Items per page" default(20)
+// @Param limit query int false "Items per page; clamped to the configured maximum (100 by default)" default(20)
+// @Param fields query string false "Comma-separated list of fields to return" example(title,author)
+// @Param search query string false "Search text matched against title and author"
+// @Param search_mode query string false "fulltext (ranked, default) or prefix" Enums(fulltext, prefix)
+// @Param author_exact query string false "Exact author name"
+// @Param created_after query string false "Only books created at or after this RFC3339 time"
+// @Param created_before query string false "Only books created before this RFC3339 time"
+// @Param updated_after query string false "Only books updated at or after this RFC3339 time"
+// @Param updated_before query string false "Only books updated before this RFC3339 time"
+// @Param updated_since query string false "Incremental sync: books changed after this RFC3339 time, including deleted ones, oldest first"
+// @Param filter query string false "Filter expression over title, author, isbn (= !=), pages, stock and published (= != > >= < <=), joined by AND and OR with parentheses; quote values with spaces; published takes a year or a date" example(author="Frank Herbert" AND pages>300)
+// @Param include_deleted query bool false "Also return soft-deleted books; admin only"
+// @Param only_deleted query bool false "Return only soft-deleted books; admin only"
+// @Param snapshot_at query string false "Hide books created after this RFC3339 time; defaults to the request time and is echoed back so later pages stay stable as books are added"
+// @Param ids query string false "Comma-separated IDs of up to 100 books to fetch in that order; pagination and filters are ignored and the body is a models.BookBatchGetResponse" example(1,5,9)
 // @Success 200 {object} models.BookListResponse
 // @Header 200 {string} Cache-Control "max-age=60, public"
+// @Param If-None-Match header string false "ETag of a cached copy of this page; 304 if it is unchanged"
+// @Header 200 {string} ETag "Weak entity tag of the page"
+// @Header 200 {string} X-Total-Count "Number of books matching the filters"
+// @Success 304 "Not Modified"
+// @Header 200 {string} Link "RFC 5988 links to the first, prev, next and last pages"
 // @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
 // @Failure 429 {object} handlers.ErrorResponse
 // @Failure 500 {object} handlers.ErrorResponse
 // @Router /books [get]
 func (h *BookHandler) ListBooks(c echo.Context) error {
-	page, _ := strconv.Atoi(c.QueryParam("page"))
-	if page < 1 {
-		page = 1
+	if raw := c.QueryParam("ids"); raw != "" {
+		return h.getBooksByIDs(c, raw)
 	}
 
-	limit, _ := strconv.Atoi(c.QueryParam("limit"))
-	if limit < 1 || limit > 100 {
-		limit = 20
+	params, err := h.pagination.Parse(c)
+	if err != nil {
+		return invalidPaginationResponse(c, err)
 	}
 
-	type QueryParams struct {
-		Page  int `validate:"min=1"`
-		Limit int `validate:"min=1,max=100"`
+	fields, fieldErrs := parseFields(c.QueryParam("fields"))
+	if fieldErrs != nil {
+		return invalidFieldsResponse(c, fieldErrs)
 	}
 
-	params := QueryParams{Page: page, Limit: limit}
-	if err := h.validator.Struct(params); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_pagination",
+	filter, filterErrs := parseBookFilter(c)
+	if filterErrs != nil {
+		return invalidFilterResponse(c, filterErrs)
+	}
+	if filter.Deleted != models.DeletedFilterActive && !canViewDeleted(c) {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeForbidden,
+			Code:    http.StatusForbidden,
+			Message: "Listing deleted books requires the admin role",
+		})
+	}
+	// pin the first page to the request time; the links carry it forward.
+	// Incremental sync pages by updated_at and needs no snapshot.
+	if filter.SnapshotAt == nil && filter.UpdatedSince == nil {
+		now := h.service.Now().UTC().Truncate(time.Microsecond) // Postgres precision
+		filter.SnapshotAt = &now
+	}
+	if filter.SnapshotAt != nil {
+		params = params.With("snapshot_at", filter.SnapshotAt.Format(time.RFC3339Nano))
+	}
+
+	books, total, err := h.service.FetchAllBook(c.Request().Context(), params.Page, params.Limit, filter)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	page := pagination.BuildResponse(total, params)
+	resp := models.BookListResponse{
+		Data:       books,
+		TotalPages: page.TotalPages,
+		TotalItems: total,
+		Page:       page.Page,
+		Limit:      page.Limit,
+		Links:      page.Links,
+		SnapshotAt: filter.SnapshotAt,
+		Warning:    params.Warning,
+	}
+
+	tag := listETag(c, books, total)
+	c.Response().Header().Set("Cache-Control", "max-age=60, public")
+	c.Response().Header().Set("ETag", tag)
+	if etagMatches(c.Request().Header.Get("If-None-Match"), tag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(total))
+	if link := page.LinkHeader(); link != "" {
+		c.Response().Header().Set("Link", link)
+	}
+
+	var data interface{} = books
+	if fields != nil {
+		projected := make([]map[string]interface{}, len(books))
+		for i, book := range books {
+			projected[i] = projectBook(book, fields)
+		}
+		data = projected
+	}
+
+	if negotiateJSONAPI(c) {
+		return respondJSONAPI(c, http.StatusOK, JSONAPIDocument{
+			Data:  bookResources(c, books, fields),
+			Links: jsonAPIListLinks(resp.Links),
+			Meta: JSONAPIListMeta{
+				Page:       page.Page,
+				PerPage:    page.Limit,
+				TotalPages: page.TotalPages,
+				TotalItems: total,
+				SnapshotAt: filter.SnapshotAt,
+				Warning:    params.Warning,
+			},
+		})
+	}
+
+	if enveloped(c) {
+		return respond(c, http.StatusOK, data, ListMeta{
+			Page:       page.Page,
+			PerPage:    page.Limit,
+			TotalPages: page.TotalPages,
+			TotalItems: total,
+			Links:      resp.Links,
+			SnapshotAt: filter.SnapshotAt,
+			Warning:    params.Warning,
+		})
+	}
+
+	if fields != nil {
+		// the outer Data shadows the embedded one when encoded
+		return c.JSON(http.StatusOK, struct {
+			models.BookListResponse
+			Data interface{} `json:"data"`
+		}{resp, data})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// getBooksByIDs serves GET /books?ids=, returning exactly the requested books
+// in the requested order.
+func (h *BookHandler) getBooksByIDs(c echo.Context, raw string) error {
+	ids, err := parseIDList(raw)
+	if err != nil {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidIDs,
 			Code:    http.StatusBadRequest,
-			Message: "Invalid pagination parameters",
+			Message: "Invalid ids parameter",
+			Details: []ValidationError{{
+				Field:   "ids",
+				Message: "Must be a comma-separated list of positive integers",
+			}},
 		})
 	}
 
-	books, total, err := h.service.FetchAllBook(c.Request().Context(), page, limit)
+	fields, fieldErrs := parseFields(c.QueryParam("fields"))
+	if fieldErrs != nil {
+		return invalidFieldsResponse(c, fieldErrs)
+	}
+
+	result, err := h.service.GetByIDs(c.Request().Context(), ids)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			return RespondError(c, ErrorResponse{
+				Error:   ErrCodeInvalidIDs,
+				Code:    http.StatusBadRequest,
+				Message: "Between 1 and 100 positive book IDs are required",
+				Details: []ValidationError{{
+					Field:   "ids",
+					Message: "Must contain 1 to 100 positive integers",
+				}},
+			})
+		}
 		return handleServiceError(c, h.logger, err)
 	}
 
 	c.Response().Header().Set("Cache-Control", "max-age=60, public")
-	return c.JSON(http.StatusOK, models.BookListResponse{
-		Data:       books,
-		TotalPages: total,
-		Page:       page,
-		Limit:      limit,
-	})
+
+	meta := map[string][]int{"not_found": result.NotFound}
+	if negotiateJSONAPI(c) {
+		return respondJSONAPI(c, http.StatusOK, JSONAPIDocument{
+			Data: bookResources(c, result.Data, fields),
+			Meta: meta,
+		})
+	}
+
+	var data interface{} = result.Data
+	if fields != nil {
+		projected := make([]bookProjection, len(result.Data))
+		for i, book := range result.Data {
+			projected[i] = bookProjection{book, fields}
+		}
+		data = projected
+	}
+
+	if enveloped(c) {
+		return respond(c, http.StatusOK, data, meta)
+	}
+	if fields != nil {
+		return c.JSON(http.StatusOK, struct {
+			Data     interface{} `json:"data"`
+			NotFound []int       `json:"not_found"`
+		}{data, result.NotFound})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// CountBooks godoc
+// @Summary Count books
+// @Description Get the number of active books matching the same filters as the list endpoint
+// @Tags books
+// @Produce json
+// @Param search query string false "Search text matched against title and author"
+// @Param search_mode query string false "fulltext (ranked, default) or prefix" Enums(fulltext, prefix)
+// @Param author_exact query string false "Exact author name"
+// @Param created_after query string false "Only books created at or after this RFC3339 time"
+// @Param created_before query string false "Only books created before this RFC3339 time"
+// @Param updated_after query string false "Only books updated at or after this RFC3339 time"
+// @Param updated_before query string false "Only books updated before this RFC3339 time"
+// @Param updated_since query string false "Incremental sync: books changed after this RFC3339 time, including deleted ones, oldest first"
+// @Param filter query string false "Filter expression over title, author, isbn (= !=), pages, stock and published (= != > >= < <=), joined by AND and OR with parentheses; quote values with spaces; published takes a year or a date" example(author="Frank Herbert" AND pages>300)
+// @Param include_deleted query bool false "Also return soft-deleted books; admin only"
+// @Param only_deleted query bool false "Return only soft-deleted books; admin only"
+// @Param snapshot_at query string false "Only count books created at or before this RFC3339 time"
+// @Success 200 {object} models.BookCountResponse
+// @Header 200 {string} Cache-Control "max-age=60, public"
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
+// @Failure 429 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Router /books/count [get]
+func (h *BookHandler) CountBooks(c echo.Context) error {
+	filter, filterErrs := parseBookFilter(c)
+	if filterErrs != nil {
+		return invalidFilterResponse(c, filterErrs)
+	}
+	if filter.Deleted != models.DeletedFilterActive && !canViewDeleted(c) {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeForbidden,
+			Code:    http.StatusForbidden,
+			Message: "Listing deleted books requires the admin role",
+		})
+	}
+
+	total, err := h.service.CountBooks(c.Request().Context(), filter)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "max-age=60, public")
+	return respond(c, http.StatusOK, models.BookCountResponse{Count: total}, nil)
 }
 
 // UpdateBook godoc
@@ -230,37 +719,64 @@ func (h *BookHandler) ListBooks(c echo.Context) error {
 // @Produce json
 // @Param id path int true "Book ID"
 // @Param book body models.BookUpdateRequest true "Book data"
+// @Param dry_run query bool false "Validate without storing; returns the book as it would be updated"
+// @Param Prefer header string false "return=minimal sends 204 with no body; return=representation (default) the updated book" Enums(return=minimal, return=representation)
 // @Success 200 {object} models.Book
+// @Success 204 "Updated; sent for Prefer: return=minimal"
+// @Header 200,204 {string} Preference-Applied "The return preference honored, if one was sent"
+// @Header 204 {string} Location "URL of the updated book"
 // @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
 // @Failure 404 {object} handlers.ErrorResponse
 // @Failure 403 {object} handlers.ErrorResponse
+// @Failure 413 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
 // @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /books/{id} [put]
 func (h *BookHandler) UpdateBook(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil || id <= 0 {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_id",
-			Code:    http.StatusNotFound,
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidID,
+			Code:    http.StatusBadRequest,
 			Message: "Invalid book ID",
 		})
 	}
 
 	var req models.BookUpdateRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_request",
+		if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+			return err
+		}
+
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidRequest,
 			Code:    http.StatusBadRequest,
 			Message: "Invalid request payload",
 		})
 	}
 
+	if isDryRun(c) {
+		book, err := h.service.PreviewUpdateBook(c.Request().Context(), id, &req)
+		if err != nil {
+			return handleServiceError(c, h.logger, err)
+		}
+		return respond(c, http.StatusOK, models.BookDryRunResponse{Book: book, DryRun: true}, nil)
+	}
+
 	book, err := h.service.UpdateBook(c.Request().Context(), id, &req)
 	if err != nil {
 		return handleServiceError(c, h.logger, err)
 	}
 
-	return c.JSON(http.StatusOK, book)
+	if applyPreferReturn(c) {
+		c.Response().Header().Set(echo.HeaderLocation, c.Request().URL.Path)
+		c.Response().Header().Set("ETag", generateETag(book))
+		return c.NoContent(http.StatusNoContent)
+	}
+	return respond(c, http.StatusOK, book, nil)
 }
 
 // DeleteBook godoc
@@ -270,68 +786,392 @@ func (h *BookHandler) UpdateBook(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Book ID"
+// @Param If-Unmodified-Since header string false "Only delete if the book has not changed since this HTTP date"
 // @Success 204
 // @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
 // @Failure 404 {object} handlers.ErrorResponse
 // @Failure 403 {object} handlers.ErrorResponse
+// @Failure 412 {object} handlers.ErrorResponse
 // @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /books/{id} [delete]
 func (h *BookHandler) DeleteBook(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil || id <= 0 {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_id",
-			Code:    http.StatusNotFound,
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidID,
+			Code:    http.StatusBadRequest,
 			Message: "Invalid book ID",
 		})
 	}
 
-	if err := h.service.DeleteBook(c.Request().Context(), id); err != nil {
+	// an unparsable date must be ignored (RFC 9110 13.1.4)
+	unmodifiedSince, _ := http.ParseTime(c.Request().Header.Get("If-Unmodified-Since"))
+
+	if err := h.service.DeleteBook(c.Request().Context(), id, unmodifiedSince); err != nil {
 		return handleServiceError(c, h.logger, err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
+// BatchDeleteBooks godoc
+// @Summary Delete several books
+// @Description Soft delete up to 100 books by ID, given either as the ids query parameter or a JSON body
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param ids query string false "Comma-separated book IDs" example(1,2,3)
+// @Param body body models.BookBatchDeleteRequest false "Book IDs"
+// @Success 200 {object} models.BookBatchDeleteResponse
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
+// @Failure 413 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /books [delete]
+func (h *BookHandler) BatchDeleteBooks(c echo.Context) error {
+	var req models.BookBatchDeleteRequest
+	if raw := c.QueryParam("ids"); raw != "" {
+		ids, err := parseIDList(raw)
+		if err != nil {
+			return RespondError(c, ErrorResponse{
+				Error:   ErrCodeInvalidIDs,
+				Code:    http.StatusBadRequest,
+				Message: "Invalid ids parameter",
+				Details: []ValidationError{{
+					Field:   "ids",
+					Message: "Must be a comma-separated list of positive integers",
+				}},
+			})
+		}
+		req.IDs = ids
+	} else if err := (&echo.DefaultBinder{}).BindBody(c, &req); err != nil {
+		if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+			return err
+		}
+
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidRequest,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request payload",
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidIDs,
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Between 1 and 100 positive book IDs are required",
+			Details: []ValidationError{{
+				Field:   "ids",
+				Message: "Must contain 1 to 100 positive integers",
+			}},
+		})
+	}
+
+	result, err := h.service.DeleteBooks(c.Request().Context(), req.IDs)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	return respond(c, http.StatusOK, result, nil)
+}
+
 type ErrorResponse struct {
-	Error   string            `json:"error" example:"not found"`
+	Error   ErrorCode         `json:"error" example:"not_found"`
 	Message string            `json:"message" example:"book not found"`
 	Code    int               `json:"code" example:"404"`
 	Details []ValidationError `json:"details"`
 }
 
+// UpsertBooks godoc
+// @Summary Create or update books by ISBN
+// @Description Upsert up to 100 books keyed on ISBN in one transaction; every item is validated first
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param body body models.BookBulkUpsertRequest true "Books to upsert"
+// @Success 200 {object} models.BookBulkUpsertResponse
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
+// @Failure 413 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /books/bulk [put]
+func (h *BookHandler) UpsertBooks(c echo.Context) error {
+	var req models.BookBulkUpsertRequest
+	if err := c.Bind(&req); err != nil {
+		if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+			return err
+		}
+
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidRequest,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request payload",
+		})
+	}
+
+	result, err := h.service.UpsertBooks(c.Request().Context(), req.Books)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	return respond(c, http.StatusOK, result, nil)
+}
+
+// ValidateBooks godoc
+// @Summary Validate books
+// @Description Check up to 1000 books against the create rules, including duplicate ISBNs within the payload, without storing anything. ISBNs already in the store are not checked.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param body body []models.BookCreateRequest true "Books to validate"
+// @Success 200 {object} models.BookValidateResponse
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 413 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Router /books/validate [post]
+func (h *BookHandler) ValidateBooks(c echo.Context) error {
+	var reqs []models.BookCreateRequest
+	if err := c.Bind(&reqs); err != nil {
+		if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+			return err
+		}
+
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidRequest,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request payload; expected a JSON array of books",
+		})
+	}
+
+	result, err := h.service.ValidateBooks(reqs)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	return respond(c, http.StatusOK, result, nil)
+}
+
+// RestockBook godoc
+// @Summary Restock a book
+// @Description Add units to the stock of a book
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param body body models.BookRestockRequest true "Units to add"
+// @Success 200 {object} models.Book
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
+// @Failure 404 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /books/{id}/restock [post]
+func (h *BookHandler) RestockBook(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidID,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid book ID",
+		})
+	}
+
+	var req models.BookRestockRequest
+	if err := c.Bind(&req); err != nil {
+		if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+			return err
+		}
+
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidRequest,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request payload",
+		})
+	}
+
+	book, err := h.service.RestockBook(c.Request().Context(), id, req.Amount)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	c.Response().Header().Set("ETag", generateETag(book))
+	return respond(c, http.StatusOK, book, nil)
+}
+
+// LowStockBooks godoc
+// @Summary List low-stock books
+// @Description Get books with fewer units in stock than the threshold, lowest stock first
+// @Tags books
+// @Produce json
+// @Param threshold query int false "Stock threshold" default(10)
+// @Param limit query int false "Maximum number of books" default(100)
+// @Success 200 {object} models.BookLowStockResponse
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Router /books/low-stock [get]
+func (h *BookHandler) LowStockBooks(c echo.Context) error {
+	threshold := 10
+	if raw := c.QueryParam("threshold"); raw != "" {
+		var err error
+		if threshold, err = strconv.Atoi(raw); err != nil {
+			return RespondError(c, ErrorResponse{
+				Error:   ErrCodeInvalidThreshold,
+				Code:    http.StatusBadRequest,
+				Message: "Threshold must be an integer",
+			})
+		}
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	books, err := h.service.LowStockBooks(c.Request().Context(), threshold, limit)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	if enveloped(c) || negotiateJSONAPI(c) {
+		return respond(c, http.StatusOK, books, map[string]int{"threshold": threshold})
+	}
+	return c.JSON(http.StatusOK, models.BookLowStockResponse{
+		Data:      books,
+		Threshold: threshold,
+	})
+}
+
+// PurgeDeletedBooks godoc
+// @Summary Purge deleted books
+// @Description Permanently remove books soft-deleted longer ago than older_than. Admin only; meant for scheduled cleanup.
+// @Tags books
+// @Produce json
+// @Param older_than query string true "Minimum time since deletion, in days (30d) or as a Go duration (720h); at least 7d" example(30d)
+// @Success 200 {object} models.BookPurgeResponse
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /books/trash [delete]
+func (h *BookHandler) PurgeDeletedBooks(c echo.Context) error {
+	olderThan, err := parseRetention(c.QueryParam("older_than"))
+	if err != nil {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidRetention,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid older_than parameter",
+			Details: []ValidationError{{
+				Field:   "older_than",
+				Message: err.Error(),
+			}},
+		})
+	}
+
+	purged, err := h.service.PurgeDeleted(c.Request().Context(), olderThan)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	h.logger.Info("purged deleted books",
+		zap.Int("purged", purged),
+		zap.Duration("older_than", olderThan),
+		zap.String("trace_id", getTraceID(c.Request().Context())),
+	)
+	return respond(c, http.StatusOK, models.BookPurgeResponse{Purged: purged}, nil)
+}
+
 // Helper functions
-func validationMessage(fieldError validator.FieldError) string {
-	switch fieldError.Tag() {
-	case "required":
-		return "This field is required"
-	case "min":
-		return "Value too small"
-	case "max":
-		return "Value too large"
-	case "email":
-		return "Invalid email format"
-	default:
-		return fieldError.Error()
+
+// parseRetention parses a retention window given in whole days ("30d") or as
+// a Go duration ("720h").
+func parseRetention(raw string) (time.Duration, error) {
+	if raw == "" {
+		return 0, errors.New("This parameter is required")
+	}
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, errors.New("Must be a positive number of days, e.g. 30d")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, errors.New("Must be a positive duration, e.g. 30d or 720h")
 	}
+	return d, nil
 }
 
+// isDryRun reports whether the request asks for validation only.
+func isDryRun(c echo.Context) bool {
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+	return dryRun
+}
+
+// validationErrorResponse renders field validation failures as 422; 400 is
+// reserved for requests that could not be parsed at all.
+func validationErrorResponse(c echo.Context, errs services.ValidationErrors) error {
+	details := make([]ValidationError, len(errs))
+	for i, fe := range errs {
+		details[i] = ValidationError{Field: fe.Field, Message: fe.Message}
+	}
+
+	return RespondError(c, ErrorResponse{
+		Error:   ErrCodeValidation,
+		Code:    http.StatusUnprocessableEntity,
+		Message: "Validation failed",
+		Details: details,
+	})
+}
+
+// unknownAuthorResponse renders authors rejected by a strict author check as
+// 400: the book is well-formed, but the author must be registered first or
+// corrected to one of the suggested names.
+func unknownAuthorResponse(c echo.Context, e *services.UnknownAuthorError) error {
+	details := make([]ValidationError, len(e.Fields))
+	for i, fe := range e.Fields {
+		details[i] = ValidationError{Field: fe.Field, Message: fe.Message}
+	}
+
+	return RespondError(c, ErrorResponse{
+		Error:   ErrCodeUnknownAuthor,
+		Code:    http.StatusBadRequest,
+		Message: "Unknown author",
+		Details: details,
+	})
+}
+
+// UnavailableRetryAfter is the Retry-After delay, in seconds, sent with 503s.
+const UnavailableRetryAfter = "1"
+
 func handleServiceError(c echo.Context, logger *zap.Logger, err error) error {
 	ctx := c.Request().Context()
 
-	if valErr, ok := err.(validator.ValidationErrors); ok {
-		validationErrors := make([]ValidationError, len(valErr))
-		for i, fieldErr := range valErr {
-			validationErrors[i] = ValidationError{
-				Field:   fieldErr.Field(),
-				Message: validationMessage(fieldErr),
-			}
-		}
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Code:    http.StatusUnprocessableEntity,
-			Message: "Validation failed",
-			Details: validationErrors,
-		})
+	// checked before ValidationErrors, which it wraps
+	var unknownAuthor *services.UnknownAuthorError
+	if errors.As(err, &unknownAuthor) {
+		return unknownAuthorResponse(c, unknownAuthor)
+	}
+
+	var valErrs services.ValidationErrors
+	if errors.As(err, &valErrs) {
+		return validationErrorResponse(c, valErrs)
 	}
 
 	switch {
@@ -342,44 +1182,76 @@ func handleServiceError(c echo.Context, logger *zap.Logger, err error) error {
 			zap.String("trace_id", getTraceID(ctx)),
 		)
 
-		return c.JSON(http.StatusNotFound, ErrorResponse{
-			Error:   "not_found",
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeNotFound,
 			Code:    http.StatusNotFound,
 			Message: err.Error(),
 		})
 	case errors.Is(err, services.ErrInvalidInput):
 		logger.Warn("invalid input",
 			zap.Error(err),
-			zap.Any("request_body", c.Request().Body),
+			zap.String("path", c.Path()),
 			zap.String("trace_id", getTraceID(ctx)),
 		)
 
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_input",
-			Code:    http.StatusBadRequest,
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidInput,
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrPermissionDenied):
+		logger.Warn("permission denied",
+			zap.Error(err),
+			zap.String("path", c.Path()),
+			zap.String("trace_id", getTraceID(ctx)),
+		)
+
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeForbidden,
+			Code:    http.StatusForbidden,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrPrecondition):
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodePreconditionFailed,
+			Code:    http.StatusPreconditionFailed,
 			Message: err.Error(),
 		})
 	case errors.Is(err, services.ErrConflict):
 		logger.Warn("conflict",
 			zap.Error(err),
-			zap.Any("conflict", c.Request().Body),
+			zap.String("path", c.Path()),
 			zap.String("trace_id", getTraceID(ctx)),
 		)
 
-		return c.JSON(http.StatusConflict, ErrorResponse{
-			Error:   "conflict",
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeConflict,
 			Code:    http.StatusConflict,
 			Message: err.Error(),
 		})
+	case errors.Is(err, services.ErrUnavailable):
+		// checked before DeadlineExceeded, which an exhausted pool also wraps
+		logger.Warn("database pool exhausted",
+			zap.Error(err),
+			zap.String("path", c.Path()),
+			zap.String("trace_id", getTraceID(ctx)),
+		)
+
+		c.Response().Header().Set("Retry-After", UnavailableRetryAfter)
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeUnavailable,
+			Code:    http.StatusServiceUnavailable,
+			Message: "Service temporarily unavailable",
+		})
 	case errors.Is(err, context.DeadlineExceeded):
 		logger.Warn("timeout",
 			zap.Error(err),
-			zap.Any("timeout", c.Request().Body),
+			zap.String("path", c.Path()),
 			zap.String("trace_id", getTraceID(ctx)),
 		)
 
-		return c.JSON(http.StatusGatewayTimeout, ErrorResponse{
-			Error:   "timeout",
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeTimeout,
 			Code:    http.StatusGatewayTimeout,
 			Message: "Request timed out",
 		})
@@ -392,20 +1264,228 @@ func handleServiceError(c echo.Context, logger *zap.Logger, err error) error {
 			zap.Stack("stack"),
 		)
 
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "internal_error",
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInternal,
 			Code:    http.StatusInternalServerError,
 			Message: err.Error(),
 		})
 	}
 }
 
+// bookFieldIndex maps the JSON name of every models.Book field to its struct
+// index; it is the allowlist for the fields query parameter.
+var bookFieldIndex = func() map[string]int {
+	t := reflect.TypeOf(models.Book{})
+	index := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		index[name] = i
+	}
+	return index
+}()
+
+// parseFields splits a comma-separated fields parameter, returning nil when
+// no projection was requested.
+func parseFields(raw string) ([]string, []ValidationError) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	var fieldErrs []ValidationError
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if _, ok := bookFieldIndex[name]; !ok {
+			fieldErrs = append(fieldErrs, ValidationError{
+				Field:   "fields",
+				Message: "Unknown field: " + name,
+			})
+			continue
+		}
+		fields = append(fields, name)
+	}
+
+	return fields, fieldErrs
+}
+
+func parseIDList(raw string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(raw, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func parseBookFilter(c echo.Context) (models.BookFilter, []ValidationError) {
+	filter := models.BookFilter{
+		Search:      strings.TrimSpace(c.QueryParam("search")),
+		SearchMode:  models.SearchMode(c.QueryParam("search_mode")),
+		AuthorExact: strings.TrimSpace(c.QueryParam("author_exact")),
+	}
+
+	var filterErrs []ValidationError
+	parseTime := func(name string) *time.Time {
+		raw := c.QueryParam(name)
+		if raw == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			filterErrs = append(filterErrs, ValidationError{
+				Field:   name,
+				Message: "Must be an RFC3339 timestamp",
+			})
+			return nil
+		}
+		return &t
+	}
+
+	filter.CreatedAfter = parseTime("created_after")
+	filter.CreatedBefore = parseTime("created_before")
+	filter.UpdatedAfter = parseTime("updated_after")
+	filter.UpdatedBefore = parseTime("updated_before")
+	filter.UpdatedSince = parseTime("updated_since")
+	filter.SnapshotAt = parseTime("snapshot_at")
+
+	parseBool := func(name string) bool {
+		raw := c.QueryParam(name)
+		if raw == "" {
+			return false
+		}
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			filterErrs = append(filterErrs, ValidationError{
+				Field:   name,
+				Message: "Must be true or false",
+			})
+		}
+		return v
+	}
+
+	if raw := strings.TrimSpace(c.QueryParam("filter")); raw != "" {
+		expr, err := filterexpr.Parse(raw)
+		if err != nil {
+			filterErrs = append(filterErrs, ValidationError{Field: "filter", Message: err.Error()})
+		}
+		filter.Expr = expr
+	}
+
+	includeDeleted, onlyDeleted := parseBool("include_deleted"), parseBool("only_deleted")
+	switch {
+	case includeDeleted && onlyDeleted:
+		filterErrs = append(filterErrs, ValidationError{
+			Field:   "only_deleted",
+			Message: "Cannot be combined with include_deleted",
+		})
+	case includeDeleted:
+		filter.Deleted = models.DeletedFilterAll
+	case onlyDeleted:
+		filter.Deleted = models.DeletedFilterOnly
+	}
+
+	return filter, filterErrs
+}
+
+// canViewDeleted reports whether the caller may list soft-deleted books.
+func canViewDeleted(c echo.Context) bool {
+	principal, ok := auth.PrincipalFromContext(c.Request().Context())
+	return ok && principal.HasRole(auth.RoleAdmin)
+}
+
+func invalidFilterResponse(c echo.Context, filterErrs []ValidationError) error {
+	return RespondError(c, ErrorResponse{
+		Error:   ErrCodeInvalidFilter,
+		Code:    http.StatusBadRequest,
+		Message: "Invalid filter parameters",
+		Details: filterErrs,
+	})
+}
+
+func projectBook(book *models.Book, fields []string) map[string]interface{} {
+	v := reflect.ValueOf(book).Elem()
+	projected := make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		projected[name] = v.Field(bookFieldIndex[name]).Interface()
+	}
+	return projected
+}
+
+func invalidPaginationResponse(c echo.Context, err error) error {
+	resp := ErrorResponse{
+		Error:   ErrCodeInvalidPagination,
+		Code:    http.StatusBadRequest,
+		Message: "Invalid pagination parameters",
+	}
+	var perr *pagination.Error
+	if errors.As(err, &perr) {
+		resp.Details = []ValidationError{{Field: perr.Field, Message: perr.Message}}
+	}
+	return RespondError(c, resp)
+}
+
+func invalidFieldsResponse(c echo.Context, fieldErrs []ValidationError) error {
+	return RespondError(c, ErrorResponse{
+		Error:   ErrCodeInvalidFields,
+		Code:    http.StatusBadRequest,
+		Message: "Invalid fields parameter",
+		Details: fieldErrs,
+	})
+}
+
 func generateETag(book *models.Book) string {
-	return strconv.Itoa(book.ID) + "-" + strconv.FormatInt(book.UpdatedAt.Unix(), 10)
+	return etag(book.ID, book.UpdatedAt)
+}
+
+func etag(id int, updatedAt time.Time) string {
+	return strconv.Itoa(id) + "-" + strconv.FormatInt(updatedAt.Unix(), 10)
+}
+
+// listETag returns a weak ETag for a page of books. It covers the tenant,
+// the query parameters and Accept header, which shape the representation, and
+// the ID and version of every book on the page along with the total, so any
+// change to a listed book or to the result set produces a new tag. The
+// defaulted snapshot_at is left out so repeated polls of the same URL can
+// match.
+func listETag(c echo.Context, books []*models.Book, total int) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s?%s\n%s\n%d\n", tenant.FromContext(c.Request().Context()), c.Request().URL.Path, c.Request().URL.Query().Encode(), c.Request().Header.Get(echo.HeaderAccept), total)
+	for _, book := range books {
+		fmt.Fprintf(h, "%d:%d:%t\n", book.ID, book.UpdatedAt.UnixNano(), book.Deleted)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches tag, using the
+// weak comparison RFC 9110 prescribes for it.
+func etagMatches(ifNoneMatch, tag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
 }
 
 func getTraceID(ctx context.Context) string {
-	if traceID, ok := ctx.Value(middleware.TraceIDKey).(string); ok {
+	if traceID, ok := tracing.TraceIDFromContext(ctx); ok {
 		return traceID
 	}
 	return "not_available"
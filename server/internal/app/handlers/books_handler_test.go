@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bf-api/internal/domain/services"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBookErrorStatusCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+		code   ErrorCode
+	}{
+		{"malformed JSON on create", http.MethodPost, "/api/v1/books", `{"title":`, http.StatusBadRequest, ErrCodeInvalidRequest},
+		{"validation on create", http.MethodPost, "/api/v1/books", `{"title":"Title"}`, http.StatusUnprocessableEntity, ErrCodeValidation},
+		{"duplicate ISBN on create", http.MethodPost, "/api/v1/books", validBookJSON, http.StatusConflict, ErrCodeConflict},
+		{"malformed JSON on update", http.MethodPut, "/api/v1/books/1", `{"title":`, http.StatusBadRequest, ErrCodeInvalidRequest},
+		{"invalid ID on update", http.MethodPut, "/api/v1/books/abc", `{"title":"Title"}`, http.StatusBadRequest, ErrCodeInvalidID},
+		{"validation on update", http.MethodPut, "/api/v1/books/1", `{"pages":-1}`, http.StatusUnprocessableEntity, ErrCodeValidation},
+		{"missing book on update", http.MethodPut, "/api/v1/books/999", `{"title":"Title"}`, http.StatusNotFound, ErrCodeNotFound},
+		{"missing book on get", http.MethodGet, "/api/v1/books/999", "", http.StatusNotFound, ErrCodeNotFound},
+		{"unparseable limit on list", http.MethodGet, "/api/v1/books?limit=abc", "", http.StatusBadRequest, ErrCodeInvalidPagination},
+		{"page out of range on list", http.MethodGet, "/api/v1/books?page=99999999999", "", http.StatusBadRequest, ErrCodeInvalidPagination},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestServer(t)
+			if rec := do(e, http.MethodPost, "/api/v1/books", validBookJSON); rec.Code != http.StatusCreated {
+				t.Fatalf("create status = %d: %s", rec.Code, rec.Body)
+			}
+
+			rec := do(e, tt.method, tt.target, tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			var resp ErrorResponse
+			decodeJSON(t, rec, &resp)
+			// the body repeats the status line
+			if resp.Code != tt.want || resp.Error != tt.code {
+				t.Errorf("body = %d %s, want %d %s", resp.Code, resp.Error, tt.want, tt.code)
+			}
+		})
+	}
+}
+
+func TestListBooksEmpty(t *testing.T) {
+	e := newTestServer(t)
+
+	rec := do(e, http.MethodGet, "/api/v1/books", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Data       json.RawMessage `json:"data"`
+		TotalItems *int            `json:"total_items"`
+	}
+	decodeJSON(t, rec, &body)
+	if string(body.Data) != "[]" {
+		t.Errorf("data = %s, want []", body.Data)
+	}
+	if body.TotalItems == nil || *body.TotalItems != 0 {
+		t.Errorf("total_items missing or not 0 in %s", rec.Body)
+	}
+}
+
+func TestBookTimestampsInUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+7", 7*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	e := newTestServer(t)
+	rec := do(e, http.MethodPost, "/api/v1/books", validBookJSON)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	var created struct {
+		ID int `json:"id"`
+	}
+	decodeJSON(t, rec, &created)
+
+	for _, target := range []string{"/api/v1/books/" + strconv.Itoa(created.ID), "/api/v1/books"} {
+		rec := do(e, http.MethodGet, target, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want 200: %s", target, rec.Code, rec.Body)
+		}
+		for _, key := range []string{`"created_at"`, `"updated_at"`} {
+			_, rest, ok := strings.Cut(rec.Body.String(), key+`:"`)
+			if !ok {
+				t.Errorf("GET %s: no %s in %s", target, key, rec.Body)
+				continue
+			}
+			value, _, _ := strings.Cut(rest, `"`)
+			if !strings.HasSuffix(value, "Z") {
+				t.Errorf("GET %s: %s = %q, want a UTC time ending in Z", target, key, value)
+			}
+		}
+	}
+}
+
+func TestStrictAuthorCheck(t *testing.T) {
+	e := newTestServer(t, services.WithAuthorCheck(services.AuthorCheckStrict))
+
+	// the books are well-formed, so an unknown author is a 400 on every path
+	for _, tt := range []struct {
+		method, target, body, field string
+	}{
+		{http.MethodPost, "/api/v1/books", validBookJSON, "author"},
+		{http.MethodPut, "/api/v1/books/bulk", `{"books":[` + validBookJSON + `]}`, "books[0].author"},
+	} {
+		rec := do(e, tt.method, tt.target, tt.body)
+		var resp ErrorResponse
+		decodeJSON(t, rec, &resp)
+		if rec.Code != http.StatusBadRequest || resp.Error != ErrCodeUnknownAuthor {
+			t.Fatalf("%s %s = %d %s, want 400 %s: %s", tt.method, tt.target, rec.Code, resp.Error, ErrCodeUnknownAuthor, rec.Body)
+		}
+		if len(resp.Details) != 1 || resp.Details[0].Field != tt.field {
+			t.Errorf("%s %s details = %+v, want one for %s", tt.method, tt.target, resp.Details, tt.field)
+		}
+	}
+
+	for _, want := range []int{http.StatusCreated, http.StatusOK} {
+		if rec := do(e, http.MethodPost, "/api/v1/authors", `{"name":" Author "}`); rec.Code != want {
+			t.Fatalf("add author status = %d, want %d: %s", rec.Code, want, rec.Body)
+		}
+	}
+	if rec := do(e, http.MethodPost, "/api/v1/books", validBookJSON); rec.Code != http.StatusCreated {
+		t.Errorf("create status after adding the author = %d, want 201: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestListBooksSnapshotUsesClock(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 12, 30, 0, 123456789, time.UTC)
+	e := newTestServer(t, services.WithClock(services.NewFakeClock(now)))
+
+	rec := do(e, http.MethodGet, "/api/v1/books", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Links struct {
+			Self string `json:"self"`
+		} `json:"links"`
+	}
+	decodeJSON(t, rec, &body)
+	// truncated to the microsecond precision of timestamptz
+	want := "snapshot_at=" + url.QueryEscape("2024-06-15T12:30:00.123456Z")
+	if !strings.Contains(body.Links.Self, want) {
+		t.Errorf("self link = %q, want it to contain %q", body.Links.Self, want)
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bf-api/internal/domain/services"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// coverFormField is the multipart field carrying an uploaded cover image.
+const coverFormField = "cover"
+
+// UploadCover godoc
+// @Summary Upload a book cover
+// @Description Replace the cover of a book with a JPEG or PNG image of at most 5 MiB and 100 to 6000 pixels per side. The image is re-encoded, which strips EXIF and other metadata.
+// @Tags books
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param cover formData file true "Cover image (image/jpeg or image/png)"
+// @Success 200 {object} models.Book
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
+// @Failure 404 {object} handlers.ErrorResponse
+// @Failure 413 {object} handlers.ErrorResponse
+// @Failure 415 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /books/{id}/cover [post]
+func (h *BookHandler) UploadCover(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidID,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid book ID",
+		})
+	}
+
+	file, err := c.FormFile(coverFormField)
+	if err != nil {
+		if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+			return err
+		}
+
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidRequest,
+			Code:    http.StatusBadRequest,
+			Message: "Expected a multipart/form-data body with the image in the " + coverFormField + " field",
+		})
+	}
+
+	if contentType := file.Header.Get(echo.HeaderContentType); contentType != "image/jpeg" && contentType != "image/png" {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeUnsupportedMedia,
+			Code:    http.StatusUnsupportedMediaType,
+			Message: "Cover must be sent as image/jpeg or image/png",
+		})
+	}
+	if file.Size > services.MaxCoverBytes {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodePayloadTooLarge,
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("Cover exceeds the limit of %d bytes", services.MaxCoverBytes),
+		})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, services.MaxCoverBytes+1))
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	book, err := h.service.SetCover(c.Request().Context(), id, data)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	c.Response().Header().Set("ETag", generateETag(book))
+	return respond(c, http.StatusOK, book, nil)
+}
+
+// GetCover godoc
+// @Summary Get a book cover
+// @Description Get the cover image of a book. When covers are served from object storage directly, this redirects there instead.
+// @Tags books
+// @Produce image/jpeg
+// @Produce image/png
+// @Param id path int true "Book ID"
+// @Param If-None-Match header string false "ETag of a cached cover"
+// @Success 200 {file} file
+// @Success 302 "Redirect to the cover in object storage"
+// @Success 304 "Cover not modified"
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 404 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Router /books/{id}/cover [get]
+func (h *BookHandler) GetCover(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidID,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid book ID",
+		})
+	}
+
+	cover, err := h.service.GetCover(c.Request().Context(), id)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	if cover.RedirectURL != "" {
+		return c.Redirect(http.StatusFound, cover.RedirectURL)
+	}
+
+	// the URL is stable across uploads, so caches must revalidate
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("ETag", cover.ETag)
+	if etagMatches(c.Request().Header.Get("If-None-Match"), cover.ETag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.Blob(http.StatusOK, cover.ContentType, cover.Data)
+}
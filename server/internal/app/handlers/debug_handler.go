@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+)
+
+type DebugHandler struct {
+	pool *pgxpool.Pool
+}
+
+func NewDebugHandler(pool *pgxpool.Pool) *DebugHandler {
+	return &DebugHandler{pool: pool}
+}
+
+type PoolStatsResponse struct {
+	MaxConns                int32 `json:"max_conns" example:"4"`
+	TotalConns              int32 `json:"total_conns" example:"3"`
+	IdleConns               int32 `json:"idle_conns" example:"2"`
+	AcquiredConns           int32 `json:"acquired_conns" example:"1"`
+	ConstructingConns       int32 `json:"constructing_conns" example:"0"`
+	AcquireCount            int64 `json:"acquire_count" example:"1024"`
+	EmptyAcquireCount       int64 `json:"empty_acquire_count" example:"12"`
+	CanceledAcquireCount    int64 `json:"canceled_acquire_count" example:"0"`
+	AcquireDurationMs       int64 `json:"acquire_duration_ms" example:"340"`
+	NewConnsCount           int64 `json:"new_conns_count" example:"5"`
+	MaxLifetimeDestroyCount int64 `json:"max_lifetime_destroy_count" example:"1"`
+	MaxIdleDestroyCount     int64 `json:"max_idle_destroy_count" example:"1"`
+}
+
+// PoolStats returns a snapshot of the database connection pool counters. It is
+// an ops endpoint and deliberately left out of the public API docs.
+func (h *DebugHandler) PoolStats(c echo.Context) error {
+	stat := h.pool.Stat()
+	return c.JSON(http.StatusOK, PoolStatsResponse{
+		MaxConns:                stat.MaxConns(),
+		TotalConns:              stat.TotalConns(),
+		IdleConns:               stat.IdleConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		AcquireCount:            stat.AcquireCount(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		AcquireDurationMs:       stat.AcquireDuration().Milliseconds(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+	})
+}
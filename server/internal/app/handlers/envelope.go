@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"bf-api/internal/domain/models"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// APIVersionKey is the echo context key holding the API version a route
+// belongs to; it decides how responses are serialized.
+const APIVersionKey = "api_version"
+
+// Envelope wraps every v2 response. Data is null on failure and Errors is
+// empty on success.
+type Envelope struct {
+	Data   interface{}     `json:"data"`
+	Meta   interface{}     `json:"meta,omitempty"`
+	Errors []EnvelopeError `json:"errors"`
+}
+
+type EnvelopeError struct {
+	Code    ErrorCode `json:"code" example:"validation_error"`
+	Message string    `json:"message" example:"Must be at least 5"`
+	Field   string    `json:"field,omitempty" example:"pages"`
+}
+
+// ListMeta describes a page of a v2 listing.
+type ListMeta struct {
+	Page       int                    `json:"page" example:"2"`
+	PerPage    int                    `json:"per_page" example:"20"`
+	TotalPages int                    `json:"total_pages" example:"10"`
+	TotalItems int                    `json:"total_items" example:"200"`
+	Links      models.PaginationLinks `json:"links"`
+	SnapshotAt *time.Time             `json:"snapshot_at,omitempty" example:"2024-01-02T15:04:05.123456Z"`
+	Warning    string                 `json:"warning,omitempty" example:"limit 500 exceeds the maximum of 100; 100 items are returned per page"`
+}
+
+func enveloped(c echo.Context) bool {
+	version, _ := c.Get(APIVersionKey).(int)
+	return version >= 2
+}
+
+// respond writes data as is for v1 routes and wrapped in an Envelope with
+// meta for v2 routes. Clients accepting JSON:API get a JSON:API document on
+// either version.
+func respond(c echo.Context, status int, data interface{}, meta interface{}) error {
+	if negotiateJSONAPI(c) {
+		doc := jsonAPIData(c, data)
+		if meta != nil {
+			doc.Meta = meta
+		}
+		return respondJSONAPI(c, status, doc)
+	}
+	if !enveloped(c) {
+		return c.JSON(status, data)
+	}
+	return c.JSON(status, Envelope{Data: data, Meta: meta, Errors: []EnvelopeError{}})
+}
+
+// RespondError writes resp with its Code as the status, as an ErrorResponse on
+// v1 routes and as an Envelope with one error per detail on v2 routes, or as
+// JSON:API errors or RFC 7807 problem details when the client accepts them.
+func RespondError(c echo.Context, resp ErrorResponse) error {
+	if negotiateJSONAPI(c) {
+		return respondJSONAPI(c, resp.Code, JSONAPIDocument{Errors: jsonAPIErrors(resp)})
+	}
+	if negotiateProblem(c) {
+		return respondProblem(c, resp)
+	}
+	if !enveloped(c) {
+		return c.JSON(resp.Code, resp)
+	}
+
+	errs := []EnvelopeError{{Code: resp.Error, Message: resp.Message}}
+	if len(resp.Details) > 0 {
+		errs = errs[:0]
+		for _, detail := range resp.Details {
+			errs = append(errs, EnvelopeError{Code: resp.Error, Message: detail.Message, Field: detail.Field})
+		}
+	}
+	return c.JSON(resp.Code, Envelope{Errors: errs})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrorCode is the stable, machine-readable identifier of a failure mode sent
+// in ErrorResponse.Error. Clients should switch on it rather than on the HTTP
+// status; codes are never renamed once published.
+type ErrorCode string
+
+const (
+	ErrCodeInvalidRequest     ErrorCode = "invalid_request"
+	ErrCodeInvalidID          ErrorCode = "invalid_id"
+	ErrCodeInvalidIDs         ErrorCode = "invalid_ids"
+	ErrCodeInvalidFields      ErrorCode = "invalid_fields"
+	ErrCodeInvalidFilter      ErrorCode = "invalid_filter"
+	ErrCodeInvalidPagination  ErrorCode = "invalid_pagination"
+	ErrCodeInvalidThreshold   ErrorCode = "invalid_threshold"
+	ErrCodeInvalidRetention   ErrorCode = "invalid_retention"
+	ErrCodeInvalidTenant      ErrorCode = "invalid_tenant"
+	ErrCodeInvalidParameter   ErrorCode = "invalid_parameter"
+	ErrCodeValidation         ErrorCode = "validation_error"
+	ErrCodeSchemaViolation    ErrorCode = "schema_violation"
+	ErrCodeInvalidInput       ErrorCode = "invalid_input"
+	ErrCodeUnknownAuthor      ErrorCode = "unknown_author"
+	ErrCodeUnauthorized       ErrorCode = "unauthorized"
+	ErrCodeForbidden          ErrorCode = "forbidden"
+	ErrCodeNotFound           ErrorCode = "not_found"
+	ErrCodeMethodNotAllowed   ErrorCode = "method_not_allowed"
+	ErrCodeConflict           ErrorCode = "conflict"
+	ErrCodePreconditionFailed ErrorCode = "precondition_failed"
+	ErrCodePayloadTooLarge    ErrorCode = "payload_too_large"
+	ErrCodeUnsupportedMedia   ErrorCode = "unsupported_media_type"
+	ErrCodeRateLimited        ErrorCode = "rate_limited"
+	ErrCodeInternal           ErrorCode = "internal_error"
+	ErrCodeTimeout            ErrorCode = "timeout"
+	ErrCodeUnavailable        ErrorCode = "service_unavailable"
+)
+
+type ErrorCodeInfo struct {
+	Code        ErrorCode `json:"code" example:"not_found"`
+	Status      int       `json:"status" example:"404"`
+	Description string    `json:"description" example:"The requested resource does not exist"`
+}
+
+// ErrorCatalog documents every ErrorCode the API can return. Keep it in sync
+// when adding a code.
+var ErrorCatalog = []ErrorCodeInfo{
+	{ErrCodeInvalidRequest, http.StatusBadRequest, "The request body could not be parsed"},
+	{ErrCodeInvalidID, http.StatusBadRequest, "The book ID in the path is not a positive integer"},
+	{ErrCodeInvalidIDs, http.StatusBadRequest, "The list of book IDs is missing or malformed"},
+	{ErrCodeInvalidFields, http.StatusBadRequest, "The fields parameter names an unknown field"},
+	{ErrCodeInvalidFilter, http.StatusBadRequest, "A filter parameter is malformed"},
+	{ErrCodeInvalidPagination, http.StatusBadRequest, "The page or limit parameter is out of range"},
+	{ErrCodeInvalidThreshold, http.StatusBadRequest, "The threshold parameter is not an integer"},
+	{ErrCodeInvalidRetention, http.StatusBadRequest, "The older_than parameter is not a duration such as 30d or 720h"},
+	{ErrCodeInvalidTenant, http.StatusBadRequest, "The X-Tenant-ID header is not 1-64 letters, digits, underscores or hyphens"},
+	{ErrCodeInvalidParameter, http.StatusBadRequest, "A query parameter is malformed or has an unsupported value"},
+	{ErrCodeValidation, http.StatusUnprocessableEntity, "One or more fields failed validation; see details"},
+	{ErrCodeSchemaViolation, http.StatusBadRequest, "The request does not match the OpenAPI schema; see details"},
+	{ErrCodeInvalidInput, http.StatusUnprocessableEntity, "The request breaks a business rule"},
+	{ErrCodeUnknownAuthor, http.StatusBadRequest, "Strict author checking is on and an author is not known to the tenant; details suggest close matches"},
+	{ErrCodeUnauthorized, http.StatusUnauthorized, "A valid API key is required"},
+	{ErrCodeForbidden, http.StatusForbidden, "The caller is not allowed to perform this action"},
+	{ErrCodeNotFound, http.StatusNotFound, "The requested resource does not exist"},
+	{ErrCodeMethodNotAllowed, http.StatusMethodNotAllowed, "The resource does not support the request method"},
+	{ErrCodeConflict, http.StatusConflict, "The request conflicts with existing data, e.g. a duplicate ISBN"},
+	{ErrCodePreconditionFailed, http.StatusPreconditionFailed, "The resource changed since the time given in If-Unmodified-Since"},
+	{ErrCodePayloadTooLarge, http.StatusRequestEntityTooLarge, "The request body exceeds the size limit"},
+	{ErrCodeUnsupportedMedia, http.StatusUnsupportedMediaType, "The uploaded file is not of an accepted type"},
+	{ErrCodeRateLimited, http.StatusTooManyRequests, "Too many requests; retry later"},
+	{ErrCodeInternal, http.StatusInternalServerError, "An unexpected server error occurred"},
+	{ErrCodeTimeout, http.StatusGatewayTimeout, "The request did not complete in time"},
+	{ErrCodeUnavailable, http.StatusServiceUnavailable, "The server is overloaded; retry after the Retry-After delay"},
+}
+
+// ListErrorCodes godoc
+// @Summary List error codes
+// @Description Get the catalog of machine-readable error codes the API can return
+// @Tags errors
+// @Produce json
+// @Success 200 {array} handlers.ErrorCodeInfo
+// @Router /errors [get]
+func ListErrorCodes(c echo.Context) error {
+	return c.JSON(http.StatusOK, ErrorCatalog)
+}
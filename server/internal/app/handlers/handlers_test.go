@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bf-api/internal/domain/services"
+	"bf-api/internal/infrastructure/db/memory"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// newTestServer serves the book routes used by the handler tests under
+// /api/v1 and /api/v2, backed by an in-memory repository.
+func newTestServer(t *testing.T, opts ...services.BookServiceOption) *echo.Echo {
+	t.Helper()
+
+	store := memory.NewStore()
+	svc := services.NewBookService(memory.NewBookRepository(store), memory.NewAuditRepository(store), memory.NewTxManager(store), nil, nil, opts...)
+	h := NewBookHandler(svc, zap.NewNop())
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler(zap.NewNop())
+	e.Pre(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if strings.HasPrefix(c.Request().URL.Path, "/api/v2/") {
+				c.Set(APIVersionKey, 2)
+			}
+			return next(c)
+		}
+	})
+	for _, prefix := range []string{"/api/v1", "/api/v2"} {
+		g := e.Group(prefix + "/books")
+		g.POST("", h.CreateBook)
+		g.GET("", h.ListBooks)
+		g.GET("/:id", h.GetBook)
+		g.PUT("/bulk", h.UpsertBooks)
+		g.PUT("/:id", h.UpdateBook)
+		e.POST(prefix+"/authors", h.CreateAuthor)
+	}
+	return e
+}
+
+// do sends a request with a JSON body, which may be empty, and returns the
+// recorded response.
+func do(e *echo.Echo, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+const validBookJSON = `{"title":"Title","author":"Author","published":"2000-01-01","isbn":"9780306406157","pages":100}`
+
+func decodeJSON(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+}
+
+func TestCreateBookLocation(t *testing.T) {
+	// each version points at the book under its own prefix
+	for _, prefix := range []string{"/api/v1", "/api/v2"} {
+		t.Run(prefix, func(t *testing.T) {
+			e := newTestServer(t)
+
+			rec := do(e, http.MethodPost, prefix+"/books", validBookJSON)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want 201: %s", rec.Code, rec.Body)
+			}
+
+			var body struct {
+				ID   int `json:"id"`
+				Data struct {
+					ID int `json:"id"`
+				} `json:"data"`
+			}
+			decodeJSON(t, rec, &body)
+			id := body.ID
+			if prefix == "/api/v2" {
+				id = body.Data.ID
+			}
+			if id == 0 {
+				t.Fatalf("no book ID in response %s", rec.Body)
+			}
+
+			want := prefix + "/books/" + strconv.Itoa(id)
+			if got := rec.Header().Get(echo.HeaderLocation); got != want {
+				t.Errorf("Location = %q, want %q", got, want)
+			}
+			if got := do(e, http.MethodGet, want, "").Code; got != http.StatusOK {
+				t.Errorf("GET Location status = %d, want 200", got)
+			}
+			if rec.Header().Get("ETag") == "" {
+				t.Error("no ETag on the created book")
+			}
+		})
+	}
+}
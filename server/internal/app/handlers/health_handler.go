@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bf-api/internal/infrastructure/db/postgres"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type HealthHandler struct {
+	pool   *pgxpool.Pool
+	logger *zap.Logger
+
+	mu         sync.Mutex
+	serverInfo *postgres.ServerDetails // cached once read; the server does not change under us
+
+	// monitor, if set, checks the pool in the background; readiness reports
+	// its latest result instead of querying on every probe
+	monitor *postgres.HealthMonitor
+
+	// started is set once migrations and pool warm-up have completed
+	started atomic.Bool
+}
+
+type HealthHandlerOption func(*HealthHandler)
+
+// WithHealthMonitor serves the latest check of monitor on /readyz.
+func WithHealthMonitor(monitor *postgres.HealthMonitor) HealthHandlerOption {
+	return func(h *HealthHandler) {
+		h.monitor = monitor
+	}
+}
+
+// NewHealthHandler returns a HealthHandler checking pool. A nil pool, as
+// with the in-memory backend, leaves no dependency to check.
+func NewHealthHandler(pool *pgxpool.Pool, logger *zap.Logger, opts ...HealthHandlerOption) *HealthHandler {
+	h := &HealthHandler{pool: pool, logger: logger}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// MarkStarted records that startup work such as migrations has finished, so
+// /startupz succeeds and /readyz starts checking dependencies.
+func (h *HealthHandler) MarkStarted() {
+	h.started.Store(true)
+}
+
+type StartupResponse struct {
+	Status string `json:"status" example:"started"`
+}
+
+// Startup reports whether the service has finished starting up, for use as
+// a Kubernetes startup probe. It fails while migrations run and the pool
+// warms up, which may take a while, and never fails again afterwards;
+// ongoing dependency health is left to /readyz.
+func (h *HealthHandler) Startup(c echo.Context) error {
+	if !h.started.Load() {
+		return c.JSON(http.StatusServiceUnavailable, StartupResponse{Status: "starting"})
+	}
+	return c.JSON(http.StatusOK, StartupResponse{Status: "started"})
+}
+
+type ReadinessResponse struct {
+	Status   string                  `json:"status" example:"ready"`
+	Error    string                  `json:"error,omitempty" example:"missing required extensions: plpgsql"`
+	Database *postgres.ServerDetails `json:"database,omitempty"`
+	// Health is the latest background check, when one is running.
+	Health *postgres.HealthStatus `json:"health,omitempty"`
+}
+
+// Ready reports whether the database is reachable and provides every required
+// extension, along with the server version. Reachability is the result of
+// the background health check once it has run, and checked on the spot
+// otherwise. Like /version it is an ops endpoint and left out of the public
+// API docs.
+func (h *HealthHandler) Ready(c echo.Context) error {
+	if !h.started.Load() {
+		return c.JSON(http.StatusServiceUnavailable, ReadinessResponse{
+			Status: "unavailable",
+			Error:  "still starting up",
+		})
+	}
+
+	if h.pool == nil {
+		return c.JSON(http.StatusOK, ReadinessResponse{Status: "ready"})
+	}
+
+	var health *postgres.HealthStatus
+	if h.monitor != nil {
+		if status, ok := h.monitor.Status(); ok {
+			health = &status
+		}
+	}
+	if health != nil {
+		// the monitor logs the failure when the database turns unhealthy
+		if !health.Healthy {
+			return c.JSON(http.StatusServiceUnavailable, ReadinessResponse{
+				Status: "unavailable",
+				Error:  "database is unreachable",
+				Health: health,
+			})
+		}
+	} else if err := postgres.HealthCheck(c.Request().Context(), h.pool); err != nil {
+		h.logger.Warn("readiness check failed", zap.Error(err))
+		return c.JSON(http.StatusServiceUnavailable, ReadinessResponse{
+			Status: "unavailable",
+			Error:  "database is unreachable",
+		})
+	}
+
+	info, err := h.cachedServerInfo(c)
+	if err != nil {
+		h.logger.Warn("failed to read database server info", zap.Error(err))
+		return c.JSON(http.StatusServiceUnavailable, ReadinessResponse{
+			Status: "unavailable",
+			Error:  "database server info is unavailable",
+			Health: health,
+		})
+	}
+
+	if missing := info.Missing(); len(missing) > 0 {
+		return c.JSON(http.StatusServiceUnavailable, ReadinessResponse{
+			Status:   "unavailable",
+			Error:    "missing required extensions: " + strings.Join(missing, ", "),
+			Database: info,
+			Health:   health,
+		})
+	}
+
+	return c.JSON(http.StatusOK, ReadinessResponse{Status: "ready", Database: info, Health: health})
+}
+
+// cachedServerInfo reads the server info on first use. Failures are not
+// cached, and neither is a missing extension, so installing one is noticed
+// without a restart.
+func (h *HealthHandler) cachedServerInfo(c echo.Context) (*postgres.ServerDetails, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.serverInfo != nil {
+		return h.serverInfo, nil
+	}
+
+	info, err := postgres.ServerInfo(c.Request().Context(), h.pool)
+	if err != nil {
+		return nil, err
+	}
+	if len(info.Missing()) == 0 {
+		h.serverInfo = info
+	}
+	return info, nil
+}
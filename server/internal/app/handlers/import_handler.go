@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/services"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// importRequiredColumns must appear in the header row of an import; other
+// columns, such as the id and timestamps of an export, are ignored.
+var importRequiredColumns = []string{"title", "author", "published", "isbn", "pages"}
+
+// ImportBooks godoc
+// @Summary Import books
+// @Description Create up to 100000 books from a CSV file whose header row names at least the title, author, published, isbn and pages columns; acquisition_cost and supplier_notes are optional and other columns, such as those of an export, are ignored. Every row is validated before anything is written and the import is applied in full or not at all. Depending on the server's import mode, ISBNs already in use are either skipped and listed (batch) or fail the import with 409 (copy).
+// @Tags books
+// @Accept text/csv
+// @Produce json
+// @Param body body string true "CSV file"
+// @Success 200 {object} models.BookImportResponse
+// @Failure 400 {object} handlers.ErrorResponse
+// @Failure 401 {object} handlers.ErrorResponse
+// @Failure 403 {object} handlers.ErrorResponse
+// @Failure 409 {object} handlers.ErrorResponse
+// @Failure 413 {object} handlers.ErrorResponse
+// @Failure 415 {object} handlers.ErrorResponse
+// @Failure 422 {object} handlers.ErrorResponse
+// @Failure 500 {object} handlers.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /books/import [post]
+func (h *BookHandler) ImportBooks(c echo.Context) error {
+	if mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType)); mediaType != "text/csv" {
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeUnsupportedMedia,
+			Code:    http.StatusUnsupportedMediaType,
+			Message: "Import must be sent as text/csv",
+		})
+	}
+
+	reqs, fieldErrs, err := parseImportCSV(c.Request().Body)
+	if err != nil {
+		if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+			return err
+		}
+
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidRequest,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid CSV: " + err.Error(),
+		})
+	}
+	if len(fieldErrs) > 0 {
+		return validationErrorResponse(c, fieldErrs)
+	}
+
+	result, err := h.service.ImportBooks(c.Request().Context(), reqs)
+	if err != nil {
+		return handleServiceError(c, h.logger, err)
+	}
+
+	return respond(c, http.StatusOK, result, nil)
+}
+
+// parseImportCSV reads the books of an import. Values that cannot be parsed
+// are reported as field errors named after the row, as books[i].field with i
+// counting data rows from zero, matching the errors the service reports.
+func parseImportCSV(r io.Reader) ([]models.BookCreateRequest, services.ValidationErrors, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("missing header row")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\uFEFF") // spreadsheet byte order mark
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("header row lacks the %s column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var reqs []models.BookCreateRequest
+	var errs services.ValidationErrors
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if row >= services.MaxImportSize {
+			return nil, nil, fmt.Errorf("more than %d rows", services.MaxImportSize)
+		}
+
+		req := models.BookCreateRequest{
+			Title:           field(record, "title"),
+			Author:          field(record, "author"),
+			ISBN:            field(record, "isbn"),
+			AcquisitionCost: field(record, "acquisition_cost"),
+			SupplierNotes:   field(record, "supplier_notes"),
+		}
+		if raw := field(record, "published"); raw != "" {
+			if req.Published, err = models.ParseDate(raw); err != nil {
+				errs = append(errs, services.FieldError{
+					Field:   fmt.Sprintf("books[%d].published", row),
+					Message: "Must be a date in " + models.DateLayout + " format",
+				})
+			}
+		}
+		if raw := field(record, "pages"); raw != "" {
+			if req.Pages, err = strconv.Atoi(raw); err != nil {
+				errs = append(errs, services.FieldError{
+					Field:   fmt.Sprintf("books[%d].pages", row),
+					Message: "Must be an integer",
+				})
+			}
+		}
+		reqs = append(reqs, req)
+	}
+
+	return reqs, errs, nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bf-api/internal/domain/models"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CheckIntegrity reports how many active books fail the validation applied to
+// writes, such as rows stored before a rule existed. With fix=false it is a
+// dry run that also lists what would need correcting on each book, streamed
+// as the table is scanned and followed by the counts. Books are never changed
+// here, so fix=true is rejected. It is an ops endpoint and deliberately left
+// out of the public API docs.
+func (h *BookHandler) CheckIntegrity(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	raw := c.QueryParam("fix")
+	if raw == "" {
+		report, err := h.service.CheckIntegrity(ctx, nil)
+		if err != nil {
+			return handleServiceError(c, h.logger, err)
+		}
+		h.logIntegrity(c, report)
+		return c.JSON(http.StatusOK, report)
+	}
+	if fix, err := strconv.ParseBool(raw); err != nil || fix {
+		message := "Must be true or false"
+		if err == nil {
+			message = "Only a dry run (fix=false) is supported; correct the listed books through the books API"
+		}
+		return RespondError(c, ErrorResponse{
+			Error:   ErrCodeInvalidParameter,
+			Code:    http.StatusBadRequest,
+			Message: "Invalid fix parameter",
+			Details: []ValidationError{{Field: "fix", Message: message}},
+		})
+	}
+
+	// {"books":[...],"report":{...}}, written as books are found so the
+	// response never holds more than one of them
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	res.WriteHeader(http.StatusOK)
+	enc := c.Echo().JSONSerializer
+	if _, err := res.Write([]byte(`{"books":[`)); err != nil {
+		return nil
+	}
+	first := true
+	report, err := h.service.CheckIntegrity(ctx, func(issue models.IntegrityIssue) error {
+		if !first {
+			if _, err := res.Write([]byte(",")); err != nil {
+				return err
+			}
+		}
+		first = false
+		return enc.Serialize(c, issue, "")
+	})
+	if err != nil {
+		// the status is already sent; ending here leaves the body invalid
+		// JSON, so a client cannot mistake it for a complete report
+		h.logger.Error("integrity check failed",
+			zap.Error(err),
+			zap.String("trace_id", getTraceID(ctx)),
+		)
+		return nil
+	}
+	h.logIntegrity(c, report)
+
+	if _, err := res.Write([]byte(`],"report":`)); err != nil {
+		return nil
+	}
+	if err := enc.Serialize(c, report, ""); err != nil {
+		return nil
+	}
+	_, _ = res.Write([]byte("}"))
+	return nil
+}
+
+func (h *BookHandler) logIntegrity(c echo.Context, report *models.IntegrityReport) {
+	h.logger.Info("checked book integrity",
+		zap.Int("scanned", report.Scanned),
+		zap.Int("invalid", report.Invalid),
+		zap.String("trace_id", getTraceID(c.Request().Context())),
+	)
+}
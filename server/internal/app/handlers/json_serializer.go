@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// prettyIndent indents JSON responses when JSONSerializer.Pretty is set.
+const prettyIndent = "  "
+
+// JSONSerializer writes every JSON response compactly, or indented when
+// Pretty is set, so development output is readable without ?pretty. A
+// response that asks for an indent, such as one with ?pretty, keeps it.
+// Decoding is left to echo's default serializer.
+type JSONSerializer struct {
+	echo.DefaultJSONSerializer
+	Pretty bool
+}
+
+func (s JSONSerializer) Serialize(c echo.Context, i interface{}, indent string) error {
+	if indent == "" && s.Pretty {
+		indent = prettyIndent
+	}
+	return s.DefaultJSONSerializer.Serialize(c, i, indent)
+}
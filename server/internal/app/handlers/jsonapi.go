@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bf-api/internal/app/pagination"
+	"bf-api/internal/domain/models"
+	"encoding/json"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MIMEApplicationJSONAPI is the JSON:API media type (https://jsonapi.org).
+const MIMEApplicationJSONAPI = "application/vnd.api+json"
+
+const bookResourceType = "books"
+
+// JSONAPIDocument is the top-level JSON:API document. Responses without a
+// book resource, such as counts, are sent with only meta.
+type JSONAPIDocument struct {
+	Data    interface{}    `json:"data,omitempty"`
+	Errors  []JSONAPIError `json:"errors,omitempty"`
+	Meta    interface{}    `json:"meta,omitempty"`
+	Links   *JSONAPILinks  `json:"links,omitempty"`
+	JSONAPI JSONAPIVersion `json:"jsonapi"`
+}
+
+type JSONAPIVersion struct {
+	Version string `json:"version" example:"1.1"`
+}
+
+type JSONAPILinks struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+type JSONAPIResource struct {
+	Type       string                 `json:"type" example:"books"`
+	ID         string                 `json:"id" example:"42"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Links      *JSONAPILinks          `json:"links,omitempty"`
+	Meta       interface{}            `json:"meta,omitempty"`
+}
+
+// JSONAPIListMeta describes a page of a JSON:API listing; the page links are
+// top-level links instead.
+type JSONAPIListMeta struct {
+	Page       int        `json:"page" example:"2"`
+	PerPage    int        `json:"per_page" example:"20"`
+	TotalPages int        `json:"total_pages" example:"10"`
+	TotalItems int        `json:"total_items" example:"200"`
+	SnapshotAt *time.Time `json:"snapshot_at,omitempty" example:"2024-01-02T15:04:05.123456Z"`
+	Warning    string     `json:"warning,omitempty" example:"limit 500 exceeds the maximum of 100; 100 items are returned per page"`
+}
+
+type JSONAPIError struct {
+	Status string              `json:"status" example:"422"`
+	Code   ErrorCode           `json:"code" example:"validation_error"`
+	Title  string              `json:"title" example:"Validation failed"`
+	Detail string              `json:"detail,omitempty" example:"Must be at least 5"`
+	Source *JSONAPIErrorSource `json:"source,omitempty"`
+}
+
+type JSONAPIErrorSource struct {
+	Pointer   string `json:"pointer,omitempty" example:"/data/attributes/pages"`
+	Parameter string `json:"parameter,omitempty" example:"limit"`
+}
+
+// negotiateJSONAPI reports whether the client asked for JSON:API. The answer
+// depends on Accept, so it also marks the response as varying on it. As the
+// spec requires, an Accept entry with media type parameters does not count.
+func negotiateJSONAPI(c echo.Context) bool {
+	varyOnAccept(c)
+	for _, accept := range strings.Split(c.Request().Header.Get(echo.HeaderAccept), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil || mediaType != MIMEApplicationJSONAPI {
+			continue
+		}
+		delete(params, "q")
+		if len(params) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func varyOnAccept(c echo.Context) {
+	header := c.Response().Header()
+	for _, v := range header.Values(echo.HeaderVary) {
+		for _, name := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), echo.HeaderAccept) {
+				return
+			}
+		}
+	}
+	header.Add(echo.HeaderVary, echo.HeaderAccept)
+}
+
+func respondJSONAPI(c echo.Context, status int, doc JSONAPIDocument) error {
+	doc.JSONAPI = JSONAPIVersion{Version: "1.1"}
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationJSONAPI)
+	c.Response().WriteHeader(status)
+	return c.Echo().JSONSerializer.Serialize(c, doc, "")
+}
+
+// jsonAPIData converts a response body into primary data: books become
+// resources and anything else is sent as meta.
+func jsonAPIData(c echo.Context, data interface{}) JSONAPIDocument {
+	switch v := data.(type) {
+	case *models.Book:
+		res := bookResource(c, v, nil)
+		return JSONAPIDocument{Data: res, Links: &JSONAPILinks{Self: res.Links.Self}}
+	case models.BookDryRunResponse:
+		res := bookResource(c, v.Book, nil)
+		res.Meta = map[string]bool{"dry_run": v.DryRun}
+		return JSONAPIDocument{Data: res}
+	case bookProjection:
+		res := bookResource(c, v.book, v.fields)
+		return JSONAPIDocument{Data: res, Links: &JSONAPILinks{Self: res.Links.Self}}
+	case []*models.Book:
+		return JSONAPIDocument{Data: bookResources(c, v, nil)}
+	default:
+		return JSONAPIDocument{Meta: data}
+	}
+}
+
+// bookProjection is a book restricted to the fields query parameter. It
+// encodes as the projected object and as a sparse resource in JSON:API.
+type bookProjection struct {
+	book   *models.Book
+	fields []string
+}
+
+func (p bookProjection) MarshalJSON() ([]byte, error) {
+	return json.Marshal(projectBook(p.book, p.fields))
+}
+
+// bookResource renders book as a resource object. fields restricts the
+// attributes like the fields query parameter does; nil means all of them.
+func bookResource(c echo.Context, book *models.Book, fields []string) JSONAPIResource {
+	if fields == nil {
+		fields = make([]string, 0, len(bookFieldIndex))
+		for name := range bookFieldIndex {
+			fields = append(fields, name)
+		}
+	}
+	attributes := projectBook(book, fields)
+	delete(attributes, "id")
+	// the omitempty fields of the plain representation
+	if book.Relevance == nil {
+		delete(attributes, "relevance")
+	}
+	if !book.Deleted {
+		delete(attributes, "deleted")
+	}
+
+	return JSONAPIResource{
+		Type:       bookResourceType,
+		ID:         strconv.Itoa(book.ID),
+		Attributes: attributes,
+		Links:      &JSONAPILinks{Self: bookURL(c, book.ID)},
+	}
+}
+
+func bookResources(c echo.Context, books []*models.Book, fields []string) []JSONAPIResource {
+	resources := make([]JSONAPIResource, len(books))
+	for i, book := range books {
+		resources[i] = bookResource(c, book, fields)
+	}
+	return resources
+}
+
+// bookURL is the canonical URL of a book under the API version of the
+// current request.
+func bookURL(c echo.Context, id int) string {
+	path := c.Request().URL.Path
+	if i := strings.Index(path, "/books"); i >= 0 {
+		path = path[:i+len("/books")]
+	}
+	return pagination.BaseURL(c) + path + "/" + strconv.Itoa(id)
+}
+
+// jsonAPIErrors converts resp into JSON:API error objects, one per detail.
+// Details of body validation point into the resource attributes; the rest
+// name the offending query parameter.
+func jsonAPIErrors(resp ErrorResponse) []JSONAPIError {
+	status := strconv.Itoa(resp.Code)
+	if len(resp.Details) == 0 {
+		return []JSONAPIError{{Status: status, Code: resp.Error, Title: resp.Message}}
+	}
+
+	errs := make([]JSONAPIError, len(resp.Details))
+	for i, detail := range resp.Details {
+		source := &JSONAPIErrorSource{Parameter: detail.Field}
+		if resp.Error == ErrCodeValidation || resp.Error == ErrCodeSchemaViolation {
+			source = &JSONAPIErrorSource{Pointer: "/data/attributes/" + detail.Field}
+		}
+		errs[i] = JSONAPIError{
+			Status: status,
+			Code:   resp.Error,
+			Title:  resp.Message,
+			Detail: detail.Message,
+			Source: source,
+		}
+	}
+	return errs
+}
+
+// jsonAPIListLinks maps the pagination links onto JSON:API's.
+func jsonAPIListLinks(links models.PaginationLinks) *JSONAPILinks {
+	return &JSONAPILinks{
+		Self:  links.Self,
+		First: links.First,
+		Prev:  links.Prev,
+		Next:  links.Next,
+		Last:  links.Last,
+	}
+}
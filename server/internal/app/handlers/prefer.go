@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderPrefer            = "Prefer"
+	HeaderPreferenceApplied = "Preference-Applied"
+
+	preferReturnMinimal        = "minimal"
+	preferReturnRepresentation = "representation"
+)
+
+// preferReturn returns the return preference of the request (RFC 7240), either
+// preferReturnMinimal or preferReturnRepresentation, or "" when none of the
+// Prefer headers states one. The first return preference wins.
+func preferReturn(c echo.Context) string {
+	for _, header := range c.Request().Header.Values(HeaderPrefer) {
+		for _, pref := range strings.Split(header, ",") {
+			// parameters after ';' do not apply to return
+			pref, _, _ = strings.Cut(pref, ";")
+			name, value, _ := strings.Cut(pref, "=")
+			if !strings.EqualFold(strings.TrimSpace(name), "return") {
+				continue
+			}
+			switch value = strings.ToLower(strings.Trim(strings.TrimSpace(value), `"`)); value {
+			case preferReturnMinimal, preferReturnRepresentation:
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// applyPreferReturn records the honored return preference in
+// Preference-Applied and reports whether the body should be left out.
+func applyPreferReturn(c echo.Context) (minimal bool) {
+	c.Response().Header().Add(echo.HeaderVary, HeaderPrefer)
+	pref := preferReturn(c)
+	if pref != "" {
+		c.Response().Header().Set(HeaderPreferenceApplied, "return="+pref)
+	}
+	return pref == preferReturnMinimal
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bf-api/internal/infrastructure/tracing"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// MIMEApplicationProblemJSON is the RFC 7807 problem details media type.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Code and Errors are
+// extension members carrying what ErrorResponse does.
+type Problem struct {
+	// Type points at the error code's entry in the catalog at /api/v1/errors.
+	Type     string            `json:"type" example:"/api/v1/errors#not_found"`
+	Title    string            `json:"title" example:"The requested resource does not exist"`
+	Status   int               `json:"status" example:"404"`
+	Detail   string            `json:"detail,omitempty" example:"book not found"`
+	Instance string            `json:"instance,omitempty" example:"urn:trace:4bf92f3577b34da6"`
+	Code     ErrorCode         `json:"code" example:"not_found"`
+	Errors   []ValidationError `json:"errors,omitempty"`
+}
+
+// negotiateProblem reports whether the client accepts problem details.
+func negotiateProblem(c echo.Context) bool {
+	varyOnAccept(c)
+	for _, accept := range strings.Split(c.Request().Header.Get(echo.HeaderAccept), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == MIMEApplicationProblemJSON {
+			return true
+		}
+	}
+	return false
+}
+
+// problemFrom converts resp into problem details. The instance is the trace
+// ID of the request, so a report can be matched to its logs.
+func problemFrom(c echo.Context, resp ErrorResponse) Problem {
+	problem := Problem{
+		Type:   "/api/v1/errors#" + string(resp.Error),
+		Title:  http.StatusText(resp.Code),
+		Status: resp.Code,
+		Detail: resp.Message,
+		Code:   resp.Error,
+		Errors: resp.Details,
+	}
+	for _, info := range ErrorCatalog {
+		if info.Code == resp.Error {
+			problem.Title = info.Description
+			break
+		}
+	}
+	if traceID, ok := tracing.TraceIDFromContext(c.Request().Context()); ok {
+		problem.Instance = "urn:trace:" + traceID
+	}
+	return problem
+}
+
+func respondProblem(c echo.Context, resp ErrorResponse) error {
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	c.Response().WriteHeader(resp.Code)
+	return c.Echo().JSONSerializer.Serialize(c, problemFrom(c, resp), "")
+}
+
+// HTTPErrorHandler renders errors that reach echo, such as unknown routes or
+// errors returned by middleware, in the same shapes as handler errors.
+func HTTPErrorHandler(logger *zap.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		resp := ErrorResponse{
+			Error:   ErrCodeInternal,
+			Code:    http.StatusInternalServerError,
+			Message: "An unexpected server error occurred",
+		}
+		var he *echo.HTTPError
+		if errors.As(err, &he) {
+			resp.Code = he.Code
+			resp.Error = errorCodeForStatus(he.Code)
+			resp.Message = http.StatusText(he.Code)
+			if msg, ok := he.Message.(string); ok && msg != "" {
+				resp.Message = msg
+			}
+		}
+		if resp.Code >= http.StatusInternalServerError {
+			logger.Error("unhandled request error",
+				zap.Error(err),
+				zap.String("trace_id", getTraceID(c.Request().Context())),
+			)
+		}
+
+		var writeErr error
+		if c.Request().Method == http.MethodHead {
+			writeErr = c.NoContent(resp.Code)
+		} else {
+			writeErr = RespondError(c, resp)
+		}
+		if writeErr != nil {
+			logger.Error("failed to write error response", zap.Error(writeErr))
+		}
+	}
+}
+
+// errorCodeForStatus picks the ErrorCode for errors raised outside handlers,
+// where only the status is known.
+func errorCodeForStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeInvalidRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrCodeMethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return ErrCodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return ErrCodeUnsupportedMedia
+	case http.StatusTooManyRequests:
+		return ErrCodeRateLimited
+	case http.StatusServiceUnavailable:
+		return ErrCodeUnavailable
+	case http.StatusGatewayTimeout:
+		return ErrCodeTimeout
+	}
+	if status < http.StatusInternalServerError {
+		return ErrCodeInvalidRequest
+	}
+	return ErrCodeInternal
+}
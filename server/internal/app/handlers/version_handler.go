@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"bf-api/internal/buildinfo"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type VersionResponse struct {
+	Version       string    `json:"version" example:"v1.2.0"`
+	Commit        string    `json:"commit" example:"cb6c2e4"`
+	BuildTime     string    `json:"build_time" example:"2024-05-01T12:00:00Z"`
+	GoVersion     string    `json:"go_version" example:"go1.23.0"`
+	StartTime     time.Time `json:"start_time"`
+	UptimeSeconds int64     `json:"uptime_seconds" example:"3600"`
+}
+
+// Version returns the build metadata of the running binary. It is an ops
+// endpoint and deliberately left out of the public API docs.
+func Version(c echo.Context) error {
+	return c.JSON(http.StatusOK, VersionResponse{
+		Version:       buildinfo.Version,
+		Commit:        buildinfo.Commit,
+		BuildTime:     buildinfo.BuildTime,
+		GoVersion:     runtime.Version(),
+		StartTime:     buildinfo.StartTime.UTC(),
+		UptimeSeconds: int64(time.Since(buildinfo.StartTime).Seconds()),
+	})
+}
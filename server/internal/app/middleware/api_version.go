@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"bf-api/internal/app/handlers"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// APIVersion tags requests under prefix with the API version so handlers
+// serialize responses in that version's shape. It runs before routing, so
+// router errors such as 404 and 405 under the prefix are tagged too.
+func APIVersion(prefix string, version int) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			path := c.Request().URL.Path
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				c.Set(handlers.APIVersionKey, version)
+			}
+			return next(c)
+		}
+	}
+}
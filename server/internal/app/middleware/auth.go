@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bf-api/internal/app/handlers"
+	"bf-api/internal/infrastructure/auth"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+// Authenticate resolves the API key sent as a bearer token or in X-API-Key
+// and stores the owning principal in the request context. Requests without a
+// known key are rejected with 401.
+func Authenticate(cfg auth.Config) echo.MiddlewareFunc {
+	return authenticate(cfg, false)
+}
+
+// OptionalAuthenticate is like Authenticate but lets anonymous requests
+// through without a principal. A key that is sent but unknown is still
+// rejected with 401.
+func OptionalAuthenticate(cfg auth.Config) echo.MiddlewareFunc {
+	return authenticate(cfg, true)
+}
+
+func authenticate(cfg auth.Config, optional bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(HeaderAPIKey)
+			if bearer, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer "); ok {
+				key = bearer
+			}
+			if key == "" && optional {
+				return next(c)
+			}
+
+			principal, ok := cfg.Lookup(key)
+			if key == "" || !ok {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return handlers.RespondError(c, handlers.ErrorResponse{
+					Error:   handlers.ErrCodeUnauthorized,
+					Code:    http.StatusUnauthorized,
+					Message: "A valid API key is required",
+				})
+			}
+
+			req := c.Request()
+			c.SetRequest(req.WithContext(auth.WithPrincipal(req.Context(), principal)))
+			return next(c)
+		}
+	}
+}
+
+// RequireRole rejects authenticated callers holding none of roles with 403.
+// It must run after Authenticate.
+func RequireRole(roles ...auth.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, ok := auth.PrincipalFromContext(c.Request().Context())
+			if !ok || !principal.HasRole(roles...) {
+				return handlers.RespondError(c, handlers.ErrorResponse{
+					Error:   handlers.ErrCodeForbidden,
+					Code:    http.StatusForbidden,
+					Message: "Insufficient permissions",
+				})
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"bf-api/internal/app/handlers"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// BodyLimit wraps echo's BodyLimit so oversize requests are answered with the
+// standard ErrorResponse instead of echo's generic error body. limit uses
+// echo's format, e.g. "1M" or "512K".
+func BodyLimit(limit string) echo.MiddlewareFunc {
+	bodyLimit := middleware.BodyLimit(limit)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		h := bodyLimit(next)
+		return func(c echo.Context) error {
+			err := h(c)
+			if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+				return handlers.RespondError(c, handlers.ErrorResponse{
+					Error:   handlers.ErrCodePayloadTooLarge,
+					Code:    http.StatusRequestEntityTooLarge,
+					Message: "Request body exceeds the " + limit + " limit",
+				})
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"bf-api/internal/infrastructure/tracing"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// redactedHeaders are never logged verbatim.
+var redactedHeaders = map[string]bool{
+	echo.HeaderAuthorization: true,
+	HeaderAPIKey:             true,
+	echo.HeaderCookie:        true,
+	echo.HeaderSetCookie:     true,
+}
+
+// redactedFields are JSON keys whose values are masked at any depth. They
+// include the confidential book fields, which only admins may read.
+var redactedFields = map[string]bool{
+	"password":         true,
+	"secret":           true,
+	"token":            true,
+	"api_key":          true,
+	"acquisition_cost": true,
+	"supplier_notes":   true,
+}
+
+const redacted = "[REDACTED]"
+
+// BodyLogger logs request and response headers and bodies at debug level,
+// truncated to maxBytes each. Bodies are captured as the handler reads and
+// writes them, so the request body stays intact for the handler. Meant for
+// debugging only: keep it disabled in production.
+func BodyLogger(logger *zap.Logger, maxBytes int) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !logger.Core().Enabled(zap.DebugLevel) {
+				return next(c)
+			}
+
+			req := c.Request()
+			reqBody := &cappedBuffer{max: maxBytes}
+			if req.Body != nil {
+				req.Body = &teeReadCloser{Reader: io.TeeReader(req.Body, reqBody), Closer: req.Body}
+			}
+
+			res := c.Response()
+			resBody := &cappedBuffer{max: maxBytes}
+			res.Writer = &bodyCaptureWriter{ResponseWriter: res.Writer, body: resBody}
+
+			err := next(c)
+
+			traceID, _ := tracing.TraceIDFromContext(req.Context())
+			logger.Debug("http exchange",
+				zap.String("method", req.Method),
+				zap.String("uri", req.RequestURI),
+				zap.Int("status", res.Status),
+				zap.String("trace_id", traceID),
+				zap.Any("request_headers", redactHeaders(req.Header)),
+				zap.String("request_body", redactBody(reqBody)),
+				zap.Any("response_headers", redactHeaders(res.Header())),
+				zap.String("response_body", redactBody(resBody)),
+			)
+
+			return err
+		}
+	}
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			out[name] = redacted
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+// redactBody masks sensitive fields of a JSON body. Bodies that are not valid
+// JSON are returned as captured, unless they were truncated: a truncated body
+// cannot be parsed as a whole, so it is redacted as it is scanned.
+func redactBody(b *cappedBuffer) string {
+	if b.truncated {
+		return redactTruncated(b.Bytes()) + "...(truncated)"
+	}
+
+	body := b.String()
+	var v interface{}
+	if json.Unmarshal(b.Bytes(), &v) != nil {
+		return body
+	}
+	masked, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return body
+	}
+	return string(masked)
+}
+
+// redactTruncated masks sensitive fields of a JSON body cut off at the size
+// limit. The body is copied token by token up to the last complete one, and
+// the value of a sensitive key is replaced even when it is cut off itself.
+// Anything that is not JSON is replaced by its size.
+func redactTruncated(body []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	var out strings.Builder
+	copied := 0
+	// the open containers, innermost last; key is set while an object
+	// expects a key next
+	type container struct{ object, key bool }
+	var open []container
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Sprintf("[%d bytes, not JSON]", len(body))
+		}
+
+		top := len(open) - 1
+		isKey := top >= 0 && open[top].key
+		if top >= 0 && open[top].object {
+			// a key is followed by its value, a value by the next key
+			open[top].key = !isKey
+		}
+		switch tok {
+		case json.Delim('{'):
+			open = append(open, container{object: true, key: true})
+		case json.Delim('['):
+			open = append(open, container{})
+		case json.Delim('}'), json.Delim(']'):
+			open = open[:top]
+		}
+		if name, ok := tok.(string); !ok || !isKey || !redactedFields[strings.ToLower(name)] {
+			continue
+		}
+
+		out.Write(body[copied:dec.InputOffset()])
+		out.WriteString(`: "` + redacted + `"`)
+		open[top].key = true
+		if err := skipValue(dec); err != nil {
+			return out.String()
+		}
+		copied = int(dec.InputOffset())
+	}
+	out.Write(body[copied:dec.InputOffset()])
+	return out.String()
+}
+
+// skipValue consumes the next value from dec, however deeply nested.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if redactedFields[strings.ToLower(k)] {
+				t[k] = redacted
+			} else {
+				t[k] = redactValue(val)
+			}
+		}
+	case []interface{}:
+		for i, val := range t {
+			t[i] = redactValue(val)
+		}
+	}
+	return v
+}
+
+// cappedBuffer keeps the first max bytes written to it and discards the rest.
+type cappedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type bodyCaptureWriter struct {
+	http.ResponseWriter
+	body io.Writer
+}
+
+func (w *bodyCaptureWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyCaptureWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *bodyCaptureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *bodyCaptureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import "testing"
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name, body string
+		want       string
+	}{
+		{"secrets", `{"password":"hunter2","API_KEY":"k","title":"Dune"}`, `{"API_KEY":"[REDACTED]","password":"[REDACTED]","title":"Dune"}`},
+		{"confidential book fields", `{"books":[{"acquisition_cost":"12.50","supplier_notes":"net 30","title":"Dune"}]}`,
+			`{"books":[{"acquisition_cost":"[REDACTED]","supplier_notes":"[REDACTED]","title":"Dune"}]}`},
+		{"numeric value", `{"acquisition_cost":12.5}`, `{"acquisition_cost":"[REDACTED]"}`},
+		{"not JSON", `title=Dune`, `title=Dune`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &cappedBuffer{max: 1024}
+			b.Write([]byte(tt.body))
+			if got := redactBody(b); got != tt.want {
+				t.Errorf("redactBody(%s) = %s, want %s", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactBodyTruncated(t *testing.T) {
+	tests := []struct {
+		name, body string
+		max        int
+		want       string
+	}{
+		{"cut in a sensitive value", `{"title":"Dune","supplier_notes":"net 30 days"}`, 40, `{"title":"Dune","supplier_notes": "[REDACTED]"`},
+		{"cut after a sensitive value", `{"password":"hunter2","title":"Dune Messiah"}`, 40, `{"password": "[REDACTED]","title"`},
+		{"nested", `{"books":[{"ACQUISITION_COST":{"amount":"12.50"},"isbn":"9780306406157"},{"isbn":"9780140449136"}]}`, 90,
+			`{"books":[{"ACQUISITION_COST": "[REDACTED]","isbn":"9780306406157"},{"isbn"`},
+		{"not JSON", `password=hunter2&title=Dune`, 20, `[20 bytes, not JSON]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &cappedBuffer{max: tt.max}
+			b.Write([]byte(tt.body))
+			want := tt.want + "...(truncated)"
+			if got := redactBody(b); got != want {
+				t.Errorf("redactBody(%s) = %s, want %s", b, got, want)
+			}
+		})
+	}
+}
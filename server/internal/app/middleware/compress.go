@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+// CompressConfig configures Compress.
+type CompressConfig struct {
+	Skipper middleware.Skipper
+
+	GzipLevel   int // 1 (fastest) to 9 (best), -1 for the gzip default
+	BrotliLevel int // 0 (fastest) to 11 (best)
+	// MinLength is the smallest response, in bytes, worth compressing;
+	// shorter ones are sent as is.
+	MinLength int
+}
+
+// Compress encodes responses with Brotli or gzip, whichever the client's
+// Accept-Encoding ranks higher, preferring Brotli on a tie. Clients that accept
+// neither get the response uncompressed.
+func Compress(cfg CompressConfig) echo.MiddlewareFunc {
+	if cfg.Skipper == nil {
+		cfg.Skipper = middleware.DefaultSkipper
+	}
+
+	gzipPool := sync.Pool{New: func() any {
+		w, err := gzip.NewWriterLevel(io.Discard, cfg.GzipLevel)
+		if err != nil {
+			w = gzip.NewWriter(io.Discard)
+		}
+		return w
+	}}
+	brotliPool := sync.Pool{New: func() any {
+		return brotli.NewWriterLevel(io.Discard, cfg.BrotliLevel)
+	}}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if cfg.Skipper(c) {
+				return next(c)
+			}
+
+			res := c.Response()
+			res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+
+			encoding := negotiateEncoding(c.Request().Header.Get(echo.HeaderAcceptEncoding))
+			if encoding == "" {
+				return next(c)
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: res.Writer,
+				encoding:       encoding,
+				minLength:      cfg.MinLength,
+			}
+			switch encoding {
+			case encodingBrotli:
+				bw := brotliPool.Get().(*brotli.Writer)
+				defer brotliPool.Put(bw)
+				cw.newEncoder = func(w io.Writer) io.WriteCloser { bw.Reset(w); return bw }
+				cw.flushEncoder = bw.Flush
+			case encodingGzip:
+				gw := gzipPool.Get().(*gzip.Writer)
+				defer gzipPool.Put(gw)
+				cw.newEncoder = func(w io.Writer) io.WriteCloser { gw.Reset(w); return gw }
+				cw.flushEncoder = gw.Flush
+			}
+
+			res.Writer = cw
+			defer func() {
+				cw.finish()
+				res.Writer = cw.ResponseWriter
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// negotiateEncoding picks the response encoding from an Accept-Encoding
+// header, honouring quality values. It returns "" for identity.
+func negotiateEncoding(accept string) string {
+	if accept == "" {
+		return ""
+	}
+
+	q := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		if coding == "*" {
+			wildcard = weight
+		} else {
+			q[coding] = weight
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range []string{encodingBrotli, encodingGzip} {
+		weight, ok := q[coding]
+		if !ok {
+			weight = max(wildcard, 0)
+		}
+		if weight > bestQ {
+			best, bestQ = coding, weight
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of a response until it reaches minLength,
+// then switches to compressing. Responses that end sooner, already carry a
+// Content-Encoding, or have no body are written unchanged.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding     string
+	minLength    int
+	newEncoder   func(io.Writer) io.WriteCloser
+	flushEncoder func() error
+
+	status      int
+	buf         bytes.Buffer
+	encoder     io.WriteCloser
+	passthrough bool
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	switch {
+	case w.encoder != nil:
+		return w.encoder.Write(p)
+	case w.passthrough:
+		return w.ResponseWriter.Write(p)
+	}
+
+	if w.Header().Get(echo.HeaderContentEncoding) != "" || !bodyAllowed(w.status) {
+		w.startPassthrough()
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf.Write(p)
+	if w.buf.Len() >= w.minLength {
+		if err := w.startEncoding(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *compressWriter) startEncoding() error {
+	h := w.Header()
+	h.Set(echo.HeaderContentEncoding, w.encoding)
+	h.Del(echo.HeaderContentLength)
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.encoder = w.newEncoder(w.ResponseWriter)
+	_, err := w.encoder.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *compressWriter) startPassthrough() {
+	w.passthrough = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+// finish completes the response once the handler has returned.
+func (w *compressWriter) finish() {
+	switch {
+	case w.encoder != nil:
+		w.encoder.Close()
+	case !w.passthrough:
+		w.startPassthrough()
+	}
+}
+
+// Flush starts compressing right away so streamed output is not held back
+// waiting for minLength.
+func (w *compressWriter) Flush() {
+	if w.encoder == nil && !w.passthrough {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if w.Header().Get(echo.HeaderContentEncoding) != "" || !bodyAllowed(w.status) {
+			w.startPassthrough()
+		} else if err := w.startEncoding(); err != nil {
+			return
+		}
+	}
+	if w.encoder != nil {
+		_ = w.flushEncoder()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
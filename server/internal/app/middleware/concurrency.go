@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"bf-api/internal/app/handlers"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/semaphore"
+)
+
+var (
+	inFlightRequests = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_in_flight_requests",
+		Help: "Requests currently holding a concurrency slot.",
+	})
+	rejectedRequests = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "http_concurrency_rejected_requests_total",
+		Help: "Requests rejected with 503 because every concurrency slot was taken.",
+	})
+)
+
+// ConcurrencyLimit caps the number of requests handled at once at max,
+// protecting the database pool from more work than it can take. Requests
+// beyond the limit are not queued but rejected with 503 and Retry-After. A
+// max of 0 or less only counts requests in flight.
+func ConcurrencyLimit(max int64) echo.MiddlewareFunc {
+	var sem *semaphore.Weighted
+	if max > 0 {
+		sem = semaphore.NewWeighted(max)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if sem != nil {
+				if !sem.TryAcquire(1) {
+					rejectedRequests.Inc()
+					c.Response().Header().Set("Retry-After", handlers.UnavailableRetryAfter)
+					return handlers.RespondError(c, handlers.ErrorResponse{
+						Error:   handlers.ErrCodeUnavailable,
+						Code:    http.StatusServiceUnavailable,
+						Message: "Too many concurrent requests; retry later",
+					})
+				}
+				defer sem.Release(1)
+			}
+
+			inFlightRequests.Inc()
+			defer inFlightRequests.Dec()
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"bf-api/internal/app/handlers"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type CORSConfig struct {
+	// AllowOrigins lists the origins browsers may call the API from; "*"
+	// allows any origin. Empty disables CORS.
+	AllowOrigins []string
+	// AllowCredentials lets browsers send cookies and Authorization headers
+	// cross-origin. It cannot be combined with a wildcard origin.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// CORS answers preflight requests and adds CORS headers for the configured
+// origins. The headers clients need to follow writes, revalidate and report
+// problems (ETag, Location and X-Trace-ID) are exposed to scripts.
+func CORS(cfg CORSConfig) echo.MiddlewareFunc {
+	if len(cfg.AllowOrigins) == 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: cfg.AllowOrigins,
+		AllowMethods: []string{
+			http.MethodGet, http.MethodHead, http.MethodPost,
+			http.MethodPut, http.MethodPatch, http.MethodDelete,
+		},
+		AllowHeaders: []string{
+			echo.HeaderAccept,
+			echo.HeaderAuthorization,
+			echo.HeaderContentType,
+			"If-None-Match",
+			"If-Unmodified-Since",
+			"Idempotency-Key",
+			handlers.HeaderPrefer,
+			HeaderAPIKey,
+			HeaderRequestTimeout,
+			HeaderTenantID,
+			HeaderTraceID,
+		},
+		ExposeHeaders: []string{
+			"ETag",
+			echo.HeaderLocation,
+			HeaderTraceID,
+		},
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           int(cfg.MaxAge / time.Second),
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"bf-api/internal/app/handlers"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+)
+
+// InFlight counts requests that are currently being handled and, once
+// draining, turns new ones away.
+type InFlight struct {
+	count    atomic.Int64
+	draining atomic.Bool
+}
+
+func NewInFlight() *InFlight {
+	return &InFlight{}
+}
+
+func (f *InFlight) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if f.draining.Load() {
+			// closing the connection sends the client's retry through the
+			// load balancer to an instance that is not going away
+			c.Response().Header().Set(echo.HeaderConnection, "close")
+			c.Response().Header().Set("Retry-After", handlers.UnavailableRetryAfter)
+			return handlers.RespondError(c, handlers.ErrorResponse{
+				Error:   handlers.ErrCodeUnavailable,
+				Code:    http.StatusServiceUnavailable,
+				Message: "Server is shutting down; retry later",
+			})
+		}
+
+		f.count.Add(1)
+		defer f.count.Add(-1)
+		return next(c)
+	}
+}
+
+// Drain makes the middleware reject every new request with 503 from now on,
+// while requests already in flight run to completion.
+func (f *InFlight) Drain() {
+	f.draining.Store(true)
+}
+
+// Count returns the number of requests in flight.
+func (f *InFlight) Count() int64 {
+	return f.count.Load()
+}
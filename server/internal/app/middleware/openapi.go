@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"bf-api/internal/app/handlers"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/labstack/echo/v4"
+)
+
+// OpenAPIValidator rejects requests whose parameters or body do not match
+// spec, the Swagger 2.0 document generated by swag, before they reach a
+// handler. Object schemas are closed, so unknown JSON fields are rejected too.
+// Routes missing from the spec pass through unvalidated.
+func OpenAPIValidator(spec string, basePaths ...string) (echo.MiddlewareFunc, error) {
+	var doc2 openapi2.T
+	if err := json.Unmarshal([]byte(spec), &doc2); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	doc, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert OpenAPI spec: %w", err)
+	}
+
+	closed := false
+	for _, schema := range doc.Components.Schemas {
+		if schema.Value != nil && schema.Value.Type.Is(openapi3.TypeObject) {
+			schema.Value.AdditionalProperties = openapi3.AdditionalProperties{Has: &closed}
+		}
+	}
+
+	// the spec documents paths relative to each mounted API version
+	doc.Servers = nil
+	for _, base := range basePaths {
+		doc.Servers = append(doc.Servers, &openapi3.Server{URL: base})
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OpenAPI router: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			route, pathParams, err := router.FindRoute(req)
+			if errors.Is(err, routers.ErrPathNotFound) || errors.Is(err, routers.ErrMethodNotAllowed) {
+				return next(c)
+			}
+			if err != nil {
+				return err
+			}
+
+			err = openapi3filter.ValidateRequest(req.Context(), &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			})
+			if err != nil {
+				return handlers.RespondError(c, handlers.ErrorResponse{
+					Error:   handlers.ErrCodeSchemaViolation,
+					Code:    http.StatusBadRequest,
+					Message: "Request does not match the API schema",
+					Details: openAPIDetails(err),
+				})
+			}
+
+			return next(c)
+		}
+	}, nil
+}
+
+// openAPIDetails flattens validation errors into one detail per offending
+// parameter or body field.
+func openAPIDetails(err error) []handlers.ValidationError {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		var details []handlers.ValidationError
+		for _, e := range multi {
+			details = append(details, openAPIDetails(e)...)
+		}
+		return details
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		if reqErr.Parameter != nil {
+			return []handlers.ValidationError{{Field: reqErr.Parameter.Name, Message: reqErr.Error()}}
+		}
+		if reqErr.Err != nil {
+			if details := openAPIDetails(reqErr.Err); len(details) > 0 {
+				return details
+			}
+		}
+		return []handlers.ValidationError{{Field: "body", Message: reqErr.Error()}}
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		field := strings.Join(schemaErr.JSONPointer(), ".")
+		if field == "" {
+			field = "body"
+		}
+		return []handlers.ValidationError{{Field: field, Message: schemaErr.Reason}}
+	}
+
+	return nil
+}
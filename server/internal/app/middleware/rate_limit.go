@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitStore allows each client a number of requests per second that
+// can be changed while serving. Use it as the Store of echo's rate limiter.
+type RateLimitStore struct {
+	store atomic.Pointer[middleware.RateLimiterMemoryStore]
+}
+
+// NewRateLimitStore returns a RateLimitStore allowing perSecond requests per
+// second to each client.
+func NewRateLimitStore(perSecond int) *RateLimitStore {
+	s := &RateLimitStore{}
+	s.SetRate(perSecond)
+	return s
+}
+
+// SetRate changes the requests per second allowed to each client. Every
+// client starts over with a full budget at the new rate.
+func (s *RateLimitStore) SetRate(perSecond int) {
+	s.store.Store(middleware.NewRateLimiterMemoryStore(rate.Limit(perSecond)))
+}
+
+func (s *RateLimitStore) Allow(identifier string) (bool, error) {
+	return s.store.Load().Allow(identifier)
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"bf-api/internal/app/handlers"
+	"bf-api/internal/domain/tenant"
+	"bf-api/internal/infrastructure/auth"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+const HeaderTenantID = "X-Tenant-ID"
+
+// Tenant stores the tenant of the request in its context: the tenant the
+// caller's API key is bound to, else the one an unbound admin names in
+// X-Tenant-ID, else tenant.Default. Anonymous callers cannot leave
+// tenant.Default. It must run after OptionalAuthenticate or Authenticate.
+func Tenant() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			principal, _ := auth.PrincipalFromContext(req.Context())
+
+			id, err := tenant.Resolve(principal.Tenant, principal.MayChooseTenant(), req.Header.Get(HeaderTenantID))
+			switch {
+			case errors.Is(err, tenant.ErrMismatch):
+				return handlers.RespondError(c, handlers.ErrorResponse{
+					Error:   handlers.ErrCodeForbidden,
+					Code:    http.StatusForbidden,
+					Message: "The caller may not act on the requested tenant",
+				})
+			case err != nil:
+				return handlers.RespondError(c, handlers.ErrorResponse{
+					Error:   handlers.ErrCodeInvalidTenant,
+					Code:    http.StatusBadRequest,
+					Message: "Invalid X-Tenant-ID header",
+				})
+			}
+
+			// cached responses must not be served across tenants, and the
+			// tenant may come from the key as well as the header
+			c.Response().Header().Add(echo.HeaderVary, HeaderTenantID)
+			c.Response().Header().Add(echo.HeaderVary, echo.HeaderAuthorization)
+			c.Response().Header().Add(echo.HeaderVary, HeaderAPIKey)
+			c.SetRequest(req.WithContext(tenant.WithTenant(req.Context(), id)))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"bf-api/internal/app/handlers"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderRequestTimeout lets a client that will stop waiting after some number
+// of milliseconds shorten a request's deadline to match.
+const HeaderRequestTimeout = "X-Request-Timeout"
+
+// Timeout gives the request context a deadline d from now, so every query the
+// handler runs is cancelled once the route's budget is spent. A client can
+// ask for less with X-Request-Timeout; a longer value is clamped to d and one
+// that is not a positive number of milliseconds is ignored. The handler
+// runs on the request goroutine and is never abandoned: it returns as soon as
+// its blocked query sees the deadline, which leaves nothing running behind
+// the response. If it returns after the deadline without having written a
+// response, a 504 is sent. A deadline already on the context, e.g. one set by
+// an earlier middleware, still applies when it is earlier. A d of 0 or less disables it.
+func Timeout(d time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if d <= 0 {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			budget := d
+			if ms, ok := clientTimeoutMillis(c.Request()); ok && ms < d.Milliseconds() {
+				budget = time.Duration(ms) * time.Millisecond
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request().Context(), budget)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			err := next(c)
+			if ctx.Err() == context.DeadlineExceeded && !c.Response().Committed {
+				return handlers.RespondError(c, handlers.ErrorResponse{
+					Error:   handlers.ErrCodeTimeout,
+					Code:    http.StatusGatewayTimeout,
+					Message: "Request timed out",
+				})
+			}
+			return err
+		}
+	}
+}
+
+// clientTimeoutMillis returns the timeout the client asked for in
+// X-Request-Timeout, if it is a positive number of milliseconds.
+func clientTimeoutMillis(req *http.Request) (int64, bool) {
+	ms, err := strconv.ParseInt(req.Header.Get(HeaderRequestTimeout), 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, false
+	}
+	return ms, true
+}
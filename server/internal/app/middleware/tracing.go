@@ -1,7 +1,8 @@
 package middleware
 
 import (
-	"context"
+	"bf-api/internal/infrastructure/tracing"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,22 +10,29 @@ import (
 	"go.uber.org/zap"
 )
 
-type contextKey string
-
 const (
-	TraceIDKey contextKey = "trace_id"
+	HeaderTraceID     = "X-Trace-ID"
+	HeaderTraceparent = "traceparent"
 )
 
+// Tracing propagates the caller's trace ID when one is supplied, via
+// X-Trace-ID, a W3C traceparent or X-Request-ID, and generates one otherwise.
+// The same ID is echoed in both X-Trace-ID and X-Request-ID so it replaces
+// echo's RequestID middleware rather than competing with it.
 func Tracing() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			start := time.Now()
-			traceID := uuid.New().String()
+			traceID := incomingTraceID(c)
+			if traceID == "" {
+				traceID = uuid.New().String()
+			}
 
-			ctx := context.WithValue(c.Request().Context(), TraceIDKey, traceID)
+			ctx := tracing.WithTraceID(c.Request().Context(), traceID)
 			c.SetRequest(c.Request().WithContext(ctx))
 
-			c.Response().Header().Set("X-Trace-ID", traceID)
+			c.Response().Header().Set(HeaderTraceID, traceID)
+			c.Response().Header().Set(echo.HeaderXRequestID, traceID)
 
 			zap.L().Info("request started",
 				zap.String("trace_id", traceID),
@@ -44,3 +52,42 @@ func Tracing() echo.MiddlewareFunc {
 		}
 	}
 }
+
+// incomingTraceID returns the normalized trace ID sent by the caller, or ""
+// when none is present or it is not a well-formed ID. Only values that parse
+// as a UUID are accepted so arbitrary header content never reaches the logs.
+func incomingTraceID(c echo.Context) string {
+	header := c.Request().Header
+
+	if traceID := parseUUID(header.Get(HeaderTraceID)); traceID != "" {
+		return traceID
+	}
+	if traceID := parseTraceparent(header.Get(HeaderTraceparent)); traceID != "" {
+		return traceID
+	}
+	return parseUUID(header.Get(echo.HeaderXRequestID))
+}
+
+func parseUUID(value string) string {
+	if value == "" {
+		return ""
+	}
+	id, err := uuid.Parse(strings.TrimSpace(value))
+	if err != nil || id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
+
+// parseTraceparent extracts the 16-byte trace-id from a W3C traceparent
+// header (version-traceid-parentid-flags) and formats it as a UUID.
+func parseTraceparent(value string) string {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ""
+	}
+	if parts[0] == "ff" {
+		return ""
+	}
+	return parseUUID(parts[1])
+}
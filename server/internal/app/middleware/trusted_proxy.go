@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"bf-api/internal/app/pagination"
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TrustedProxy marks requests whose peer is one of trustedProxies, the same
+// proxies trusted with X-Forwarded-For, so the X-Forwarded-Host and
+// X-Forwarded-Proto they set may be used in links. The peer is the address of
+// the connection, which a client cannot spoof. It runs before routing.
+func TrustedProxy(trustedProxies []*net.IPNet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if host, _, err := net.SplitHostPort(c.Request().RemoteAddr); err == nil {
+				if ip := net.ParseIP(host); ip != nil && containsIP(trustedProxies, ip) {
+					c.Set(pagination.TrustedProxyKey, true)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"bf-api/internal/app/pagination"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestTrustedProxyBaseURL(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	e := echo.New()
+	e.Pre(TrustedProxy([]*net.IPNet{proxies}))
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, pagination.BaseURL(c))
+	})
+
+	tests := []struct {
+		peer string
+		want string
+	}{
+		{"10.1.2.3:4000", "https://books.example.com"},
+		// anyone else could point the links at their own site
+		{"203.0.113.7:4000", "http://api.internal"},
+		{"127.0.0.1:4000", "http://api.internal"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "http://api.internal/", nil)
+		req.RemoteAddr = tt.peer
+		req.Header.Set("X-Forwarded-Host", "books.example.com")
+		req.Header.Set("X-Forwarded-Proto", "https")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if got := rec.Body.String(); got != tt.want {
+			t.Errorf("BaseURL() from %s = %q, want %q", tt.peer, got, tt.want)
+		}
+	}
+}
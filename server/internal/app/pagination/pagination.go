@@ -0,0 +1,207 @@
+// Package pagination parses page and limit query parameters and builds the
+// page counts and links list endpoints return.
+package pagination
+
+import (
+	"bf-api/internal/domain/models"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Limits bound the page size of list endpoints.
+type Limits struct {
+	DefaultLimit int // used when the client sends no limit; def: 20
+	MaxLimit     int // larger limits are clamped to this; def: 100
+}
+
+// DefaultLimits are used by Parse.
+var DefaultLimits = Limits{DefaultLimit: 20, MaxLimit: 100}
+
+// Params are the validated pagination parameters of a request.
+type Params struct {
+	Page   int
+	Limit  int
+	Offset int
+	// Warning explains a limit that was clamped; empty otherwise.
+	Warning string
+
+	base  string
+	path  string
+	query url.Values
+}
+
+// Error reports an invalid pagination parameter.
+type Error struct {
+	Field   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Parse reads page and limit from c using DefaultLimits.
+func Parse(c echo.Context) (Params, error) {
+	return DefaultLimits.Parse(c)
+}
+
+// Parse reads page and limit from c. A missing or invalid page is the first
+// page; a missing or zero limit is DefaultLimit and one above MaxLimit is
+// clamped with a warning. A negative or non-numeric limit is an *Error, as is
+// a page so far out that its offset would exceed math.MaxInt32.
+func (l Limits) Parse(c echo.Context) (Params, error) {
+	limit, warning, err := l.parseLimit(c.QueryParam("limit"))
+	if err != nil {
+		return Params{}, err
+	}
+
+	// an out of range page parses as the largest int, which is rejected below
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if (err != nil && !errors.Is(err, strconv.ErrRange)) || page < 1 {
+		page = 1
+	}
+	if maxPage := math.MaxInt32 / limit; page > maxPage {
+		return Params{}, &Error{Field: "page", Message: fmt.Sprintf("Must be at most %d", maxPage)}
+	}
+
+	return Params{
+		Page:    page,
+		Limit:   limit,
+		Offset:  (page - 1) * limit,
+		Warning: warning,
+		base:    BaseURL(c),
+		path:    c.Request().URL.Path,
+		query:   c.Request().URL.Query(),
+	}, nil
+}
+
+func (l Limits) parseLimit(raw string) (int, string, error) {
+	if raw == "" {
+		return l.DefaultLimit, "", nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 0 {
+		return 0, "", &Error{Field: "limit", Message: "Must be a non-negative integer"}
+	}
+
+	switch {
+	case limit == 0:
+		return l.DefaultLimit, "", nil
+	case limit > l.MaxLimit:
+		return l.MaxLimit, fmt.Sprintf("limit %d exceeds the maximum of %d; %d items are returned per page", limit, l.MaxLimit, l.MaxLimit), nil
+	}
+	return limit, "", nil
+}
+
+// With returns a copy of p whose links also carry key=value, e.g. to pin a
+// snapshot time so later pages see the same result set.
+func (p Params) With(key, value string) Params {
+	query := make(url.Values, len(p.query)+1)
+	for k, v := range p.query {
+		query[k] = v
+	}
+	query.Set(key, value)
+	p.query = query
+	return p
+}
+
+// Response describes where a page sits in the full result set.
+type Response struct {
+	Page       int
+	Limit      int
+	TotalPages int
+	TotalItems int
+	Links      models.PaginationLinks
+}
+
+// BuildResponse computes the page count for total items and absolute links
+// to the surrounding pages, omitting next/prev at either boundary. There is
+// always at least one page, even when total is zero.
+func BuildResponse(total int, p Params) Response {
+	totalPages := (total + p.Limit - 1) / p.Limit
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	links := models.PaginationLinks{
+		Self:  p.pageURL(p.Page),
+		First: p.pageURL(1),
+		Last:  p.pageURL(totalPages),
+	}
+	if p.Page < totalPages {
+		links.Next = p.pageURL(p.Page + 1)
+	}
+	if p.Page > 1 {
+		links.Prev = p.pageURL(min(p.Page-1, totalPages))
+	}
+
+	return Response{
+		Page:       p.Page,
+		Limit:      p.Limit,
+		TotalPages: totalPages,
+		TotalItems: total,
+		Links:      links,
+	}
+}
+
+func (p Params) pageURL(page int) string {
+	query := make(url.Values, len(p.query)+2)
+	for k, v := range p.query {
+		query[k] = v
+	}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("limit", strconv.Itoa(p.Limit))
+	return p.base + p.path + "?" + query.Encode()
+}
+
+// LinkHeader renders the links as an RFC 5988 Link header in the style of
+// the GitHub API: first and prev are omitted on the first page, next and last
+// on the last one.
+func (r Response) LinkHeader() string {
+	var parts []string
+	add := func(url, rel string) {
+		parts = append(parts, fmt.Sprintf(`<%s>; rel="%s"`, url, rel))
+	}
+
+	if r.Page > 1 {
+		add(r.Links.First, "first")
+		add(r.Links.Prev, "prev")
+	}
+	if r.Page < r.TotalPages {
+		add(r.Links.Next, "next")
+		add(r.Links.Last, "last")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// TrustedProxyKey is the echo context key set to true when the request came
+// from a trusted proxy, whose X-Forwarded headers may be believed.
+const TrustedProxyKey = "trusted_proxy"
+
+// BaseURL derives scheme://host for the request. X-Forwarded-Host and
+// X-Forwarded-Proto are only honored for requests from a trusted proxy, so
+// clients cannot point the links at another site.
+func BaseURL(c echo.Context) string {
+	req := c.Request()
+	if trusted, _ := c.Get(TrustedProxyKey).(bool); !trusted {
+		scheme := "http"
+		if req.TLS != nil {
+			scheme = "https"
+		}
+		return scheme + "://" + req.Host
+	}
+
+	host := req.Host
+	if forwarded := req.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return c.Scheme() + "://" + host
+}
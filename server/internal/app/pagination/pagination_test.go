@@ -0,0 +1,44 @@
+package pagination
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		query    string
+		wantPage int
+		wantErr  bool
+	}{
+		{"", 1, false},
+		{"page=abc", 1, false},
+		{"page=-3", 1, false},
+		{"page=5&limit=10", 5, false},
+		// the offset of the last page still fits in an INT4
+		{"page=214748364&limit=10", 214748364, false},
+		{"page=214748365&limit=10", 0, true},
+		{"page=99999999999999999999", 0, true},
+		{"page=-99999999999999999999", 1, false},
+	}
+	e := echo.New()
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/books?"+tt.query, nil)
+		p, err := Parse(e.NewContext(req, httptest.NewRecorder()))
+
+		var perr *Error
+		if tt.wantErr {
+			if !errors.As(err, &perr) || perr.Field != "page" {
+				t.Errorf("Parse(%q) error = %v, want a page error", tt.query, err)
+			}
+			continue
+		}
+		if err != nil || p.Page != tt.wantPage || p.Offset != (tt.wantPage-1)*p.Limit {
+			t.Errorf("Parse(%q) = page %d offset %d, %v; want page %d", tt.query, p.Page, p.Offset, err, tt.wantPage)
+		}
+	}
+}
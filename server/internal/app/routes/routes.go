@@ -1,21 +1,50 @@
 package routes
 
 import (
+	"bf-api/docs"
+	"bf-api/internal/app/gql"
 	"bf-api/internal/app/handlers"
 	bfMiddleware "bf-api/internal/app/middleware"
+	"bf-api/internal/config"
+	"bf-api/internal/infrastructure/auth"
 
 	"bf-api/internal/domain/services"
+	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	echoSwagger "github.com/swaggo/echo-swagger"
 	"go.uber.org/zap"
 )
 
-func APIRouter(e *echo.Echo, bookHandler *handlers.BookHandler, bookService *services.BookService, logger *zap.Logger) {
+// coverBodyLimit fits a services.MaxCoverBytes image plus multipart framing.
+const coverBodyLimit = "6M"
+
+// APIRouter registers every route on e. rateLimit limits the API requests of
+// each client IP. debugHandler may be nil, in which case the /debug endpoints
+// are not mounted.
+func APIRouter(e *echo.Echo, cfg config.HTTPConfig, authCfg auth.Config, rateLimit *bfMiddleware.RateLimitStore, bookHandler *handlers.BookHandler, healthHandler *handlers.HealthHandler, debugHandler *handlers.DebugHandler, bookService *services.BookService, logger *zap.Logger) {
+	// unknown routes and middleware errors answer in the handlers' error shapes
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler(logger)
+	e.JSONSerializer = handlers.JSONSerializer{Pretty: cfg.PrettyJSON}
+
+	// v2 shares handlers with v1 but wraps every response in an envelope
+	e.Pre(bfMiddleware.APIVersion("/api/v2", 2))
+	// links honor X-Forwarded-Host only from the proxies trusted with the client IP
+	e.Pre(bfMiddleware.TrustedProxy(cfg.TrustedProxies))
+
 	e.Use(
 		middleware.Recover(),
-		middleware.RequestID(),
+		// before the limit so preflights are never rejected as overload
+		bfMiddleware.CORS(bfMiddleware.CORSConfig{
+			AllowOrigins:     cfg.CORSAllowOrigins,
+			AllowCredentials: cfg.CORSAllowCredentials,
+			MaxAge:           cfg.CORSMaxAge,
+		}),
+		bfMiddleware.ConcurrencyLimit(cfg.MaxConcurrentRequests),
 		bfMiddleware.Tracing(),
 		middleware.RequestLoggerWithConfig(
 			middleware.RequestLoggerConfig{
@@ -35,23 +64,147 @@ func APIRouter(e *echo.Echo, bookHandler *handlers.BookHandler, bookService *ser
 		),
 	)
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
+	e.GET("/version", handlers.Version)
+	e.GET("/startupz", healthHandler.Startup)
+	e.GET("/readyz", healthHandler.Ready)
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+
+	// middleware is attached per route rather than per group throughout:
+	// group middleware makes echo register catch-all routes for the group,
+	// which turn a wrong method on an existing path into a 404 instead of a
+	// 405 with an Allow header
+	if debugHandler != nil {
+		e.GET("/debug/pool", debugHandler.PoolStats, bfMiddleware.Authenticate(authCfg), bfMiddleware.RequireRole(auth.RoleAdmin))
+	}
+
 	v1 := e.Group("/api/v1")
 
 	v1.GET("/health", func(c echo.Context) error {
 		return c.JSON(200, map[string]string{"status": "ok"})
 	})
+	v1.GET("/errors", handlers.ListErrorCodes)
 
-	bookRoutes := v1.Group("/books")
-	bookRoutes.Use(
-		middleware.Gzip(),
+	// the same instances serve both versions so limits are shared
+	bookMiddleware := []echo.MiddlewareFunc{
+		bfMiddleware.Compress(bfMiddleware.CompressConfig{
+			GzipLevel:   cfg.GzipLevel,
+			BrotliLevel: cfg.BrotliLevel,
+			MinLength:   cfg.GzipMinLength,
+			// HEAD responses never carry a body worth compressing, and byte
+			// ranges of the export must index the uncompressed file
+			Skipper: func(c echo.Context) bool {
+				return c.Request().Method == http.MethodHead || strings.HasSuffix(c.Path(), "/books/export")
+			},
+		}),
 		middleware.Secure(),
-		middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(5)),
-	)
+		// anonymous access stays open; a key unlocks admin-only options
+		bfMiddleware.OptionalAuthenticate(authCfg),
+		// books are scoped to the tenant of the key, or the one requested
+		bfMiddleware.Tenant(),
+		middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+			Store: rateLimit,
+			DenyHandler: func(c echo.Context, _ string, _ error) error {
+				return handlers.RespondError(c, handlers.ErrorResponse{
+					Error:   handlers.ErrCodeRateLimited,
+					Code:    http.StatusTooManyRequests,
+					Message: "Rate limit exceeded",
+				})
+			},
+		}),
+	}
+
+	if cfg.OpenAPIValidation {
+		validator, err := bfMiddleware.OpenAPIValidator(docs.SwaggerInfo.ReadDoc(), "/api/v1", "/api/v2")
+		if err != nil {
+			logger.Fatal("failed to load OpenAPI spec for request validation", zap.Error(err))
+		}
+		bookMiddleware = append(bookMiddleware, validator)
+	}
+	if cfg.LogBodies {
+		// after compression so the uncompressed response is captured
+		bookMiddleware = append(bookMiddleware, bfMiddleware.BodyLogger(logger, cfg.LogBodyMaxBytes))
+	}
+
+	// file uploads are registered outside the books groups so they get a
+	// body limit sized for the file rather than the one for JSON
+	uploadMiddleware := func(bodyLimit string) []echo.MiddlewareFunc {
+		mw := append([]echo.MiddlewareFunc{bfMiddleware.BodyLimit(bodyLimit)}, bookMiddleware...)
+		return append(mw, bfMiddleware.Timeout(cfg.BulkRequestTimeout),
+			bfMiddleware.Authenticate(authCfg), bfMiddleware.RequireRole(auth.RoleEditor))
+	}
+	coverUploadMiddleware := uploadMiddleware(coverBodyLimit)
+	importMiddleware := uploadMiddleware(cfg.ImportBodyLimit)
+	bookMiddleware = append([]echo.MiddlewareFunc{bfMiddleware.BodyLimit(cfg.BodyLimit)}, bookMiddleware...)
+
+	// book routes set their deadlines per route; the rest share the regular one
+	timedMiddleware := append(slices.Clip(bookMiddleware), bfMiddleware.Timeout(cfg.RequestTimeout))
+
+	// keep the @Security annotations on CreateAuthor in sync with these
+	authorMiddleware := append(slices.Clip(timedMiddleware),
+		bfMiddleware.Authenticate(authCfg), bfMiddleware.RequireRole(auth.RoleEditor))
+
+	// scans every book of the tenant, so it gets the bulk budget
+	adminMiddleware := append(slices.Clip(bookMiddleware), bfMiddleware.Timeout(cfg.BulkRequestTimeout),
+		bfMiddleware.Authenticate(authCfg), bfMiddleware.RequireRole(auth.RoleAdmin))
+	e.GET("/admin/integrity", bookHandler.CheckIntegrity, adminMiddleware...)
+
+	bookRoutes(v1.Group("/books"), bookMiddleware, bookHandler, cfg, authCfg)
+	v1.GET("/authors/suggest", bookHandler.SuggestAuthors, timedMiddleware...)
+	v1.POST("/authors", bookHandler.CreateAuthor, authorMiddleware...)
+	v1.POST("/books/:id/cover", bookHandler.UploadCover, coverUploadMiddleware...)
+	v1.POST("/books/import", bookHandler.ImportBooks, importMiddleware...)
+
+	v2 := e.Group("/api/v2")
+	bookRoutes(v2.Group("/books"), bookMiddleware, bookHandler, cfg, authCfg)
+	v2.GET("/authors/suggest", bookHandler.SuggestAuthors, timedMiddleware...)
+	v2.POST("/authors", bookHandler.CreateAuthor, authorMiddleware...)
+	v2.POST("/books/:id/cover", bookHandler.UploadCover, coverUploadMiddleware...)
+	v2.POST("/books/import", bookHandler.ImportBooks, importMiddleware...)
+
+	// GraphQL resolves against the same BookService; mutations check roles
+	// themselves since one endpoint serves reads and writes
+	schema, err := gql.NewSchema(bookService)
+	if err != nil {
+		logger.Fatal("failed to build GraphQL schema", zap.Error(err))
+	}
+	e.GET("/graphql", gql.Handler(schema), timedMiddleware...)
+	e.POST("/graphql", gql.Handler(schema), timedMiddleware...)
+}
+
+func bookRoutes(g *echo.Group, mw []echo.MiddlewareFunc, bookHandler *handlers.BookHandler, cfg config.HTTPConfig, authCfg auth.Config) {
+	// a route's deadline is set before auth so it also bounds the key lookup;
+	// routes touching many books at once get the longer bulk budget
+	with := func(route ...echo.MiddlewareFunc) []echo.MiddlewareFunc {
+		return append(slices.Clip(mw), route...)
+	}
+	regular := with(bfMiddleware.Timeout(cfg.RequestTimeout))
+	bulk := with(bfMiddleware.Timeout(cfg.BulkRequestTimeout))
 
-	bookRoutes.POST("", bookHandler.CreateBook)
-	bookRoutes.GET("", bookHandler.ListBooks)
-	bookRoutes.GET("/:id", bookHandler.GetBook)
-	bookRoutes.PUT("/:id", bookHandler.UpdateBook)
-	bookRoutes.DELETE("/:id", bookHandler.DeleteBook)
+	// keep the @Security annotations on the handlers in sync with these
+	editor := func(timeout []echo.MiddlewareFunc) []echo.MiddlewareFunc {
+		return append(slices.Clip(timeout), bfMiddleware.Authenticate(authCfg), bfMiddleware.RequireRole(auth.RoleEditor))
+	}
+	admin := func(timeout []echo.MiddlewareFunc) []echo.MiddlewareFunc {
+		return append(slices.Clip(timeout), bfMiddleware.Authenticate(authCfg), bfMiddleware.RequireRole(auth.RoleAdmin))
+	}
 
+	g.POST("", bookHandler.CreateBook, editor(regular)...)
+	g.GET("", bookHandler.ListBooks, regular...)
+	g.GET("/count", bookHandler.CountBooks, regular...)
+	g.GET("/export", bookHandler.ExportBooks, bulk...)
+	g.GET("/low-stock", bookHandler.LowStockBooks, regular...)
+	g.GET("/by-isbn/:isbn", bookHandler.GetBookByISBN, regular...)
+	g.GET("/isbn-available", bookHandler.ISBNAvailable, regular...)
+	g.GET("/:id", bookHandler.GetBook, regular...)
+	g.HEAD("/:id", bookHandler.HeadBook, regular...)
+	g.GET("/:id/history", bookHandler.BookHistory, editor(regular)...)
+	g.GET("/:id/cover", bookHandler.GetCover, regular...)
+	g.GET("/:id/confidential", bookHandler.GetBookConfidential, admin(regular)...)
+	g.PUT("/bulk", bookHandler.UpsertBooks, editor(bulk)...)
+	g.POST("/validate", bookHandler.ValidateBooks, bulk...)
+	g.PUT("/:id", bookHandler.UpdateBook, editor(regular)...)
+	g.POST("/:id/restock", bookHandler.RestockBook, editor(regular)...)
+	g.DELETE("", bookHandler.BatchDeleteBooks, editor(bulk)...)
+	g.DELETE("/trash", bookHandler.PurgeDeletedBooks, admin(bulk)...)
+	g.DELETE("/:id", bookHandler.DeleteBook, editor(regular)...)
 }
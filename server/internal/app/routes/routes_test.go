@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"bf-api/internal/app/handlers"
+	bfMiddleware "bf-api/internal/app/middleware"
+	"bf-api/internal/config"
+	"bf-api/internal/domain/services"
+	"bf-api/internal/infrastructure/auth"
+	"bf-api/internal/infrastructure/db/memory"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// newTestRouter mounts every route on a new echo instance, backed by an
+// in-memory repository.
+func newTestRouter(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	store := memory.NewStore()
+	svc := services.NewBookService(memory.NewBookRepository(store), memory.NewAuditRepository(store), memory.NewTxManager(store), nil, nil)
+	cfg := config.HTTPConfig{
+		BodyLimit:          "1M",
+		ImportBodyLimit:    "32M",
+		RequestTimeout:     5 * time.Second,
+		BulkRequestTimeout: 25 * time.Second,
+	}
+
+	e := echo.New()
+	APIRouter(e, cfg, auth.Config{}, bfMiddleware.NewRateLimitStore(0),
+		handlers.NewBookHandler(svc, zap.NewNop()), handlers.NewHealthHandler(nil, zap.NewNop()), nil, svc, zap.NewNop())
+	return e
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	e := newTestRouter(t)
+
+	tests := []struct {
+		method, target string
+		allow          []string
+		errorCode      func(body []byte) (string, error)
+	}{
+		{http.MethodPatch, "/api/v1/books/1", []string{"DELETE", "GET", "HEAD", "OPTIONS", "PUT"}, v1ErrorCode},
+		{http.MethodPatch, "/api/v2/books/1", []string{"DELETE", "GET", "HEAD", "OPTIONS", "PUT"}, v2ErrorCode},
+		{http.MethodPost, "/api/v1/books/count", []string{"GET", "OPTIONS"}, v1ErrorCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("status = %d, want 405: %s", rec.Code, rec.Body)
+			}
+			allow := strings.Split(rec.Header().Get(echo.HeaderAllow), ", ")
+			slices.Sort(allow)
+			if !slices.Equal(allow, tt.allow) {
+				t.Errorf("Allow = %v, want %v", allow, tt.allow)
+			}
+			code, err := tt.errorCode(rec.Body.Bytes())
+			if err != nil {
+				t.Fatalf("invalid error response %q: %v", rec.Body, err)
+			}
+			if code != string(handlers.ErrCodeMethodNotAllowed) {
+				t.Errorf("error code = %q, want %q", code, handlers.ErrCodeMethodNotAllowed)
+			}
+		})
+	}
+}
+
+// v1ErrorCode reads the code of an ErrorResponse.
+func v1ErrorCode(body []byte) (string, error) {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	err := json.Unmarshal(body, &resp)
+	return resp.Error, err
+}
+
+// v2ErrorCode reads the code of the first error in a v2 envelope.
+func v2ErrorCode(body []byte) (string, error) {
+	var resp struct {
+		Errors []struct {
+			Code string `json:"code"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Errors) == 0 {
+		return "", err
+	}
+	return resp.Errors[0].Code, nil
+}
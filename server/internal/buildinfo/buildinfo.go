@@ -0,0 +1,17 @@
+// Package buildinfo holds version metadata injected at link time, e.g.
+//
+//	go build -ldflags "-X bf-api/internal/buildinfo.Version=v1.2.0 \
+//	  -X bf-api/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X bf-api/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/api
+package buildinfo
+
+import "time"
+
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// StartTime is when the process started.
+var StartTime = time.Now()
@@ -0,0 +1,485 @@
+package config
+
+import (
+	"bf-api/internal/domain/services"
+	"bf-api/internal/infrastructure/auth"
+	"bf-api/internal/infrastructure/db/postgres"
+	"bf-api/internal/infrastructure/fieldcrypt"
+	"bf-api/internal/infrastructure/logger"
+	"bf-api/internal/infrastructure/messaging"
+	"bf-api/internal/infrastructure/objectstore"
+	"bf-api/internal/infrastructure/webhook"
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type Config struct {
+	// Environment names the deployment, e.g. development or production. def: development
+	Environment string
+
+	Port    string
+	HTTP    HTTPConfig
+	DB      postgres.DBConfig
+	Webhook webhook.Config
+	NATS    messaging.NATSConfig
+	Outbox  services.OutboxRelayConfig
+	Auth    auth.Config
+	Covers  objectstore.Config
+	Log     logger.Config
+
+	// FieldKeys encrypt confidential book columns; nil when none are
+	// configured, in which case confidential fields are rejected.
+	FieldKeys *fieldcrypt.Keyring
+
+	// DBBackend is where books are stored: postgres, or memory for tests and
+	// demos, which keeps nothing across restarts. def: postgres
+	DBBackend string
+
+	// GRPCPort serves the internal gRPC book API; empty disables it. def: 9090
+	GRPCPort string
+
+	// DebugEndpoints exposes admin-only ops endpoints such as /debug/pool.
+	DebugEndpoints bool
+	// BookMinPages is the fewest pages a book may have; def: 5.
+	BookMinPages int
+	// BookMaxPages is the most pages a book may have; def: 50000. It cannot
+	// exceed what the pages column holds (services.MaxStoredPages).
+	BookMaxPages int
+	// BookAuthorCheck is how the author of a new book is checked against the
+	// known authors: off, lenient or strict; def: off.
+	BookAuthorCheck string
+}
+
+// Storage backends selectable with DB_BACKEND.
+const (
+	DBBackendPostgres = "postgres"
+	DBBackendMemory   = "memory"
+)
+
+// DBBackends are the accepted storage backends.
+var DBBackends = []string{DBBackendPostgres, DBBackendMemory}
+
+type HTTPConfig struct {
+	BodyLimit     string // def: 1M
+	GzipLevel     int    // 1 (fastest) to 9 (best), -1 for the gzip default
+	BrotliLevel   int    // 0 (fastest) to 11 (best); def: 5
+	GzipMinLength int    // responses shorter than this many bytes are sent uncompressed, whatever the encoding; def: 1024
+
+	ImportBodyLimit string // body limit of CSV imports, which may hold many thousands of books; def: 32M
+
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSPort         string // def: 8443
+	RedirectToHTTPS bool   // serve a redirect to TLSPort on the plain HTTP port
+
+	ReadHeaderTimeout time.Duration // def: 5s
+	ReadTimeout       time.Duration // def: 15s
+	WriteTimeout      time.Duration // def: 30s
+	IdleTimeout       time.Duration // def: 60s
+	MaxHeaderBytes    int           // def: 1MB
+
+	ShutdownTimeout time.Duration // time allowed for in-flight requests to drain; def: 10s
+
+	// RequestTimeout bounds a regular request and BulkRequestTimeout one that
+	// handles many books at once, such as a bulk upsert, or uploads a file;
+	// 0 disables either.
+	RequestTimeout     time.Duration // def: 5s
+	BulkRequestTimeout time.Duration // keep below WriteTimeout; def: 25s
+
+	MaxConcurrentRequests int64 // requests beyond this many in flight get 503; 0 disables the limit; def: 256
+	RateLimit             int   // requests per second allowed from one client IP; def: 5
+
+	CORSAllowOrigins     []string      // origins browsers may call from; "*" for any, empty disables CORS
+	CORSAllowCredentials bool          // allow cookies and Authorization cross-origin; not with "*"
+	CORSMaxAge           time.Duration // how long browsers cache a preflight response; def: 10m
+
+	ListDefaultLimit int // page size when a list request sets no limit; def: 20
+	ListMaxLimit     int // larger limits are clamped to this; def: 100
+
+	OpenAPIValidation bool // validate requests against the generated OpenAPI spec; adds per-request overhead
+
+	PrettyJSON bool // indent JSON responses, for reading them during development; compact otherwise
+
+	LogBodies       bool // log request and response bodies at debug level; never enable in production
+	LogBodyMaxBytes int  // bodies are truncated to this many bytes when logged; def: 4096
+
+	// TrustedProxies are the CIDRs of proxies allowed to report the client IP
+	// in X-Forwarded-For, and the host and scheme used in links in
+	// X-Forwarded-Host and X-Forwarded-Proto. When empty the headers are
+	// ignored: the peer address is the client IP and links use the Host.
+	TrustedProxies []*net.IPNet
+}
+
+// Production reports whether the service runs in production.
+func (c Config) Production() bool {
+	return c.Environment == "production"
+}
+
+// TLSEnabled reports whether both a certificate and key are configured.
+func (c HTTPConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// Load reads the configuration, exiting the process if it is invalid.
+func Load() Config {
+	cfg, err := Read()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
+
+// Read reads the configuration from the environment, after loading .env
+// into it if there is one. Reading again picks up changes to .env, which is
+// how settings are reloaded while serving.
+func Read() (Config, error) {
+	if err := loadEnvFile(".env"); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	apiKeys, err := auth.ParseAPIKeys(getEnvAsSlice("AUTH_API_KEYS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid AUTH_API_KEYS: %w", err)
+	}
+
+	fieldKeys, err := fieldcrypt.ParseKeys(getEnvAsSlice("FIELD_ENCRYPTION_KEYS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid FIELD_ENCRYPTION_KEYS: %w", err)
+	}
+
+	trustedProxies, err := parseCIDRs(getEnvAsSlice("HTTP_TRUSTED_PROXIES"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid HTTP_TRUSTED_PROXIES: %w", err)
+	}
+
+	dbBackend := getEnv("DB_BACKEND", DBBackendPostgres)
+	if !slices.Contains(DBBackends, dbBackend) {
+		return Config{}, fmt.Errorf("invalid DB_BACKEND %q; want one of %s", dbBackend, strings.Join(DBBackends, ", "))
+	}
+
+	importMode := getEnv("DB_IMPORT_MODE", postgres.ImportModeCopy)
+	if !slices.Contains(postgres.ImportModes, importMode) {
+		return Config{}, fmt.Errorf("invalid DB_IMPORT_MODE %q; want one of %s", importMode, strings.Join(postgres.ImportModes, ", "))
+	}
+
+	bookMinPages := getEnvAsInt("BOOK_MIN_PAGES", services.DefaultMinPages)
+	bookMaxPages := getEnvAsInt("BOOK_MAX_PAGES", services.DefaultMaxPages)
+	if bookMaxPages > services.MaxStoredPages {
+		return Config{}, fmt.Errorf("invalid BOOK_MAX_PAGES %d; the pages column holds at most %d", bookMaxPages, services.MaxStoredPages)
+	}
+	if bookMaxPages < bookMinPages {
+		return Config{}, fmt.Errorf("invalid BOOK_MAX_PAGES %d; it must be at least BOOK_MIN_PAGES (%d)", bookMaxPages, bookMinPages)
+	}
+
+	logLevel, err := zapcore.ParseLevel(getEnv("LOG_LEVEL", "info"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+
+	rateLimit := getEnvAsInt("HTTP_RATE_LIMIT", 5)
+	if rateLimit <= 0 {
+		return Config{}, fmt.Errorf("invalid HTTP_RATE_LIMIT %d; it must be positive", rateLimit)
+	}
+
+	authorCheck := getEnv("BOOK_AUTHOR_CHECK", services.AuthorCheckOff)
+	if !slices.Contains(services.AuthorChecks, authorCheck) {
+		return Config{}, fmt.Errorf("invalid BOOK_AUTHOR_CHECK %q; want one of %s", authorCheck, strings.Join(services.AuthorChecks, ", "))
+	}
+
+	corsOrigins := getEnvAsSlice("CORS_ALLOW_ORIGINS")
+	corsCredentials := getEnvAsBool("CORS_ALLOW_CREDENTIALS", false)
+	if err := validateCORS(corsOrigins, corsCredentials); err != nil {
+		return Config{}, fmt.Errorf("invalid CORS configuration: %w", err)
+	}
+
+	return Config{
+		Port:     getEnv("PORT", "8080"),
+		GRPCPort: getEnv("GRPC_PORT", "9090"),
+		HTTP: HTTPConfig{
+			BodyLimit:     getEnv("HTTP_BODY_LIMIT", "1M"),
+			GzipLevel:     getEnvAsInt("HTTP_GZIP_LEVEL", -1),
+			BrotliLevel:   getEnvAsInt("HTTP_BROTLI_LEVEL", 5),
+			GzipMinLength: getEnvAsInt("HTTP_GZIP_MIN_LENGTH", 1024),
+
+			ImportBodyLimit: getEnv("HTTP_IMPORT_BODY_LIMIT", "32M"),
+
+			TLSCertFile:     getEnv("HTTP_TLS_CERT_FILE", ""),
+			TLSKeyFile:      getEnv("HTTP_TLS_KEY_FILE", ""),
+			TLSPort:         getEnv("HTTPS_PORT", "8443"),
+			RedirectToHTTPS: getEnvAsBool("HTTP_REDIRECT_TO_HTTPS", false),
+
+			ReadHeaderTimeout: getEnvAsDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+			ReadTimeout:       getEnvAsDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+			WriteTimeout:      getEnvAsDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+			IdleTimeout:       getEnvAsDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+			MaxHeaderBytes:    getEnvAsInt("HTTP_MAX_HEADER_BYTES", 1<<20),
+
+			ShutdownTimeout: getEnvAsDuration("HTTP_SHUTDOWN_TIMEOUT", 10*time.Second),
+
+			RequestTimeout:     getEnvAsDuration("HTTP_REQUEST_TIMEOUT", 5*time.Second),
+			BulkRequestTimeout: getEnvAsDuration("HTTP_BULK_REQUEST_TIMEOUT", 25*time.Second),
+
+			MaxConcurrentRequests: int64(getEnvAsInt("HTTP_MAX_CONCURRENT_REQUESTS", 256)),
+			RateLimit:             rateLimit,
+
+			CORSAllowOrigins:     corsOrigins,
+			CORSAllowCredentials: corsCredentials,
+			CORSMaxAge:           getEnvAsDuration("CORS_MAX_AGE", 10*time.Minute),
+
+			ListDefaultLimit: getEnvAsInt("LIST_DEFAULT_LIMIT", 20),
+			ListMaxLimit:     getEnvAsInt("LIST_MAX_LIMIT", 100),
+
+			OpenAPIValidation: getEnvAsBool("HTTP_OPENAPI_VALIDATION", false),
+
+			PrettyJSON: getEnvAsBool("HTTP_PRETTY_JSON", false),
+
+			LogBodies:       getEnvAsBool("HTTP_LOG_BODIES", false),
+			LogBodyMaxBytes: getEnvAsInt("HTTP_LOG_BODY_MAX_BYTES", 4096),
+
+			TrustedProxies: trustedProxies,
+		},
+		DB: postgres.DBConfig{
+			Host:       getEnv("DB_HOST", "localhost"),
+			Port:       getEnvAsInt("DB_PORT", 5432),
+			User:       getEnv("DB_USER", "postgres"),
+			Password:   getEnv("DB_PASSWORD", "postgres"),
+			DBName:     getEnv("DB_NAME", "bookdb"),
+			SSLMode:    getEnv("DB_SSLMODE", "disable"),
+			SearchPath: getEnv("DB_SEARCH_PATH", ""),
+
+			SSLRootCert: getEnv("DB_SSLROOTCERT", ""),
+			SSLCert:     getEnv("DB_SSLCERT", ""),
+			SSLKey:      getEnv("DB_SSLKEY", ""),
+
+			PasswordFile: getEnv("DB_PASSWORD_FILE", ""),
+
+			SeparateCount: getEnvAsBool("DB_SEPARATE_COUNT", false),
+
+			SlowQueryThreshold: getEnvAsDuration("DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
+			SlowQueryLogArgs:   getEnvAsBool("DB_SLOW_QUERY_LOG_ARGS", false),
+
+			ImportMode:      importMode,
+			ImportBatchSize: getEnvAsInt("DB_IMPORT_BATCH_SIZE", postgres.DefaultImportBatchSize),
+
+			HealthCheckQuery:    getEnv("DB_HEALTH_CHECK_QUERY", postgres.DefaultHealthCheckQuery),
+			HealthCheckInterval: getEnvAsDuration("DB_HEALTH_CHECK_INTERVAL", 15*time.Second),
+		},
+		Webhook: webhook.Config{
+			URLs:       getEnvAsSlice("WEBHOOK_URLS"),
+			Secret:     getEnv("WEBHOOK_SECRET", ""),
+			Workers:    getEnvAsInt("WEBHOOK_WORKERS", 4),
+			QueueSize:  getEnvAsInt("WEBHOOK_QUEUE_SIZE", 100),
+			MaxRetries: getEnvAsInt("WEBHOOK_MAX_RETRIES", 3),
+			Timeout:    getEnvAsDuration("WEBHOOK_TIMEOUT", 5*time.Second),
+		},
+		NATS: messaging.NATSConfig{
+			URL:           getEnv("NATS_URL", ""),
+			SubjectPrefix: getEnv("NATS_SUBJECT_PREFIX", "events"),
+			ConnTimeout:   getEnvAsDuration("NATS_CONN_TIMEOUT", 5*time.Second),
+		},
+		Outbox: services.OutboxRelayConfig{
+			PollInterval: getEnvAsDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:    getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+		},
+		Auth: auth.Config{
+			APIKeys: apiKeys,
+		},
+		Covers: objectstore.Config{
+			Backend:  getEnv("COVER_STORAGE", objectstore.BackendLocal),
+			LocalDir: getEnv("COVER_LOCAL_DIR", "./data/covers"),
+			S3: objectstore.S3Config{
+				Endpoint:  getEnv("COVER_S3_ENDPOINT", ""),
+				Bucket:    getEnv("COVER_S3_BUCKET", ""),
+				Region:    getEnv("COVER_S3_REGION", ""),
+				AccessKey: getEnv("COVER_S3_ACCESS_KEY", ""),
+				SecretKey: getEnv("COVER_S3_SECRET_KEY", ""),
+				UseSSL:    getEnvAsBool("COVER_S3_USE_SSL", true),
+				PublicURL: getEnv("COVER_S3_PUBLIC_URL", ""),
+			},
+		},
+		Log: logger.Config{
+			Output:     getEnv("LOG_OUTPUT", logger.OutputStderr),
+			Format:     getEnv("LOG_FORMAT", logger.FormatJSON),
+			MaxSizeMB:  getEnvAsInt("LOG_MAX_SIZE_MB", 100),
+			MaxBackups: getEnvAsInt("LOG_MAX_BACKUPS", 5),
+			MaxAgeDays: getEnvAsInt("LOG_MAX_AGE_DAYS", 28),
+			Compress:   getEnvAsBool("LOG_COMPRESS", false),
+
+			Level: logLevel,
+		},
+		Environment:    getEnv("APP_ENV", "development"),
+		FieldKeys:      fieldKeys,
+		DebugEndpoints: getEnvAsBool("DEBUG_ENDPOINTS_ENABLED", false),
+		BookMinPages:   bookMinPages,
+
+		BookMaxPages: bookMaxPages,
+
+		BookAuthorCheck: authorCheck,
+
+		DBBackend: dbBackend,
+	}, nil
+}
+
+// ColdChanges returns the settings, as field paths such as DB.Host, that
+// differ between running and next and only take effect on a restart. Every
+// setting is cold except the rate limit (HTTP_RATE_LIMIT), the log level
+// (LOG_LEVEL) and the slow query threshold (DB_SLOW_QUERY_THRESHOLD), which
+// a reload applies while serving.
+func ColdChanges(running, next Config) []string {
+	var changed []string
+	diffFields("", reflect.ValueOf(running.withoutHot()), reflect.ValueOf(next.withoutHot()), &changed)
+	return changed
+}
+
+// withoutHot returns c with the settings a reload applies while serving zeroed.
+func (c Config) withoutHot() Config {
+	c.HTTP.RateLimit = 0
+	c.Log.Level = 0
+	c.DB.SlowQueryThreshold = 0
+	return c
+}
+
+// diffFields appends the paths of the fields that differ between the structs
+// a and b to changed, descending into nested structs whose fields are all
+// exported.
+func diffFields(prefix string, a, b reflect.Value, changed *[]string) {
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		name := prefix + field.Name
+		if field.Type.Kind() == reflect.Struct && allExported(field.Type) {
+			diffFields(name+".", a.Field(i), b.Field(i), changed)
+		} else if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			*changed = append(*changed, name)
+		}
+	}
+}
+
+func allExported(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
+
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsSlice(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// validateCORS rejects credentials combined with a wildcard origin: browsers
+// refuse that pair, and reflecting any origin instead would let every site
+// make authenticated calls on a user's behalf.
+func validateCORS(origins []string, allowCredentials bool) error {
+	if !allowCredentials {
+		return nil
+	}
+	if len(origins) == 0 {
+		return fmt.Errorf("CORS_ALLOW_CREDENTIALS requires CORS_ALLOW_ORIGINS")
+	}
+	for _, origin := range origins {
+		if origin == "*" {
+			return fmt.Errorf("CORS_ALLOW_CREDENTIALS cannot be combined with the wildcard origin; list the allowed origins explicitly")
+		}
+	}
+	return nil
+}
+
+// parseCIDRs parses CIDR blocks; a bare IP is taken as a single host.
+func parseCIDRs(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func loadEnvFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue // Skip empty lines and comments
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue // Skip malformed lines
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		os.Setenv(key, value) // Set in system environment
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,126 @@
+// Package filterexpr parses book filter expressions such as
+//
+//	author="Ursula K. Le Guin" AND (pages>300 OR published>2020)
+//
+// into a tree repositories can evaluate. Only the fields and operators listed
+// here are accepted, and the size of an expression is bounded, so the tree is
+// safe to compile into parameterized SQL.
+package filterexpr
+
+import "time"
+
+// Limits on an expression, so a single request cannot make the database
+// evaluate an arbitrarily large predicate.
+const (
+	MaxLength     = 1000 // characters
+	MaxDepth      = 4    // nested parentheses
+	MaxConditions = 20
+)
+
+// Expr is a parsed expression: a *Condition or a *Group.
+type Expr interface {
+	expr()
+}
+
+// Logic joins the terms of a Group.
+type Logic string
+
+const (
+	And Logic = "AND"
+	Or  Logic = "OR"
+)
+
+// Group matches when all (And) or any (Or) of its terms match. It always has
+// at least two terms.
+type Group struct {
+	Op    Logic
+	Terms []Expr
+}
+
+// Condition compares a field of a book with a value.
+type Condition struct {
+	Field Field
+	Op    Op
+	// Value is a string for KindText fields, an int for KindNumber fields and
+	// a UTC midnight time.Time for KindDate fields.
+	Value any
+}
+
+func (*Group) expr()     {}
+func (*Condition) expr() {}
+
+// Field is a book field an expression may filter on.
+type Field string
+
+const (
+	FieldTitle     Field = "title"
+	FieldAuthor    Field = "author"
+	FieldISBN      Field = "isbn"
+	FieldPages     Field = "pages"
+	FieldStock     Field = "stock"
+	FieldPublished Field = "published"
+)
+
+// Kind is the type of the values a field is compared with.
+type Kind int
+
+const (
+	KindText Kind = iota
+	KindNumber
+	KindDate
+)
+
+// Fields are the fields expressions may use, with their kinds.
+var Fields = map[Field]Kind{
+	FieldTitle:     KindText,
+	FieldAuthor:    KindText,
+	FieldISBN:      KindText,
+	FieldPages:     KindNumber,
+	FieldStock:     KindNumber,
+	FieldPublished: KindDate,
+}
+
+// Op compares a field with a value.
+type Op string
+
+const (
+	OpEq Op = "="
+	OpNe Op = "!="
+	OpGt Op = ">"
+	OpGe Op = ">="
+	OpLt Op = "<"
+	OpLe Op = "<="
+)
+
+// ops are the operators accepted for each kind; text is only compared for
+// equality.
+var ops = map[Kind][]Op{
+	KindText:   {OpEq, OpNe},
+	KindNumber: {OpEq, OpNe, OpGt, OpGe, OpLt, OpLe},
+	KindDate:   {OpEq, OpNe, OpGt, OpGe, OpLt, OpLe},
+}
+
+// Compare reports whether a value comparing as cmp (negative, zero or
+// positive, as from cmp.Compare) against the condition's value satisfies op.
+func (op Op) Compare(cmp int) bool {
+	switch op {
+	case OpEq:
+		return cmp == 0
+	case OpNe:
+		return cmp != 0
+	case OpGt:
+		return cmp > 0
+	case OpGe:
+		return cmp >= 0
+	case OpLt:
+		return cmp < 0
+	case OpLe:
+		return cmp <= 0
+	}
+	return false
+}
+
+// date returns midnight UTC of the given day.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,298 @@
+package filterexpr
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyntaxError reports why an expression was rejected and where.
+type SyntaxError struct {
+	Pos     int    // 1-based character position of Token; one past the end when the expression ended early
+	Token   string // the offending token; empty at the end of the expression
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Token == "" {
+		return "end of filter: " + e.Message
+	}
+	return fmt.Sprintf("%q at position %d: %s", e.Token, e.Pos, e.Message)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string // the value for strings, unquoted and unescaped
+	raw  string // as written, for error messages
+	pos  int
+}
+
+// Parse parses s. AND binds tighter than OR, both are case-insensitive, and
+// parentheses group. Values containing spaces or special characters are
+// double-quoted, with \" and \\ as escapes. A published date is compared
+// either with a day (published>=2020-06-01) or a whole year (published>2020
+// means after 2020).
+func Parse(s string) (Expr, error) {
+	if n := len([]rune(s)); n > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength + 1, Token: string([]rune(s)[MaxLength:min(n, MaxLength+10)]), Message: fmt.Sprintf("filter is longer than %d characters", MaxLength)}
+	}
+
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "expected AND, OR or the end of the filter")
+	}
+	return expr, nil
+}
+
+// operator characters; a run of them forms one operator token
+const opChars = "=!<>"
+
+func lex(s string) ([]token, error) {
+	runes := []rune(s)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, raw: "(", pos: start + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, raw: ")", pos: start + 1})
+			i++
+		case strings.ContainsRune(opChars, r):
+			for i < len(runes) && strings.ContainsRune(opChars, runes[i]) {
+				i++
+			}
+			raw := string(runes[start:i])
+			tokens = append(tokens, token{kind: tokenOp, text: raw, raw: raw, pos: start + 1})
+		case r == '"':
+			var value strings.Builder
+			i++
+			for {
+				if i == len(runes) {
+					return nil, &SyntaxError{Pos: start + 1, Token: string(runes[start:]), Message: "unterminated quoted value"}
+				}
+				if runes[i] == '"' {
+					i++
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value.String(), raw: string(runes[start:i]), pos: start + 1})
+		default:
+			for i < len(runes) && !strings.ContainsRune(" \t\n\r()\""+opChars, runes[i]) {
+				i++
+			}
+			raw := string(runes[start:i])
+			tokens = append(tokens, token{kind: tokenWord, text: raw, raw: raw, pos: start + 1})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
+
+type parser struct {
+	tokens     []token
+	next       int
+	conditions int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return &SyntaxError{Pos: tok.pos, Token: tok.raw, Message: fmt.Sprintf(format, args...)}
+}
+
+// keyword reports whether tok is the unquoted keyword kw, in any case.
+func keyword(tok token, kw Logic) bool {
+	return tok.kind == tokenWord && strings.EqualFold(tok.text, string(kw))
+}
+
+func (p *parser) parseOr(depth int) (Expr, error) {
+	return p.parseLogic(depth, Or, p.parseAnd)
+}
+
+func (p *parser) parseAnd(depth int) (Expr, error) {
+	return p.parseLogic(depth, And, p.parseTerm)
+}
+
+// parseLogic parses operands joined by op into a Group, or returns the
+// single operand as is.
+func (p *parser) parseLogic(depth int, op Logic, operand func(depth int) (Expr, error)) (Expr, error) {
+	first, err := operand(depth)
+	if err != nil {
+		return nil, err
+	}
+	terms := []Expr{first}
+	for keyword(p.peek(), op) {
+		p.advance()
+		term, err := operand(depth)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return &Group{Op: op, Terms: terms}, nil
+}
+
+func (p *parser) parseTerm(depth int) (Expr, error) {
+	tok := p.peek()
+	if tok.kind != tokenLParen {
+		return p.parseCondition()
+	}
+
+	if depth == MaxDepth {
+		return nil, p.errorf(tok, "parentheses are nested more than %d deep", MaxDepth)
+	}
+	p.advance()
+	expr, err := p.parseOr(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	if closing := p.advance(); closing.kind != tokenRParen {
+		return nil, p.errorf(closing, "expected )")
+	}
+	return expr, nil
+}
+
+func (p *parser) parseCondition() (Expr, error) {
+	fieldTok := p.advance()
+	if fieldTok.kind == tokenEOF {
+		return nil, p.errorf(fieldTok, "expected a condition")
+	}
+	if fieldTok.kind != tokenWord || keyword(fieldTok, And) || keyword(fieldTok, Or) {
+		return nil, p.errorf(fieldTok, "expected a field name")
+	}
+	field := Field(strings.ToLower(fieldTok.text))
+	kind, ok := Fields[field]
+	if !ok {
+		return nil, p.errorf(fieldTok, "unknown field; use one of %s", fieldNames())
+	}
+
+	opTok := p.advance()
+	if opTok.kind != tokenOp {
+		return nil, p.errorf(opTok, "expected an operator after %s", field)
+	}
+	op := Op(opTok.text)
+	if !slices.Contains(ops[kind], op) {
+		return nil, p.errorf(opTok, "unsupported operator for %s; use one of %s", field, opNames(kind))
+	}
+
+	valueTok := p.advance()
+	if valueTok.kind != tokenWord && valueTok.kind != tokenString {
+		return nil, p.errorf(valueTok, "expected a value for %s", field)
+	}
+
+	p.conditions++
+	if p.conditions > MaxConditions {
+		return nil, p.errorf(fieldTok, "filter has more than %d conditions", MaxConditions)
+	}
+
+	switch kind {
+	case KindNumber:
+		n, err := strconv.ParseInt(valueTok.text, 10, 32)
+		if err != nil {
+			return nil, p.errorf(valueTok, "%s must be compared with a whole number", field)
+		}
+		return &Condition{Field: field, Op: op, Value: int(n)}, nil
+	case KindDate:
+		return p.dateCondition(field, op, valueTok)
+	default:
+		return &Condition{Field: field, Op: op, Value: valueTok.text}, nil
+	}
+}
+
+var yearPattern = regexp.MustCompile(`^[0-9]{4}$`)
+
+// dateCondition compares field with a day, or with a year by comparing with
+// the days the year starts and ends.
+func (p *parser) dateCondition(field Field, op Op, tok token) (Expr, error) {
+	if !yearPattern.MatchString(tok.text) {
+		day, err := time.Parse(time.DateOnly, tok.text)
+		if err != nil {
+			return nil, p.errorf(tok, "%s must be compared with a year (2020) or a date (2020-06-01)", field)
+		}
+		return &Condition{Field: field, Op: op, Value: day}, nil
+	}
+
+	year, _ := strconv.Atoi(tok.text)
+	start, next := date(year, time.January, 1), date(year+1, time.January, 1)
+	switch op {
+	case OpEq:
+		return &Group{Op: And, Terms: []Expr{
+			&Condition{Field: field, Op: OpGe, Value: start},
+			&Condition{Field: field, Op: OpLt, Value: next},
+		}}, nil
+	case OpNe:
+		return &Group{Op: Or, Terms: []Expr{
+			&Condition{Field: field, Op: OpLt, Value: start},
+			&Condition{Field: field, Op: OpGe, Value: next},
+		}}, nil
+	case OpGt:
+		return &Condition{Field: field, Op: OpGe, Value: next}, nil
+	case OpLe:
+		return &Condition{Field: field, Op: OpLt, Value: next}, nil
+	default: // >= and <
+		return &Condition{Field: field, Op: op, Value: start}, nil
+	}
+}
+
+func fieldNames() string {
+	names := make([]string, 0, len(Fields))
+	for field := range Fields {
+		names = append(names, string(field))
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+func opNames(kind Kind) string {
+	names := make([]string, len(ops[kind]))
+	for i, op := range ops[kind] {
+		names[i] = string(op)
+	}
+	return strings.Join(names, " ")
+}
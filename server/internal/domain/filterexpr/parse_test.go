@@ -0,0 +1,130 @@
+package filterexpr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// render writes expr with every group parenthesized, so trees can be compared
+// as strings.
+func render(expr Expr) string {
+	switch e := expr.(type) {
+	case *Group:
+		terms := make([]string, len(e.Terms))
+		for i, term := range e.Terms {
+			terms[i] = render(term)
+		}
+		return "(" + strings.Join(terms, " "+string(e.Op)+" ") + ")"
+	case *Condition:
+		if t, ok := e.Value.(time.Time); ok {
+			return fmt.Sprintf("%s%s%s", e.Field, e.Op, t.Format(time.DateOnly))
+		}
+		return fmt.Sprintf("%s%s%q", e.Field, e.Op, fmt.Sprint(e.Value))
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{`pages>300`, `pages>"300"`},
+		// AND binds tighter than OR
+		{`pages>1 OR pages>2 AND pages>3`, `(pages>"1" OR (pages>"2" AND pages>"3"))`},
+		{`pages>1 AND pages>2 OR pages>3`, `((pages>"1" AND pages>"2") OR pages>"3")`},
+		// parentheses override it
+		{`(pages>1 OR pages>2) AND pages>3`, `((pages>"1" OR pages>"2") AND pages>"3")`},
+		{`pages>1 and (pages>2 or pages>3)`, `(pages>"1" AND (pages>"2" OR pages>"3"))`},
+		// runs of one operator form a single group
+		{`pages>1 OR pages>2 OR pages>3`, `(pages>"1" OR pages>"2" OR pages>"3")`},
+		{`((pages>1))`, `pages>"1"`},
+		{
+			`author="Ursula K. Le Guin" AND (pages>300 OR published>2020)`,
+			`(author="Ursula K. Le Guin" AND (pages>"300" OR published>=2021-01-01))`,
+		},
+		// a year stands for every day in it
+		{`published=2020`, `(published>=2020-01-01 AND published<2021-01-01)`},
+		{`published!=2020`, `(published<2020-01-01 OR published>=2021-01-01)`},
+		{`published<=2020`, `published<2021-01-01`},
+		{`published<2020-06-01`, `published<2020-06-01`},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.filter)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.filter, err)
+			continue
+		}
+		if got := render(expr); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestParseInjectionStaysAValue(t *testing.T) {
+	// quoted, anything is a value compared for equality and never SQL
+	for _, value := range []string{
+		`x' OR '1'='1`,
+		`x"); DROP TABLE books; --`,
+		`x) OR (1=1`,
+		`' UNION SELECT password FROM users --`,
+	} {
+		quoted := `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+		expr, err := Parse("title=" + quoted)
+		if err != nil {
+			t.Errorf("Parse(title=%s) error = %v", quoted, err)
+			continue
+		}
+		cond, ok := expr.(*Condition)
+		if !ok || cond.Field != FieldTitle || cond.Op != OpEq || cond.Value != value {
+			t.Errorf("Parse(title=%s) = %s, want title compared with %q", quoted, render(expr), value)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	deep := strings.Repeat("(", MaxDepth+1) + "pages>1" + strings.Repeat(")", MaxDepth+1)
+	many := strings.Repeat("pages>1 AND ", MaxConditions) + "pages>1"
+	tests := []struct {
+		filter string
+		token  string
+	}{
+		// unquoted SQL never gets past the lexer and parser
+		{`title=x; DROP TABLE books`, `DROP`},
+		{`title=x OR 1=1`, `1`},
+		{`title=x -- comment`, `--`},
+		{`pages>1 UNION SELECT 1`, `UNION`},
+		{`pages>"1 OR 1=1"`, `"1 OR 1=1"`},
+		// only the allowlisted fields and operators
+		{`id=1`, `id`},
+		{`deleted_at!=x`, `deleted_at`},
+		{`title>x`, `>`},
+		{`pages=>1`, `=>`},
+		{`pages LIKE 1`, `LIKE`},
+		// malformed
+		{`pages>1 AND`, ``},
+		{`(pages>1`, ``},
+		{`pages>1)`, `)`},
+		{`title="open`, `"open`},
+		{`published>20201`, `20201`},
+		{`pages>99999999999`, `99999999999`},
+		// limits
+		{deep, `(`},
+		{many, `pages`},
+		{strings.Repeat("x", MaxLength+1), `x`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.filter)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%.40q) error = %v, want a SyntaxError", tt.filter, err)
+			continue
+		}
+		if syntaxErr.Token != tt.token {
+			t.Errorf("Parse(%.40q) points at %q, want %q: %v", tt.filter, syntaxErr.Token, tt.token, err)
+		}
+	}
+}
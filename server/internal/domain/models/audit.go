@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+	AuditPurge  AuditAction = "purge"
+)
+
+// AuditEntry records one book mutation. Before is null for creates and After
+// is null for deletes.
+type AuditEntry struct {
+	ID        int             `json:"id" example:"1"`
+	Actor     string          `json:"actor" example:"ops-team"`
+	Action    AuditAction     `json:"action" example:"update"`
+	BookID    int             `json:"book_id" example:"42"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"`
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+	TraceID   string          `json:"trace_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type BookHistoryResponse struct {
+	Data []*AuditEntry `json:"data"`
+}
@@ -1,33 +1,53 @@
 package models
 
-import "time"
+import (
+	"bf-api/internal/domain/filterexpr"
+	"time"
+)
 
 type Book struct {
 	ID        int       `json:"id"`
 	Title     string    `json:"title" validate:"required,min=1,max=200"`
 	Author    string    `json:"author" validate:"required,min=1,max=100"`
-	Published string    `json:"published" validate:"required,datetime=2006-01-02"`
+	Published Date      `json:"published" swaggertype:"string" format:"date" example:"2024-01-02"`
 	ISBN      string    `json:"isbn" validate:"required"`
-	Pages     int       `json:"pages" validate:"required,min=5"`
+	Pages     int       `json:"pages" validate:"required,minpages,maxpages"`
+	Stock     int       `json:"stock"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Relevance *float32  `json:"relevance,omitempty"` // set for full-text search results only
+	Deleted   bool      `json:"deleted,omitempty"`   // set for soft-deleted books in incremental sync listings
+	CoverURL  string    `json:"cover_url,omitempty" example:"/api/v1/books/1/cover"`
+	CoverKey  string    `json:"-"` // object storage key of the cover
+
+	// Confidential fields are encrypted at rest and never serialized, which
+	// also keeps them out of audit snapshots and events. Admins read them
+	// through BookConfidentialResponse.
+	AcquisitionCost Secret `json:"-"`
+	SupplierNotes   Secret `json:"-"`
 }
 
 type (
 	BookCreateRequest struct {
 		Title     string `json:"title" validate:"required,min=1,max=200"`
 		Author    string `json:"author" validate:"required,min=1,max=100"`
-		Published string `json:"published" validate:"required,datetime=2006-01-02"`
+		Published Date   `json:"published" swaggertype:"string" format:"date" example:"2024-01-02"`
 		ISBN      string `json:"isbn" validate:"required"`
-		Pages     int    `json:"pages" validate:"required,min=5,gt=0"`
+		Pages     int    `json:"pages" validate:"required,minpages,maxpages"`
+		// stored encrypted; only admins read them back
+		AcquisitionCost string `json:"acquisition_cost,omitempty" validate:"omitempty,numeric,max=32" example:"12.50"`
+		SupplierNotes   string `json:"supplier_notes,omitempty" validate:"omitempty,max=2000"`
 	}
 
 	BookUpdateRequest struct {
 		Title     string `json:"title" validate:"omitempty,min=1,max=200"`
 		Author    string `json:"author" validate:"omitempty,min=1,max=100"`
-		Published string `json:"published" validate:"omitempty,datetime=2006-01-02"`
+		Published Date   `json:"published" swaggertype:"string" format:"date" example:"2024-01-02"`
 		ISBN      string `json:"isbn" validate:"omitempty"`
-		Pages     int    `json:"pages" validate:"omitempty,min=5"`
+		Pages     int    `json:"pages" validate:"omitempty,minpages,maxpages"`
+		// stored encrypted; only admins read them back
+		AcquisitionCost string `json:"acquisition_cost,omitempty" validate:"omitempty,numeric,max=32" example:"12.50"`
+		SupplierNotes   string `json:"supplier_notes,omitempty" validate:"omitempty,max=2000"`
 	}
 	BookGetByIDRequest struct {
 		ID        int    `json:"id" validate:"required"`
@@ -35,7 +55,7 @@ type (
 		Author    string `json:"author" validate:"omitempty,min=1,max=100"`
 		Published string `json:"published" validate:"omitempty,datetime=2006-01-02"`
 		ISBN      string `json:"isbn" validate:"omitempty"`
-		Pages     int    `json:"pages" validate:"omitempty,min=5"`
+		Pages     int    `json:"pages" validate:"omitempty,minpages,maxpages"`
 	}
 	BookFetchAllRequest struct {
 		ID        int    `json:"id" validate:"omitempty"`
@@ -43,20 +63,197 @@ type (
 		Author    string `json:"author" validate:"omitempty,min=1,max=100"`
 		Published string `json:"published" validate:"omitempty,datetime=2006-01-02"`
 		ISBN      string `json:"isbn" validate:"omitempty"`
-		Pages     int    `json:"pages" validate:"omitempty,min=5"`
+		Pages     int    `json:"pages" validate:"omitempty,minpages,maxpages"`
 		PageSize  int    `json:"page_size" validate:"omitempty"`
 	}
 	BookDeleteRequest struct {
 		ID int `json:"id" validate:"required"`
 	}
+	BookBulkUpsertRequest struct {
+		Books []BookCreateRequest `json:"books"`
+	}
+	BookRestockRequest struct {
+		Amount int `json:"amount" validate:"required,gt=0" example:"10"`
+	}
+	BookBatchDeleteRequest struct {
+		IDs []int `json:"ids" validate:"required,min=1,max=100,dive,gt=0"`
+	}
+)
+
+// BookMeta is the subset of a book needed to validate cached copies.
+type BookMeta struct {
+	ID        int
+	UpdatedAt time.Time
+}
+
+type SearchMode string
+
+const (
+	// SearchModeFullText matches whole words in title and author, ranked by relevance.
+	SearchModeFullText SearchMode = "fulltext"
+	// SearchModePrefix matches titles or authors starting with the search text.
+	SearchModePrefix SearchMode = "prefix"
+)
+
+// DeletedFilter selects books by soft-delete state.
+type DeletedFilter int
+
+const (
+	// DeletedFilterActive returns only books that are not deleted.
+	DeletedFilterActive DeletedFilter = iota
+	// DeletedFilterAll returns active and deleted books, flagged as deleted.
+	DeletedFilterAll
+	// DeletedFilterOnly returns only deleted books.
+	DeletedFilterOnly
 )
 
+// BookFilter narrows a book listing; zero values are ignored.
+type BookFilter struct {
+	Search        string
+	SearchMode    SearchMode
+	AuthorExact   string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// UpdatedSince switches the listing to incremental sync: only books
+	// changed strictly after it are returned, oldest change first.
+	UpdatedSince *time.Time
+	// SnapshotAt hides books created after it so a client paging through a
+	// listing is not shown shifted pages as new books arrive.
+	SnapshotAt *time.Time
+	Deleted    DeletedFilter
+	// Expr is a parsed filter expression the books must also match.
+	Expr filterexpr.Expr
+}
+
 type (
 	BookListResponse struct {
-		Data       []*Book `json:"data"`
-		Page       int     `json:"page" example:"10"`
-		PageSize   int     `json:"page_size" example:"10"`
-		TotalPages int     `json:"total_pages" example:"10"`
-		Limit      int     `json:"limit"`
+		Data       []*Book         `json:"data"`
+		Page       int             `json:"page" example:"10"`
+		PageSize   int             `json:"page_size" example:"10"`
+		TotalPages int             `json:"total_pages" example:"10"`
+		TotalItems int             `json:"total_items" example:"200"`
+		Limit      int             `json:"limit"`
+		Links      PaginationLinks `json:"links"`
+		SnapshotAt *time.Time      `json:"snapshot_at,omitempty" example:"2024-01-02T15:04:05.123456Z"`
+		Warning    string          `json:"warning,omitempty" example:"limit 500 exceeds the maximum of 100; 100 items are returned per page"`
+	}
+
+	BookLowStockResponse struct {
+		Data      []*Book `json:"data"`
+		Threshold int     `json:"threshold" example:"10"`
+	}
+
+	BookBulkUpsertResponse struct {
+		Inserted []string `json:"inserted" example:"9780140449136"`
+		Updated  []string `json:"updated" example:"9780261103573"`
+	}
+
+	BookDryRunResponse struct {
+		*Book
+		DryRun bool `json:"dry_run" example:"true"`
+	}
+
+	// BookCreateResponse is the created book along with warnings about it,
+	// such as likely duplicates, that did not prevent its creation.
+	BookCreateResponse struct {
+		*Book
+		Warnings []string `json:"warnings" example:"book 12 has the same title and author and may be a duplicate"`
+	}
+
+	BookPurgeResponse struct {
+		Purged int `json:"purged" example:"12"`
+	}
+
+	// IntegrityReport counts the active books that would be rejected if they
+	// were written today, and how many fail on each field.
+	IntegrityReport struct {
+		Scanned int            `json:"scanned"`
+		Invalid int            `json:"invalid"`
+		Fields  map[string]int `json:"fields"`
+	}
+
+	// IntegrityIssue lists what would need correcting on one stored book.
+	IntegrityIssue struct {
+		ID     int                    `json:"id"`
+		Errors []FieldValidationError `json:"errors"`
+	}
+
+	AuthorSuggestResponse struct {
+		Data []string `json:"data" example:"J. K. Rowling"`
+	}
+
+	// AuthorCreateRequest registers a known author, so books by them pass a
+	// strict author check before any has been stored.
+	AuthorCreateRequest struct {
+		Name string `json:"name" validate:"required,min=1,max=100" example:"J. K. Rowling"`
+	}
+
+	// AuthorResponse is a known author as stored, after normalization.
+	AuthorResponse struct {
+		Name string `json:"name" example:"J. K. Rowling"`
+	}
+
+	// BookImportResponse counts the books an import created and lists the
+	// ISBNs it skipped because they were already in use.
+	BookImportResponse struct {
+		Imported int      `json:"imported" example:"998"`
+		Skipped  []string `json:"skipped" example:"9780306406157,9780140449136"`
+	}
+
+	// BookValidateResponse reports the validation result of every submitted
+	// book; Valid is true when all of them passed.
+	BookValidateResponse struct {
+		Valid   bool                   `json:"valid" example:"false"`
+		Results []BookValidationResult `json:"results"`
+	}
+
+	BookValidationResult struct {
+		Index  int                    `json:"index" example:"0"`
+		Valid  bool                   `json:"valid" example:"false"`
+		Errors []FieldValidationError `json:"errors"`
+	}
+
+	FieldValidationError struct {
+		Field   string `json:"field" example:"isbn"`
+		Message string `json:"message" example:"Duplicate ISBN in request"`
+	}
+
+	// BookConfidentialResponse holds the decrypted confidential fields of a
+	// book. It is only served to admins.
+	BookConfidentialResponse struct {
+		ID              int    `json:"id" example:"12"`
+		AcquisitionCost string `json:"acquisition_cost,omitempty" example:"12.50"`
+		SupplierNotes   string `json:"supplier_notes,omitempty"`
+	}
+
+	BookCountResponse struct {
+		Count int `json:"count" example:"42"`
+	}
+
+	// ISBNAvailabilityResponse tells whether an ISBN is free for a new book
+	// and, when it is not, which active book has it.
+	ISBNAvailabilityResponse struct {
+		Available  bool `json:"available" example:"false"`
+		ExistingID *int `json:"existing_id,omitempty" example:"12"`
+	}
+
+	BookBatchGetResponse struct {
+		Data     []*Book `json:"data"`
+		NotFound []int   `json:"not_found" example:"3"`
+	}
+
+	BookBatchDeleteResponse struct {
+		Deleted  []int `json:"deleted" example:"1,2"`
+		NotFound []int `json:"not_found" example:"3"`
+	}
+
+	PaginationLinks struct {
+		Self  string `json:"self" example:"http://localhost:8080/api/v1/books?limit=20&page=2"`
+		First string `json:"first" example:"http://localhost:8080/api/v1/books?limit=20&page=1"`
+		Last  string `json:"last" example:"http://localhost:8080/api/v1/books?limit=20&page=10"`
+		Next  string `json:"next,omitempty" example:"http://localhost:8080/api/v1/books?limit=20&page=3"`
+		Prev  string `json:"prev,omitempty" example:"http://localhost:8080/api/v1/books?limit=20&page=1"`
 	}
 )
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestDateRoundTripAcrossTimeZones(t *testing.T) {
+	date := Date{Year: 2024, Month: time.January, Day: 1}
+
+	// the far ends of the zone range, where midnight UTC is already or still
+	// another day locally
+	for _, loc := range []*time.Location{
+		time.FixedZone("UTC+14", 14*60*60),
+		time.FixedZone("UTC-12", -12*60*60),
+	} {
+		t.Run(loc.String(), func(t *testing.T) {
+			local := time.Local
+			time.Local = loc
+			t.Cleanup(func() { time.Local = local })
+
+			v, err := date.DateValue()
+			if err != nil {
+				t.Fatalf("DateValue() error = %v", err)
+			}
+			var got Date
+			if err := got.ScanDate(v); err != nil {
+				t.Fatalf("ScanDate() error = %v", err)
+			}
+			if got != date {
+				t.Errorf("round trip = %s, want %s", got, date)
+			}
+
+			// a DATE read through database/sql arrives as midnight UTC
+			if err := got.Scan(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if got != date {
+				t.Errorf("Scan() = %s, want %s", got, date)
+			}
+		})
+	}
+}
+
+func TestScanDateNull(t *testing.T) {
+	got := Date{Year: 2024, Month: time.January, Day: 1}
+	if err := got.ScanDate(pgtype.Date{}); err != nil {
+		t.Fatalf("ScanDate() error = %v", err)
+	}
+	if !got.IsZero() {
+		t.Errorf("ScanDate(NULL) = %s, want the zero Date", got)
+	}
+}
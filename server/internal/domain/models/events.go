@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type BookEventType string
+
+const (
+	BookCreated BookEventType = "book.created"
+	BookUpdated BookEventType = "book.updated"
+	BookDeleted BookEventType = "book.deleted"
+)
+
+type BookEvent struct {
+	EventType BookEventType `json:"event_type"`
+	Book      *Book         `json:"book"`
+	TenantID  string        `json:"tenant_id"`
+	Timestamp time.Time     `json:"timestamp"`
+	TraceID   string        `json:"trace_id,omitempty"`
+}
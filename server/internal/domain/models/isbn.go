@@ -0,0 +1,58 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeISBN returns the canonical form of an ISBN as stored: hyphens and
+// whitespace removed and an ISBN-10 check digit X uppercased, so
+// "0-306-40615-x " and "030640615X" are the same book.
+func NormalizeISBN(isbn string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, isbn)
+}
+
+// ValidISBN reports whether isbn, in the form NormalizeISBN returns, is an
+// ISBN-10 or ISBN-13 with a correct check digit.
+func ValidISBN(isbn string) bool {
+	switch len(isbn) {
+	case 10:
+		// digits weighted 10 down to 1 sum to a multiple of 11, the last
+		// one being X for 10
+		sum := 0
+		for i := 0; i < 10; i++ {
+			var d int
+			switch c := isbn[i]; {
+			case c >= '0' && c <= '9':
+				d = int(c - '0')
+			case c == 'X' && i == 9:
+				d = 10
+			default:
+				return false
+			}
+			sum += d * (10 - i)
+		}
+		return sum%11 == 0
+	case 13:
+		// digits weighted alternately 1 and 3 sum to a multiple of 10
+		sum := 0
+		for i := 0; i < 13; i++ {
+			c := isbn[i]
+			if c < '0' || c > '9' {
+				return false
+			}
+			d := int(c - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		return sum%10 == 0
+	}
+	return false
+}
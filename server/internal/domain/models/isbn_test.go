@@ -0,0 +1,34 @@
+package models
+
+import "testing"
+
+func TestValidISBN(t *testing.T) {
+	tests := []struct {
+		isbn string
+		want bool
+	}{
+		{"0306406152", true},
+		{"030640615X", false},
+		{"080442957X", true},
+		{"0306406153", false},
+		{"9780306406157", true},
+		{"9780306406158", false},
+		{"978030640615X", false},
+		{"03064061", false},
+		{"03064O6152", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ValidISBN(tt.isbn); got != tt.want {
+			t.Errorf("ValidISBN(%q) = %v, want %v", tt.isbn, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	for _, isbn := range []string{"0-8044-2957-X", "080442957x", " 0 8044 2957 X\n", "080442957X"} {
+		if got := NormalizeISBN(isbn); got != "080442957X" {
+			t.Errorf("NormalizeISBN(%q) = %q, want 080442957X", isbn, got)
+		}
+	}
+}
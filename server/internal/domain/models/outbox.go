@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// OutboxEntry is a book event waiting in the outbox to be published.
+type OutboxEntry struct {
+	ID        int64
+	Event     BookEvent
+	CreatedAt time.Time
+	Attempts  int
+}
@@ -0,0 +1,13 @@
+package models
+
+// Secret is a confidential value, such as a book's acquisition cost.
+// Repositories store it encrypted, and String redacts it so it does not leak
+// into logs.
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeText returns free text as stored: NFC-normalized with surrounding
+// whitespace trimmed, so a title typed with a precomposed "é" and one with
+// "e" plus a combining accent are the same string.
+func NormalizeText(s string) string {
+	return strings.TrimSpace(norm.NFC.String(s))
+}
@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"bf-api/internal/domain/models"
+	"context"
+)
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry *models.AuditEntry) error
+	ListByBook(ctx context.Context, bookID int) ([]*models.AuditEntry, error)
+}
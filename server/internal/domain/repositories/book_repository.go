@@ -3,12 +3,32 @@ package repositories
 import (
 	"bf-api/internal/domain/models"
 	"context"
+	"time"
 )
 
 type BookRepository interface {
 	CreateBook(ctx context.Context, book *models.Book) error
+	CreateBookIdempotent(ctx context.Context, key string, ttl time.Duration, book *models.Book) (replayed bool, err error)
 	GetByBookID(ctx context.Context, id int) (*models.Book, error)
-	FetchAllBook(ctx context.Context, page, pageSize int) ([]*models.Book, int, error)
+	GetByBookIDForUpdate(ctx context.Context, id int) (*models.Book, error)
+	GetBookMeta(ctx context.Context, id int) (*models.BookMeta, error)
+	GetByISBN(ctx context.Context, isbn string) (*models.Book, error)
+	GetByIDs(ctx context.Context, ids []int) ([]*models.Book, error)
+	EachBook(ctx context.Context, fn func(*models.Book) error) error
+	ISBNTaken(ctx context.Context, isbn string, excludeID int) (bool, error)
+	FindByTitleAuthor(ctx context.Context, title, author string, excludeID, limit int) ([]*models.Book, error)
+	FetchAllBook(ctx context.Context, page, pageSize int, filter models.BookFilter) ([]*models.Book, int, error)
+	CountBooks(ctx context.Context, filter models.BookFilter) (int, error)
 	UpdateBook(ctx context.Context, book *models.Book) error
-	DeleteBook(ctx context.Context, id int) error
+	UpsertBooks(ctx context.Context, books []*models.Book) (inserted, updated []*models.Book, err error)
+	ImportBooks(ctx context.Context, books []*models.Book, progress func(processed int)) (inserted []*models.Book, skipped []string, err error)
+	DeleteBook(ctx context.Context, id int) (*models.Book, error)
+	DeleteBooks(ctx context.Context, ids []int) ([]*models.Book, error)
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]int, error)
+	RestockBook(ctx context.Context, id, amount int) (*models.Book, error)
+	SetCover(ctx context.Context, id int, key, url string) (*models.Book, error)
+	FetchLowStock(ctx context.Context, threshold, limit int) ([]*models.Book, error)
+	SuggestAuthors(ctx context.Context, prefix string, limit int) ([]string, error)
+	MatchAuthor(ctx context.Context, name string, limit int) (known bool, similar []string, err error)
+	AddAuthor(ctx context.Context, name string) (created bool, err error)
 }
@@ -7,4 +7,10 @@ var (
 	ErrDuplicateISBN    = errors.New("isbn already exists")
 	ErrInvalidData      = errors.New("invalid book data")
 	ErrInvalidReference = errors.New("invalid reference: the referenced record does not exist")
+	// ErrPoolExhausted means no database connection became free before the
+	// request's deadline, as opposed to a query that ran but was too slow.
+	ErrPoolExhausted = errors.New("no database connection available")
+	// ErrObjectNotFound is returned by object stores for a key that holds
+	// no object.
+	ErrObjectNotFound = errors.New("object not found")
 )
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"bf-api/internal/domain/models"
+	"context"
+)
+
+// OutboxRepository stores events for later delivery. Enqueue is meant to run
+// in the transaction of the write the event describes; ClaimPending locks the
+// claimed entries until that transaction ends, so concurrent relays skip them.
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, event models.BookEvent) error
+	ClaimPending(ctx context.Context, limit int) ([]*models.OutboxEntry, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, reason string) error
+}
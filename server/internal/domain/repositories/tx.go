@@ -0,0 +1,19 @@
+package repositories
+
+import "context"
+
+// Repositories are bound to a single transaction.
+type Repositories struct {
+	Books  BookRepository
+	Audit  AuditRepository
+	Outbox OutboxRepository
+}
+
+// TxManager runs fn in a transaction, committing only when fn returns nil.
+// It lets services combine several repository calls, such as a check and the
+// write that depends on it, into one atomic operation.
+// Implementations may run fn again after a transient failure, so fn must not
+// have effects outside the transaction.
+type TxManager interface {
+	WithTx(ctx context.Context, fn func(repos Repositories) error) error
+}
@@ -3,52 +3,402 @@ package services
 import (
 	"bf-api/internal/domain/models"
 	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/tenant"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// coalescedReads counts reads answered by a query another caller already had
+// in flight for the same key, rather than by a query of their own. Only
+// GetByBookID is coalesced, so operation is always "get_book". Books are not
+// cached: there is no cache layer, and therefore no hit, miss or cache
+// latency metrics.
+var coalescedReads = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "book_reads_coalesced_total",
+	Help: "Reads answered by a query already in flight for the same book instead of their own.",
+}, []string{"operation"})
+
+// BookNotifier is told about book changes once they have been persisted.
+// Implementations must not block the caller.
+type BookNotifier interface {
+	Notify(ctx context.Context, eventType models.BookEventType, book *models.Book)
+}
+
+// EventPublisher emits domain events to a message broker. It is only called
+// after the write it describes has been committed.
+type EventPublisher interface {
+	Publish(ctx context.Context, event models.BookEvent) error
+}
+
+// RequestInfo reads what the audit log and events record about a request
+// from its context: the name of the authenticated caller and the ID of the
+// trace the request belongs to. The transport layer stores both.
+type RequestInfo interface {
+	Actor(ctx context.Context) (string, bool)
+	TraceID(ctx context.Context) (string, bool)
+}
+
+type noopNotifier struct{}
+
+func (noopNotifier) Notify(context.Context, models.BookEventType, *models.Book) {}
+
+type noopPublisher struct{}
+
+func (noopPublisher) Publish(context.Context, models.BookEvent) error { return nil }
+
+type noopRequestInfo struct{}
+
+func (noopRequestInfo) Actor(context.Context) (string, bool)   { return "", false }
+func (noopRequestInfo) TraceID(context.Context) (string, bool) { return "", false }
+
+const (
+	IdempotencyKeyTTL = 24 * time.Hour
+	MaxBatchSize      = 100
+
+	// MaxImportSize caps the books written by one ImportBooks call.
+	MaxImportSize = 100000
+
+	// MaxValidateBatchSize caps the books checked by one ValidateBooks call;
+	// nothing is written, so it can exceed MaxBatchSize.
+	MaxValidateBatchSize = 1000
+
+	// DefaultPageSize and MaxPageSize bound listings; MaxPageSize is a hard
+	// ceiling that configured limits cannot exceed.
+	DefaultPageSize = 20
+	MaxPageSize     = 1000
+
+	// DefaultMinPages is the fewest pages a book may have unless configured
+	// otherwise with WithMinPages.
+	DefaultMinPages = 5
+	// DefaultMaxPages is the most pages a book may have unless configured
+	// otherwise with WithMaxPages.
+	DefaultMaxPages = 50000
+	// MaxStoredPages is the largest page count the pages column (a 32-bit
+	// INTEGER) can hold; no configured maximum can exceed it.
+	MaxStoredPages = math.MaxInt32
+
+	// MinPublishedYear is the earliest year accepted for a published date;
+	// anything older is almost certainly a data entry mistake.
+	MinPublishedYear = 1000
+
+	// MaxRestockAmount bounds a single restock so typos such as an extra
+	// zero or two do not silently inflate inventory.
+	MaxRestockAmount = 100000
+	// MaxLowStockResults caps a low-stock listing.
+	MaxLowStockResults = 100
+
+	// DefaultAuthorSuggestions and MaxAuthorSuggestions bound author
+	// autocomplete results.
+	DefaultAuthorSuggestions = 10
+	MaxAuthorSuggestions     = 50
+
+	// MaxDuplicateWarnings caps the likely duplicates reported for a new book.
+	MaxDuplicateWarnings = 5
+	// MaxAuthorMatches caps the known authors suggested for an unknown one.
+	MaxAuthorMatches = 3
+
+	// MinPurgeRetention is the shortest time a deleted book is kept before it
+	// may be purged, leaving room to restore accidental deletes.
+	MinPurgeRetention = 7 * 24 * time.Hour
+
+	// AnonymousActor is recorded in the audit log for unauthenticated writes.
+	AnonymousActor = "anonymous"
 )
 
+// How the author of a new book is checked against the known authors of its
+// tenant, which helps catch misspelt names.
+const (
+	// AuthorCheckOff skips the check.
+	AuthorCheckOff = "off"
+	// AuthorCheckLenient creates books with unknown authors and reports the
+	// close matches as warnings (see AuthorWarnings).
+	AuthorCheckLenient = "lenient"
+	// AuthorCheckStrict rejects books with unknown authors on every create
+	// path, suggesting the close matches (see UnknownAuthorError).
+	AuthorCheckStrict = "strict"
+)
+
+// AuthorChecks are the accepted author checks.
+var AuthorChecks = []string{AuthorCheckOff, AuthorCheckLenient, AuthorCheckStrict}
+
 type BookService struct {
-	repo repositories.BookRepository
+	repo      repositories.BookRepository
+	audit     repositories.AuditRepository
+	tx        repositories.TxManager
+	notifier  BookNotifier
+	publisher EventPublisher
+	clock     Clock
+	minPages  int
+	maxPages  int
+	validator requestValidator
+	outbox    bool
+	covers    CoverStore
+	info      RequestInfo
+
+	// confidential accepts the confidential book fields, which can only be
+	// stored when field encryption keys are configured
+	confidential bool
+
+	authorCheck string
+
+	// reads coalesces concurrent GetByBookID calls for the same ID into one
+	// query, so a burst of requests for one book does not hit the database
+	// once each.
+	reads singleflight.Group
 }
 
-func NewBookService(repo repositories.BookRepository) *BookService {
-	return &BookService{
-		repo: repo,
+type BookServiceOption func(*BookService)
+
+// WithMinPages sets the fewest pages a created or updated book may have.
+// Values below 1 keep DefaultMinPages.
+func WithMinPages(n int) BookServiceOption {
+	return func(s *BookService) {
+		if n > 0 {
+			s.minPages = n
+		}
 	}
 }
 
-func (s *BookService) CreateBook(ctx context.Context, req *models.BookCreateRequest) (*models.Book, error) {
+// WithMaxPages sets the most pages a created or updated book may have.
+// Values below 1 keep DefaultMaxPages and values above MaxStoredPages are
+// capped to it.
+func WithMaxPages(n int) BookServiceOption {
+	return func(s *BookService) {
+		if n > 0 {
+			s.maxPages = min(n, MaxStoredPages)
+		}
+	}
+}
 
-	if err := validateBookCreateRequest(req); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+// WithAuthorCheck sets how the author of a new book is checked against the
+// known authors of its tenant. An empty mode keeps AuthorCheckOff.
+func WithAuthorCheck(mode string) BookServiceOption {
+	return func(s *BookService) {
+		if mode != "" {
+			s.authorCheck = mode
+		}
 	}
+}
 
-	book := &models.Book{
-		Title:     req.Title,
-		Author:    req.Author,
-		Published: req.Published,
-		ISBN:      req.ISBN,
-		Pages:     req.Pages,
+// WithOutbox writes book events to the outbox in the same transaction as the
+// change they describe instead of publishing them after commit. An
+// OutboxRelay then delivers them, so none are lost while the broker is down.
+func WithOutbox() BookServiceOption {
+	return func(s *BookService) {
+		s.outbox = true
 	}
+}
 
-	if book.Pages < 5 {
-		return nil, fmt.Errorf("%w: book must have atleast 5 pages", ErrInvalidInput)
+// WithRequestInfo reads the actor and trace ID recorded with each change
+// from the request context through info. Without it, changes are recorded
+// as made by AnonymousActor outside any trace.
+func WithRequestInfo(info RequestInfo) BookServiceOption {
+	return func(s *BookService) {
+		s.info = info
 	}
+}
 
-	if err := s.repo.CreateBook(ctx, book); err != nil {
-		return nil, fmt.Errorf("repository error: %w", err)
+// WithConfidentialFields accepts the confidential book fields. Enable it only
+// when field encryption keys are configured; without it they are rejected
+// rather than failing on the write.
+func WithConfidentialFields(enabled bool) BookServiceOption {
+	return func(s *BookService) {
+		s.confidential = enabled
+	}
+}
+
+// WithClock replaces the system clock the service reads the current time from.
+func WithClock(clock Clock) BookServiceOption {
+	return func(s *BookService) {
+		s.clock = clock
+	}
+}
+
+// NewBookService returns a BookService. Writes go through tx so each one is
+// recorded in the audit log atomically; audit serves history reads.
+func NewBookService(repo repositories.BookRepository, audit repositories.AuditRepository, tx repositories.TxManager, notifier BookNotifier, publisher EventPublisher, opts ...BookServiceOption) *BookService {
+	if notifier == nil {
+		notifier = noopNotifier{}
+	}
+	if publisher == nil {
+		publisher = noopPublisher{}
+	}
+
+	s := &BookService{
+		repo:      repo,
+		audit:     audit,
+		tx:        tx,
+		notifier:  notifier,
+		publisher: publisher,
+		clock:     RealClock{},
+		info:      noopRequestInfo{},
+		minPages:  DefaultMinPages,
+		maxPages:  DefaultMaxPages,
+
+		authorCheck: AuthorCheckOff,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.clock == nil {
+		s.clock = RealClock{}
+	}
+	if s.info == nil {
+		s.info = noopRequestInfo{}
+	}
+	s.validator = newRequestValidator(s.minPages, s.maxPages)
+
+	return s
+}
+
+// Now returns the current time by the service's clock, for callers pinning a
+// time they pass back to the service, such as a listing snapshot.
+func (s *BookService) Now() time.Time {
+	return s.clock.Now()
+}
+
+func (s *BookService) CreateBook(ctx context.Context, req *models.BookCreateRequest) (*models.Book, error) {
+	book, err := s.newBookFromRequest(req, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAuthorKnown(ctx, book.Author); err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		if err := repos.Books.CreateBook(ctx, book); err != nil {
+			return writeError(err)
+		}
+		if err := s.record(ctx, repos.Audit, models.AuditCreate, book.ID, nil, book); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, repos.Outbox, models.BookCreated, book)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.emit(ctx, models.BookCreated, book)
+
+	return book, nil
+}
+
+// PreviewCreateBook runs every check CreateBook does, including ISBN
+// uniqueness, and returns the book as it would be stored without writing it.
+func (s *BookService) PreviewCreateBook(ctx context.Context, req *models.BookCreateRequest) (*models.Book, error) {
+	book, err := s.newBookFromRequest(req, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAuthorKnown(ctx, book.Author); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkISBNAvailable(ctx, book.ISBN, 0); err != nil {
+		return nil, err
 	}
 
 	return book, nil
 }
 
+// CreateBookIdempotent creates a book at most once per idempotency key. A
+// repeated key within IdempotencyKeyTTL returns the original book with
+// replayed set, without inserting or emitting events again.
+func (s *BookService) CreateBookIdempotent(ctx context.Context, key string, req *models.BookCreateRequest) (*models.Book, bool, error) {
+	if len(key) > 255 {
+		return nil, false, fmt.Errorf("%w: idempotency key too long", ErrInvalidInput)
+	}
+
+	book, err := s.newBookFromRequest(req, s.clock.Now())
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.checkAuthorKnown(ctx, book.Author); err != nil {
+		return nil, false, err
+	}
+
+	var replayed bool
+	err = s.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		var err error
+		if replayed, err = repos.Books.CreateBookIdempotent(ctx, key, IdempotencyKeyTTL, book); err != nil {
+			return writeError(err)
+		}
+		if replayed {
+			return nil
+		}
+		if err := s.record(ctx, repos.Audit, models.AuditCreate, book.ID, nil, book); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, repos.Outbox, models.BookCreated, book)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !replayed {
+		s.emit(ctx, models.BookCreated, book)
+	}
+
+	return book, replayed, nil
+}
+
 func (s *BookService) GetByBookID(ctx context.Context, id int) (*models.Book, error) {
 	if id <= 0 {
 		return nil, fmt.Errorf("%w: invalid book ID", repositories.ErrInvalidData)
 	}
 
-	book, err := s.repo.GetByBookID(ctx, id)
+	// the shared query must outlive the caller that started it, or one
+	// cancelled request would fail every request waiting on it
+	shared := context.WithoutCancel(ctx)
+	key := tenant.FromContext(ctx) + ":" + strconv.Itoa(id)
+	var queried bool
+	ch := s.reads.DoChan(key, func() (any, error) {
+		queried = true
+		return s.repo.GetByBookID(shared, id)
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if !queried {
+		coalescedReads.WithLabelValues("get_book").Inc()
+	}
+	if res.Err != nil {
+		if errors.Is(res.Err, repositories.ErrBookNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("repository error: %w", res.Err)
+	}
+
+	// every caller gets its own copy of the shared result
+	book := *res.Val.(*models.Book)
+	return &book, nil
+}
+
+// GetByISBN looks up an active book by ISBN in any of the formats writes
+// accept.
+func (s *BookService) GetByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	isbn = models.NormalizeISBN(isbn)
+	if isbn == "" {
+		return nil, fmt.Errorf("%w: invalid ISBN", ErrInvalidInput)
+	}
+
+	book, err := s.repo.GetByISBN(ctx, isbn)
 	if err != nil {
 		if errors.Is(err, repositories.ErrBookNotFound) {
 			return nil, ErrNotFound
@@ -59,16 +409,77 @@ func (s *BookService) GetByBookID(ctx context.Context, id int) (*models.Book, er
 	return book, nil
 }
 
-func (s *BookService) FetchAllBook(ctx context.Context, page, pageSize int) ([]*models.Book, int, error) {
+// GetByIDs fetches several books in one query. Books come back in the order
+// their IDs were given, duplicates dropped, and the IDs of missing or deleted
+// books are listed in NotFound.
+func (s *BookService) GetByIDs(ctx context.Context, ids []int) (*models.BookBatchGetResponse, error) {
+	ids, err := uniqueIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	books, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	byID := make(map[int]*models.Book, len(books))
+	for _, book := range books {
+		byID[book.ID] = book
+	}
+
+	result := &models.BookBatchGetResponse{
+		Data:     make([]*models.Book, 0, len(books)),
+		NotFound: []int{},
+	}
+	for _, id := range ids {
+		if book, ok := byID[id]; ok {
+			result.Data = append(result.Data, book)
+		} else {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *BookService) GetBookMeta(ctx context.Context, id int) (*models.BookMeta, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid book ID", ErrInvalidInput)
+	}
+
+	meta, err := s.repo.GetBookMeta(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	return meta, nil
+}
+
+func (s *BookService) FetchAllBook(ctx context.Context, page, pageSize int, filter models.BookFilter) ([]*models.Book, int, error) {
 
 	if page < 1 {
 		page = 1
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	filter = normalizeBookFilter(filter)
+	if err := validateBookFilter(filter); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if filter.UpdatedSince != nil && filter.Deleted == models.DeletedFilterActive {
+		// deletions must reach sync consumers too
+		filter.Deleted = models.DeletedFilterAll
 	}
 
-	books, total, err := s.repo.FetchAllBook(ctx, page, pageSize)
+	books, total, err := s.repo.FetchAllBook(ctx, page, pageSize, filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("repository error: %w", err)
 	}
@@ -77,77 +488,983 @@ func (s *BookService) FetchAllBook(ctx context.Context, page, pageSize int) ([]*
 
 }
 
+// ExportBooks calls fn for every active book in ID order, for streaming a
+// full export without holding the catalog in memory.
+func (s *BookService) ExportBooks(ctx context.Context, fn func(*models.Book) error) error {
+	if err := s.repo.EachBook(ctx, fn); err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+	return nil
+}
+
+// CheckIntegrity runs the validation applied to writes over every active
+// book, reading them one at a time so tables of any size can be checked. A
+// stored value that differs from its normalized form is reported too, since
+// rewriting the book would change it. fn, if not nil, is called with each
+// book that fails, in ID order; scanning stops at the first error it returns.
+func (s *BookService) CheckIntegrity(ctx context.Context, fn func(models.IntegrityIssue) error) (*models.IntegrityReport, error) {
+	now := s.clock.Now()
+	report := &models.IntegrityReport{Fields: map[string]int{}}
+	err := s.repo.EachBook(ctx, func(book *models.Book) error {
+		report.Scanned++
+
+		req := models.BookCreateRequest{
+			Title:     book.Title,
+			Author:    book.Author,
+			Published: book.Published,
+			ISBN:      book.ISBN,
+			Pages:     book.Pages,
+		}
+		var errs ValidationErrors
+		if err := s.validateBookCreateRequest(&req, now); err != nil && !errors.As(err, &errs) {
+			return err
+		}
+		for _, f := range []struct{ field, stored, normalized string }{
+			{"title", book.Title, req.Title},
+			{"author", book.Author, req.Author},
+			{"isbn", book.ISBN, req.ISBN},
+		} {
+			if f.stored != f.normalized {
+				errs.add(f.field, fmt.Sprintf("Not normalized; would be stored as %q", f.normalized))
+			}
+		}
+		if len(errs) == 0 {
+			return nil
+		}
+
+		report.Invalid++
+		issue := models.IntegrityIssue{ID: book.ID, Errors: make([]models.FieldValidationError, len(errs))}
+		for i, fe := range errs {
+			report.Fields[fe.Field]++
+			issue.Errors[i] = models.FieldValidationError{Field: fe.Field, Message: fe.Message}
+		}
+		if fn == nil {
+			return nil
+		}
+		return fn(issue)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	return report, nil
+}
+
+func (s *BookService) CountBooks(ctx context.Context, filter models.BookFilter) (int, error) {
+	filter = normalizeBookFilter(filter)
+	if err := validateBookFilter(filter); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	if filter.UpdatedSince != nil && filter.Deleted == models.DeletedFilterActive {
+		// deletions must reach sync consumers too
+		filter.Deleted = models.DeletedFilterAll
+	}
+
+	total, err := s.repo.CountBooks(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("repository error: %w", err)
+	}
+
+	return total, nil
+}
+
 func (s *BookService) UpdateBook(ctx context.Context, id int, req *models.BookUpdateRequest) (*models.Book, error) {
+	before, book, err := s.prepareUpdate(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		if err := repos.Books.UpdateBook(ctx, book); err != nil {
+			return writeError(err)
+		}
+		if err := s.record(ctx, repos.Audit, models.AuditUpdate, book.ID, before, book); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, repos.Outbox, models.BookUpdated, book)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.emit(ctx, models.BookUpdated, book)
+
+	return book, nil
+}
+
+// PreviewUpdateBook runs every check UpdateBook does, including ISBN
+// uniqueness, and returns the book as it would be stored without writing it.
+func (s *BookService) PreviewUpdateBook(ctx context.Context, id int, req *models.BookUpdateRequest) (*models.Book, error) {
+	_, book, err := s.prepareUpdate(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkISBNAvailable(ctx, book.ISBN, book.ID); err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// prepareUpdate validates req and merges it into a copy of the stored book,
+// returning both.
+func (s *BookService) prepareUpdate(ctx context.Context, id int, req *models.BookUpdateRequest) (*models.Book, *models.Book, error) {
 	if id <= 0 {
-		return nil, fmt.Errorf("%w: invalid book ID", ErrInvalidInput)
+		return nil, nil, fmt.Errorf("%w: invalid book ID", ErrInvalidInput)
 	}
-	if err := validateBookUpdateRequest(req); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	if err := s.validateBookUpdateRequest(req, s.clock.Now()); err != nil {
+		return nil, nil, err
 	}
 
-	book, err := s.repo.GetByBookID(ctx, id)
+	stored, err := s.repo.GetByBookID(ctx, id)
 	if err != nil {
 		if errors.Is(err, repositories.ErrBookNotFound) {
-			return nil, ErrNotFound
+			return nil, nil, ErrNotFound
 		}
-		return nil, fmt.Errorf("repository error: %w", err)
+		return nil, nil, fmt.Errorf("repository error: %w", err)
 	}
 
+	merged := *stored
+	book := &merged
+
 	if req.Title != "" {
 		book.Title = req.Title
 	}
 	if req.Author != "" {
 		book.Author = req.Author
 	}
+	if !req.Published.IsZero() {
+		book.Published = req.Published
+	}
 	if req.ISBN != "" {
 		book.ISBN = req.ISBN
 	}
-	if req.Pages <= 0 {
+	if req.Pages > 0 {
 		book.Pages = req.Pages
 	}
+	if req.AcquisitionCost != "" {
+		book.AcquisitionCost = models.Secret(req.AcquisitionCost)
+	}
+	if req.SupplierNotes != "" {
+		book.SupplierNotes = models.Secret(req.SupplierNotes)
+	}
+
+	return stored, book, nil
+}
+
+// ISBNAvailability reports whether isbn, in any of the formats writes
+// accept, is free for a new book and, if not, which active book has it.
+func (s *BookService) ISBNAvailability(ctx context.Context, isbn string) (*models.ISBNAvailabilityResponse, error) {
+	isbn = models.NormalizeISBN(isbn)
+	if isbn == "" {
+		return nil, fmt.Errorf("%w: invalid ISBN", ErrInvalidInput)
+	}
 
-	if err := s.repo.UpdateBook(ctx, book); err != nil {
+	book, err := s.repo.GetByISBN(ctx, isbn)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookNotFound) {
+			return &models.ISBNAvailabilityResponse{Available: true}, nil
+		}
 		return nil, fmt.Errorf("repository error: %w", err)
 	}
 
+	return &models.ISBNAvailabilityResponse{Available: false, ExistingID: &book.ID}, nil
+}
+
+// checkISBNAvailable fails with ErrConflict when an active book other than
+// excludeID already has isbn.
+func (s *BookService) checkISBNAvailable(ctx context.Context, isbn string, excludeID int) error {
+	taken, err := s.repo.ISBNTaken(ctx, isbn, excludeID)
+	if err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
+	if taken {
+		return fmt.Errorf("%w: %v", ErrConflict, repositories.ErrDuplicateISBN)
+	}
+	return nil
+}
+
+// DuplicateTitleWarnings returns a warning for each other active book with
+// the title and author of book, ignoring case. Unlike ISBNs, titles are not
+// unique, so these only flag a likely mistake and never block a write.
+func (s *BookService) DuplicateTitleWarnings(ctx context.Context, book *models.Book) ([]string, error) {
+	dupes, err := s.repo.FindByTitleAuthor(ctx, book.Title, book.Author, book.ID, MaxDuplicateWarnings)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	warnings := make([]string, len(dupes))
+	for i, dupe := range dupes {
+		warnings[i] = fmt.Sprintf("book %d has the same title and author and may be a duplicate", dupe.ID)
+	}
+	return warnings, nil
+}
+
+// AuthorWarnings returns a warning, with the close matches, when author is
+// not a known author of the tenant and the author check is lenient. Like
+// DuplicateTitleWarnings, it never blocks a write; call it before the book
+// is created, as the memory store knows the authors of its stored books.
+func (s *BookService) AuthorWarnings(ctx context.Context, author string) ([]string, error) {
+	if s.authorCheck != AuthorCheckLenient {
+		return nil, nil
+	}
+
+	author = models.NormalizeText(author)
+	known, similar, err := s.repo.MatchAuthor(ctx, author, MaxAuthorMatches)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if known {
+		return nil, nil
+	}
+	return []string{fmt.Sprintf("author %q is not a known author%s", author, didYouMean(similar))}, nil
+}
+
+// checkAuthorKnown rejects an author that is not a known author of the
+// tenant when the author check is strict.
+func (s *BookService) checkAuthorKnown(ctx context.Context, author string) error {
+	return s.checkAuthorsKnown(ctx, []string{author}, func(int) string { return "author" })
+}
+
+// checkAuthorsKnown rejects, when the author check is strict, every author
+// that is not a known author of the tenant, reporting authors[i] as field(i).
+// Each distinct author is looked up once. The failures are an
+// *UnknownAuthorError.
+func (s *BookService) checkAuthorsKnown(ctx context.Context, authors []string, field func(i int) string) error {
+	if s.authorCheck != AuthorCheckStrict {
+		return nil
+	}
+
+	var errs ValidationErrors
+	matched := make(map[string]string, len(authors))
+	for i, author := range authors {
+		msg, ok := matched[author]
+		if !ok {
+			known, similar, err := s.repo.MatchAuthor(ctx, author, MaxAuthorMatches)
+			if err != nil {
+				return fmt.Errorf("repository error: %w", err)
+			}
+			if !known {
+				msg = "Not a known author" + didYouMean(similar)
+			}
+			matched[author] = msg
+		}
+		if msg != "" {
+			errs.add(field(i), msg)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &UnknownAuthorError{Fields: errs}
+}
+
+// didYouMean suggests the similar authors, if there are any.
+func didYouMean(similar []string) string {
+	if len(similar) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(similar))
+	for i, name := range similar {
+		quoted[i] = strconv.Quote(name)
+	}
+	return "; did you mean " + strings.Join(quoted, ", ") + "?"
+}
+
+// UpsertBooks creates or updates books keyed on ISBN in one transaction.
+// Every item is validated up front and nothing is written if any item fails.
+func (s *BookService) UpsertBooks(ctx context.Context, reqs []models.BookCreateRequest) (*models.BookBulkUpsertResponse, error) {
+	if len(reqs) == 0 || len(reqs) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: between 1 and %d books are required", ErrInvalidInput, MaxBatchSize)
+	}
+
+	books, err := s.newBooksFromRequests(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	var inserted, updated []*models.Book
+	err = s.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		// snapshot the rows about to be overwritten for the audit log
+		before := make(map[string]*models.Book)
+		for _, book := range books {
+			existing, err := repos.Books.GetByISBN(ctx, book.ISBN)
+			switch {
+			case err == nil:
+				before[book.ISBN] = existing
+			case !errors.Is(err, repositories.ErrBookNotFound):
+				return fmt.Errorf("repository error: %w", err)
+			}
+		}
+
+		var err error
+		if inserted, updated, err = repos.Books.UpsertBooks(ctx, books); err != nil {
+			return writeError(err)
+		}
+
+		for _, book := range inserted {
+			if err := s.record(ctx, repos.Audit, models.AuditCreate, book.ID, nil, book); err != nil {
+				return err
+			}
+			if err := s.enqueue(ctx, repos.Outbox, models.BookCreated, book); err != nil {
+				return err
+			}
+		}
+		for _, book := range updated {
+			if err := s.record(ctx, repos.Audit, models.AuditUpdate, book.ID, before[book.ISBN], book); err != nil {
+				return err
+			}
+			if err := s.enqueue(ctx, repos.Outbox, models.BookUpdated, book); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.BookBulkUpsertResponse{
+		Inserted: make([]string, 0, len(inserted)),
+		Updated:  make([]string, 0, len(updated)),
+	}
+	for _, book := range inserted {
+		result.Inserted = append(result.Inserted, book.ISBN)
+		s.emit(ctx, models.BookCreated, book)
+	}
+	for _, book := range updated {
+		result.Updated = append(result.Updated, book.ISBN)
+		s.emit(ctx, models.BookUpdated, book)
+	}
+
+	return result, nil
+}
+
+// ValidateBooks runs the create validation over every request without
+// touching the database, and flags ISBNs repeated within reqs. Each result
+// lists the failures of the request at its index, so a client can fix an
+// import file before sending it. ISBNs already in the store are not checked.
+func (s *BookService) ValidateBooks(reqs []models.BookCreateRequest) (*models.BookValidateResponse, error) {
+	if len(reqs) == 0 || len(reqs) > MaxValidateBatchSize {
+		return nil, fmt.Errorf("%w: between 1 and %d books are required", ErrInvalidInput, MaxValidateBatchSize)
+	}
+
+	now := s.clock.Now()
+	resp := &models.BookValidateResponse{
+		Valid:   true,
+		Results: make([]models.BookValidationResult, len(reqs)),
+	}
+	firstByISBN := make(map[string]int, len(reqs))
+	for i := range reqs {
+		var errs ValidationErrors
+		if err := s.validateBookCreateRequest(&reqs[i], now); err != nil && !errors.As(err, &errs) {
+			return nil, err
+		}
+		if isbn := reqs[i].ISBN; isbn != "" && !errs.has("isbn") {
+			if first, ok := firstByISBN[isbn]; ok {
+				errs.add("isbn", fmt.Sprintf("Duplicate ISBN in request; first used by books[%d]", first))
+			} else {
+				firstByISBN[isbn] = i
+			}
+		}
+
+		result := models.BookValidationResult{
+			Index:  i,
+			Valid:  len(errs) == 0,
+			Errors: make([]models.FieldValidationError, len(errs)),
+		}
+		for j, fe := range errs {
+			result.Errors[j] = models.FieldValidationError{Field: fe.Field, Message: fe.Message}
+		}
+		resp.Results[i] = result
+		resp.Valid = resp.Valid && result.Valid
+	}
+
+	return resp, nil
+}
+
+// ImportBooks creates many books at once, e.g. from a CSV file. Every book
+// is validated first and the import is rejected as a whole if any fails.
+// The repository writes them in chunks within one transaction; books whose
+// ISBN is already taken are skipped or fail the import depending on the
+// configured import mode. Progress is logged after every chunk.
+func (s *BookService) ImportBooks(ctx context.Context, reqs []models.BookCreateRequest) (*models.BookImportResponse, error) {
+	if len(reqs) == 0 || len(reqs) > MaxImportSize {
+		return nil, fmt.Errorf("%w: between 1 and %d books are required", ErrInvalidInput, MaxImportSize)
+	}
+
+	books, err := s.newBooksFromRequests(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := func(processed int) {
+		zap.L().Info("book import progress",
+			zap.String("tenant", tenant.FromContext(ctx)),
+			zap.Int("processed", processed),
+			zap.Int("total", len(books)),
+		)
+	}
+
+	var inserted []*models.Book
+	var skipped []string
+	err = s.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		var err error
+		if inserted, skipped, err = repos.Books.ImportBooks(ctx, books, progress); err != nil {
+			return writeError(err)
+		}
+
+		for _, book := range inserted {
+			if err := s.record(ctx, repos.Audit, models.AuditCreate, book.ID, nil, book); err != nil {
+				return err
+			}
+			if err := s.enqueue(ctx, repos.Outbox, models.BookCreated, book); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, book := range inserted {
+		s.emit(ctx, models.BookCreated, book)
+	}
+
+	if skipped == nil {
+		skipped = []string{}
+	}
+	return &models.BookImportResponse{Imported: len(inserted), Skipped: skipped}, nil
+}
+
+// RestockBook adds amount units to the stock of a book.
+func (s *BookService) RestockBook(ctx context.Context, id, amount int) (*models.Book, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid book ID", ErrInvalidInput)
+	}
+	if amount <= 0 || amount > MaxRestockAmount {
+		return nil, fmt.Errorf("%w: amount must be between 1 and %d", ErrInvalidInput, MaxRestockAmount)
+	}
+
+	var book *models.Book
+	err := s.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		before, err := repos.Books.GetByBookID(ctx, id)
+		if err != nil {
+			return writeError(err)
+		}
+		if book, err = repos.Books.RestockBook(ctx, id, amount); err != nil {
+			return writeError(err)
+		}
+		if err := s.record(ctx, repos.Audit, models.AuditUpdate, book.ID, before, book); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, repos.Outbox, models.BookUpdated, book)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.emit(ctx, models.BookUpdated, book)
+
 	return book, nil
 }
 
-func (s *BookService) DeleteBook(ctx context.Context, id int) error {
+// LowStockBooks lists books with fewer than threshold units in stock.
+func (s *BookService) LowStockBooks(ctx context.Context, threshold, limit int) ([]*models.Book, error) {
+	if threshold < 0 {
+		return nil, fmt.Errorf("%w: threshold must not be negative", ErrInvalidInput)
+	}
+	if limit < 1 || limit > MaxLowStockResults {
+		limit = MaxLowStockResults
+	}
+
+	books, err := s.repo.FetchLowStock(ctx, threshold, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	return books, nil
+}
+
+// SuggestAuthors returns up to limit distinct author names starting with
+// prefix, for type-ahead search. The limit defaults to
+// DefaultAuthorSuggestions and is capped at MaxAuthorSuggestions.
+func (s *BookService) SuggestAuthors(ctx context.Context, prefix string, limit int) ([]string, error) {
+	prefix = models.NormalizeText(prefix)
+	if prefix == "" {
+		return nil, fmt.Errorf("%w: prefix is required", ErrInvalidInput)
+	}
+	if limit < 1 {
+		limit = DefaultAuthorSuggestions
+	}
+	limit = min(limit, MaxAuthorSuggestions)
+
+	authors, err := s.repo.SuggestAuthors(ctx, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+
+	return authors, nil
+}
+
+// AddAuthor records the name in req, normalized like book authors, as a known
+// author of the tenant and returns it. created is false when the author was
+// already known. The author of every stored book is known without this.
+func (s *BookService) AddAuthor(ctx context.Context, req *models.AuthorCreateRequest) (name string, created bool, err error) {
+	req.Name = models.NormalizeText(req.Name)
+	if err := s.validator.validateStruct(req).err(); err != nil {
+		return "", false, err
+	}
+
+	created, err = s.repo.AddAuthor(ctx, req.Name)
+	if err != nil {
+		return "", false, fmt.Errorf("repository error: %w", err)
+	}
+
+	return req.Name, created, nil
+}
+
+// DeleteBook soft-deletes a book. A non-zero unmodifiedSince makes the delete
+// conditional: it fails with ErrPrecondition when the book was updated after
+// that time. The comparison is at second precision, like HTTP dates.
+func (s *BookService) DeleteBook(ctx context.Context, id int, unmodifiedSince time.Time) error {
 	if id <= 0 {
 		return fmt.Errorf("%w: invalid book ID", ErrInvalidInput)
 	}
 
-	// biz rule: check if book can be deleted
-	_, err := s.repo.GetByBookID(ctx, id)
+	var book *models.Book
+	err := s.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		// the existence check is the delete itself; only a precondition needs
+		// a prior read, and the row stays locked until the delete so a
+		// concurrent update cannot slip past it
+		if !unmodifiedSince.IsZero() {
+			current, err := repos.Books.GetByBookIDForUpdate(ctx, id)
+			if err != nil {
+				return writeError(err)
+			}
+			if current.UpdatedAt.Truncate(time.Second).After(unmodifiedSince) {
+				return fmt.Errorf("%w: book was modified at %s", ErrPrecondition, current.UpdatedAt.UTC().Format(time.RFC3339))
+			}
+		}
+
+		var err error
+		if book, err = repos.Books.DeleteBook(ctx, id); err != nil {
+			return writeError(err)
+		}
+		if err := s.record(ctx, repos.Audit, models.AuditDelete, id, book, nil); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, repos.Outbox, models.BookDeleted, book)
+	})
 	if err != nil {
-		if errors.Is(err, repositories.ErrBookNotFound) {
-			return ErrNotFound
+		return err
+	}
+
+	s.emit(ctx, models.BookDeleted, book)
+
+	return nil
+}
+
+// PurgeDeleted permanently removes books that were soft-deleted more than
+// olderThan ago and returns how many were removed. Each purge is recorded in
+// the audit log in the same transaction.
+func (s *BookService) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int, error) {
+	if olderThan < MinPurgeRetention {
+		return 0, fmt.Errorf("%w: older_than must be at least %s", ErrInvalidInput, MinPurgeRetention)
+	}
+
+	var purged int
+	err := s.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		ids, err := repos.Books.PurgeDeleted(ctx, s.clock.Now().Add(-olderThan))
+		if err != nil {
+			return fmt.Errorf("repository error: %w", err)
 		}
-		return fmt.Errorf("repository error: %w", err)
+		for _, id := range ids {
+			if err := s.record(ctx, repos.Audit, models.AuditPurge, id, nil, nil); err != nil {
+				return err
+			}
+		}
+		purged = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	if err := s.repo.DeleteBook(ctx, id); err != nil {
+	return purged, nil
+}
+
+// GetConfidential returns the decrypted confidential fields of an active
+// book. Callers must only serve them to admins.
+func (s *BookService) GetConfidential(ctx context.Context, id int) (*models.BookConfidentialResponse, error) {
+	book, err := s.GetByBookID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BookConfidentialResponse{
+		ID:              book.ID,
+		AcquisitionCost: string(book.AcquisitionCost),
+		SupplierNotes:   string(book.SupplierNotes),
+	}, nil
+}
+
+// BookHistory returns the audit trail of a book, oldest change first. The
+// trail outlives soft deletes; a book with no trail is reported as not found.
+func (s *BookService) BookHistory(ctx context.Context, id int) ([]*models.AuditEntry, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid book ID", ErrInvalidInput)
+	}
+
+	entries, err := s.audit.ListByBook(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository error: %w", err)
+	}
+	if len(entries) == 0 {
+		if _, err := s.GetByBookID(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// record appends an audit entry for a change to book id within the
+// transaction audit belongs to. The actor is the authenticated caller.
+func (s *BookService) record(ctx context.Context, audit repositories.AuditRepository, action models.AuditAction, id int, before, after *models.Book) error {
+	entry := &models.AuditEntry{
+		Actor:     AnonymousActor,
+		Action:    action,
+		BookID:    id,
+		CreatedAt: s.clock.Now().UTC(),
+	}
+	if actor, ok := s.info.Actor(ctx); ok {
+		entry.Actor = actor
+	}
+	if traceID, ok := s.info.TraceID(ctx); ok {
+		entry.TraceID = traceID
+	}
+
+	var err error
+	if before != nil {
+		if entry.Before, err = json.Marshal(before); err != nil {
+			return fmt.Errorf("failed to encode audit snapshot: %w", err)
+		}
+	}
+	if after != nil {
+		if entry.After, err = json.Marshal(after); err != nil {
+			return fmt.Errorf("failed to encode audit snapshot: %w", err)
+		}
+	}
+
+	if err := audit.Record(ctx, entry); err != nil {
 		return fmt.Errorf("repository error: %w", err)
 	}
+	return nil
+}
+
+// enqueue writes the event for book to the outbox when WithOutbox is set.
+// It must run in the transaction of the write the event describes.
+func (s *BookService) enqueue(ctx context.Context, outbox repositories.OutboxRepository, eventType models.BookEventType, book *models.Book) error {
+	if !s.outbox {
+		return nil
+	}
+
+	event := models.BookEvent{
+		EventType: eventType,
+		Book:      book,
+		TenantID:  tenant.FromContext(ctx),
+		Timestamp: s.clock.Now().UTC(),
+	}
+	// the relay publishes without the request context, so keep its trace
+	event.TraceID, _ = s.info.TraceID(ctx)
 
+	if err := outbox.Enqueue(ctx, event); err != nil {
+		return fmt.Errorf("repository error: %w", err)
+	}
 	return nil
 }
 
+// emit fans a committed change out to webhooks and the event broker. Failures
+// are logged rather than returned since the write has already succeeded.
+func (s *BookService) emit(ctx context.Context, eventType models.BookEventType, book *models.Book) {
+	s.notifier.Notify(ctx, eventType, book)
+	if s.outbox {
+		// already enqueued with the write
+		return
+	}
+
+	event := models.BookEvent{
+		EventType: eventType,
+		Book:      book,
+		TenantID:  tenant.FromContext(ctx),
+		Timestamp: s.clock.Now().UTC(),
+	}
+	if err := s.publisher.Publish(ctx, event); err != nil {
+		zap.L().Error("failed to publish book event",
+			zap.Error(err),
+			zap.String("event_type", string(eventType)),
+			zap.Int("book_id", book.ID),
+		)
+	}
+}
+
+// DeleteBooks soft-deletes up to MaxBatchSize books at once, reporting which
+// IDs were deleted and which did not exist.
+func (s *BookService) DeleteBooks(ctx context.Context, ids []int) (*models.BookBatchDeleteResponse, error) {
+	ids, err := uniqueIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	var books []*models.Book
+	err = s.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		var err error
+		if books, err = repos.Books.DeleteBooks(ctx, ids); err != nil {
+			return fmt.Errorf("repository error: %w", err)
+		}
+		for _, book := range books {
+			if err := s.record(ctx, repos.Audit, models.AuditDelete, book.ID, book, nil); err != nil {
+				return err
+			}
+			if err := s.enqueue(ctx, repos.Outbox, models.BookDeleted, book); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make(map[int]bool, len(books))
+	for _, book := range books {
+		deleted[book.ID] = true
+		s.emit(ctx, models.BookDeleted, book)
+	}
+
+	result := &models.BookBatchDeleteResponse{
+		Deleted:  []int{},
+		NotFound: []int{},
+	}
+	for _, id := range ids {
+		if deleted[id] {
+			result.Deleted = append(result.Deleted, id)
+		} else {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+
+	return result, nil
+}
+
 // helper functions
-func validateBookCreateRequest(req *models.BookCreateRequest) error {
-	if req.Title == "" {
-		return errors.New("title is required")
+
+// writeError translates repository failures on insert/update into service
+// errors, surfacing constraint violations as conflicts.
+func writeError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrBookNotFound):
+		return ErrNotFound
+	case errors.Is(err, repositories.ErrDuplicateISBN):
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	case errors.Is(err, repositories.ErrInvalidData):
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
 	}
-	if len(req.Title) > 200 {
-		return errors.New("title too long")
+	return fmt.Errorf("repository error: %w", err)
+}
+
+func (s *BookService) newBookFromRequest(req *models.BookCreateRequest, now time.Time) (*models.Book, error) {
+	if err := s.validateBookCreateRequest(req, now); err != nil {
+		return nil, err
+	}
+
+	book := &models.Book{
+		Title:     req.Title,
+		Author:    req.Author,
+		Published: req.Published,
+		ISBN:      req.ISBN,
+		Pages:     req.Pages,
+
+		AcquisitionCost: models.Secret(req.AcquisitionCost),
+		SupplierNotes:   models.Secret(req.SupplierNotes),
+	}
+
+	return book, nil
+}
+
+// newBooksFromRequests validates and builds the books of a batch write.
+// Failures are reported per item as books[i].field, and an ISBN repeated
+// within the batch is rejected, since the second row would hit the conflict
+// target of the first. Once every item is valid, a strict author check runs
+// over the whole batch. Nothing is returned unless every item passes.
+func (s *BookService) newBooksFromRequests(ctx context.Context, reqs []models.BookCreateRequest) ([]*models.Book, error) {
+	now := s.clock.Now()
+	var errs ValidationErrors
+	books := make([]*models.Book, 0, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+	for i := range reqs {
+		book, err := s.newBookFromRequest(&reqs[i], now)
+		var itemErrs ValidationErrors
+		if errors.As(err, &itemErrs) {
+			for _, fe := range itemErrs {
+				errs.add(fmt.Sprintf("books[%d].%s", i, fe.Field), fe.Message)
+			}
+			continue
+		}
+		if seen[book.ISBN] {
+			errs.add(fmt.Sprintf("books[%d].isbn", i), "Duplicate ISBN in request")
+			continue
+		}
+		seen[book.ISBN] = true
+		books = append(books, book)
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	authors := make([]string, len(books))
+	for i, book := range books {
+		authors[i] = book.Author
+	}
+	field := func(i int) string { return fmt.Sprintf("books[%d].author", i) }
+	if err := s.checkAuthorsKnown(ctx, authors, field); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// validateBookCreateRequest normalizes the text fields and ISBN, applies the
+// request's struct tags and then the business rules, reporting every failing
+// field. Length limits therefore apply to the normalized values.
+func (s *BookService) validateBookCreateRequest(req *models.BookCreateRequest, now time.Time) error {
+	req.Title = models.NormalizeText(req.Title)
+	req.Author = models.NormalizeText(req.Author)
+	req.SupplierNotes = models.NormalizeText(req.SupplierNotes)
+	req.ISBN = models.NormalizeISBN(req.ISBN)
+	var errs ValidationErrors
+	validateStoredPages(&errs, req.Pages)
+	errs.merge(s.validator.validateStruct(req))
+	validateISBN(&errs, req.ISBN)
+	s.validateConfidential(&errs, req.AcquisitionCost, req.SupplierNotes)
+	if req.Published.IsZero() {
+		errs.add("published", "This field is required")
+	} else if err := validatePublished(req.Published, now); err != nil {
+		errs.add("published", err.Error())
+	}
+	return errs.err()
+}
+
+// uniqueIDs validates a batch of book IDs and drops duplicates, keeping the
+// order they were given in.
+func uniqueIDs(ids []int) ([]int, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("at least one ID is required")
+	}
+	if len(ids) > MaxBatchSize {
+		return nil, fmt.Errorf("at most %d IDs are allowed", MaxBatchSize)
+	}
+
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, fmt.Errorf("invalid book ID %d", id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique, nil
+}
+
+// normalizeBookFilter normalizes text filters the way stored text is, so
+// lookups match however the caller's input was composed.
+func normalizeBookFilter(filter models.BookFilter) models.BookFilter {
+	filter.Search = models.NormalizeText(filter.Search)
+	filter.AuthorExact = models.NormalizeText(filter.AuthorExact)
+	return filter
+}
+
+func validateBookFilter(filter models.BookFilter) error {
+	if len(filter.Search) > 200 {
+		return errors.New("search too long")
+	}
+	switch filter.SearchMode {
+	case "", models.SearchModeFullText, models.SearchModePrefix:
+	default:
+		return fmt.Errorf("unknown search mode %q", filter.SearchMode)
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return errors.New("created_after must be before created_before")
+	}
+	if filter.UpdatedAfter != nil && filter.UpdatedBefore != nil && !filter.UpdatedAfter.Before(*filter.UpdatedBefore) {
+		return errors.New("updated_after must be before updated_before")
 	}
 	return nil
 }
 
-func validateBookUpdateRequest(req *models.BookUpdateRequest) error {
-	if req.Title != "" && len(req.Title) > 200 {
-		return errors.New("title too long")
+func (s *BookService) validateBookUpdateRequest(req *models.BookUpdateRequest, now time.Time) error {
+	req.Title = models.NormalizeText(req.Title)
+	req.Author = models.NormalizeText(req.Author)
+	req.SupplierNotes = models.NormalizeText(req.SupplierNotes)
+	req.ISBN = models.NormalizeISBN(req.ISBN)
+	var errs ValidationErrors
+	validateStoredPages(&errs, req.Pages)
+	errs.merge(s.validator.validateStruct(req))
+	validateISBN(&errs, req.ISBN)
+	s.validateConfidential(&errs, req.AcquisitionCost, req.SupplierNotes)
+	if !req.Published.IsZero() {
+		if err := validatePublished(req.Published, now); err != nil {
+			errs.add("published", err.Error())
+		}
+	}
+	return errs.err()
+}
+
+// validateStoredPages rejects page counts the pages column cannot hold. It
+// runs before the struct tags so an overflowing value is reported as such
+// rather than as merely above the configured maximum.
+func validateStoredPages(errs *ValidationErrors, pages int) {
+	if pages > MaxStoredPages {
+		errs.add("pages", fmt.Sprintf("Must be at most %d, the largest page count that can be stored", MaxStoredPages))
+	}
+}
+
+// validateISBN rejects an ISBN whose check digit does not match. An empty
+// one is left to the struct tags, which decide whether it is required.
+func validateISBN(errs *ValidationErrors, isbn string) {
+	if isbn != "" && !models.ValidISBN(isbn) {
+		errs.add("isbn", "Must be a valid ISBN-10 or ISBN-13")
+	}
+}
+
+// validateConfidential rejects confidential fields unless
+// WithConfidentialFields enabled them.
+func (s *BookService) validateConfidential(errs *ValidationErrors, acquisitionCost, supplierNotes string) {
+	if s.confidential {
+		return
+	}
+	if acquisitionCost != "" {
+		errs.add("acquisition_cost", "Not accepted; field encryption is not configured")
+	}
+	if supplierNotes != "" {
+		errs.add("supplier_notes", "Not accepted; field encryption is not configured")
+	}
+}
+
+// validatePublished rejects published dates after today or before
+// MinPublishedYear. Today is taken in UTC, the zone timestamps are stored in.
+func validatePublished(published models.Date, now time.Time) error {
+	if published.Year < MinPublishedYear {
+		return fmt.Errorf("Must not be before year %d", MinPublishedYear)
+	}
+	if published.After(models.DateOf(now.UTC())) {
+		return errors.New("Must not be in the future")
 	}
 	return nil
 }
@@ -0,0 +1,454 @@
+package services_test
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/services"
+	"bf-api/internal/infrastructure/db/memory"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestService returns a BookService backed by a fresh memory store.
+func newTestService(opts ...services.BookServiceOption) (*services.BookService, *memory.Store) {
+	store := memory.NewStore()
+	svc := services.NewBookService(
+		memory.NewBookRepository(store),
+		memory.NewAuditRepository(store),
+		memory.NewTxManager(store),
+		nil, nil, opts...,
+	)
+	return svc, store
+}
+
+func createRequest(isbn string) *models.BookCreateRequest {
+	return &models.BookCreateRequest{
+		Title:     "Title",
+		Author:    "Author",
+		Published: models.Date{Year: 2000, Month: time.January, Day: 1},
+		ISBN:      isbn,
+		Pages:     100,
+	}
+}
+
+func TestDeleteBookConcurrently(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newTestService()
+	book, err := svc.CreateBook(ctx, createRequest("9780306406157"))
+	if err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+
+	const callers = 20
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- svc.DeleteBook(ctx, book.ID, time.Time{})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var deleted int
+	for err := range errs {
+		switch {
+		case err == nil:
+			deleted++
+		case !errors.Is(err, services.ErrNotFound):
+			t.Errorf("DeleteBook() error = %v, want nil or ErrNotFound", err)
+		}
+	}
+	if deleted != 1 {
+		t.Errorf("%d deletes succeeded, want 1", deleted)
+	}
+
+	entries, err := memory.NewAuditRepository(store).ListByBook(ctx, book.ID)
+	if err != nil {
+		t.Fatalf("ListByBook() error = %v", err)
+	}
+	var audited int
+	for _, entry := range entries {
+		if entry.Action == models.AuditDelete {
+			audited++
+		}
+	}
+	if audited != 1 {
+		t.Errorf("%d delete audit entries, want 1", audited)
+	}
+}
+
+// fieldError returns the message err gives for field, if err is a
+// validation failure of field.
+func fieldError(err error, field string) (string, bool) {
+	var errs services.ValidationErrors
+	if !errors.As(err, &errs) {
+		return "", false
+	}
+	for _, fe := range errs {
+		if fe.Field == field {
+			return fe.Message, true
+		}
+	}
+	return "", false
+}
+
+func TestCreateBookPublishedBounds(t *testing.T) {
+	// late in the UTC day, when some zones have already moved on to tomorrow
+	now := time.Date(2024, time.June, 15, 23, 30, 0, 0, time.UTC)
+	today := models.DateOf(now)
+	tests := []struct {
+		name      string
+		published models.Date
+		ok        bool
+	}{
+		{"today", today, true},
+		{"yesterday", models.DateOf(now.AddDate(0, 0, -1)), true},
+		{"tomorrow", models.DateOf(now.AddDate(0, 0, 1)), false},
+		{"far future", models.Date{Year: 3000, Month: time.January, Day: 1}, false},
+		{"first accepted year", models.Date{Year: services.MinPublishedYear, Month: time.January, Day: 1}, true},
+		{"before first accepted year", models.Date{Year: services.MinPublishedYear - 1, Month: time.December, Day: 31}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(services.WithClock(services.NewFakeClock(now)))
+			req := createRequest("9780306406157")
+			req.Published = tt.published
+
+			_, err := svc.CreateBook(context.Background(), req)
+			if tt.ok && err != nil {
+				t.Errorf("CreateBook(published %s) error = %v", tt.published, err)
+			}
+			if _, ok := fieldError(err, "published"); !tt.ok && !ok {
+				t.Errorf("CreateBook(published %s) error = %v, want a published validation error", tt.published, err)
+			}
+		})
+	}
+}
+
+func TestCreateBookEquivalentISBNs(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService()
+	book, err := svc.CreateBook(ctx, createRequest("978-0-306-40615-7"))
+	if err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+	if book.ISBN != "9780306406157" {
+		t.Errorf("stored ISBN = %q, want 9780306406157", book.ISBN)
+	}
+
+	for _, isbn := range []string{"9780306406157", " 978 0306 40615 7\t", "978-0306406157"} {
+		if _, err := svc.CreateBook(ctx, createRequest(isbn)); !errors.Is(err, services.ErrConflict) {
+			t.Errorf("CreateBook(%q) error = %v, want ErrConflict", isbn, err)
+		}
+		got, err := svc.GetByISBN(ctx, isbn)
+		if err != nil || got.ID != book.ID {
+			t.Errorf("GetByISBN(%q) = %v, %v, want book %d", isbn, got, err, book.ID)
+		}
+	}
+
+	// an ISBN-10 check digit X is stored uppercased
+	book, err = svc.CreateBook(ctx, createRequest("0-8044-2957-x"))
+	if err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+	if book.ISBN != "080442957X" {
+		t.Errorf("stored ISBN = %q, want 080442957X", book.ISBN)
+	}
+}
+
+func TestCreateBookMinPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []services.BookServiceOption
+		pages    int
+		errorMsg string
+	}{
+		{"default minimum", nil, services.DefaultMinPages, ""},
+		{"below default minimum", nil, services.DefaultMinPages - 1, "Must be at least 5"},
+		{"raised minimum", []services.BookServiceOption{services.WithMinPages(50)}, 49, "Must be at least 50"},
+		{"at raised minimum", []services.BookServiceOption{services.WithMinPages(50)}, 50, ""},
+		{"lowered minimum", []services.BookServiceOption{services.WithMinPages(1)}, 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(tt.opts...)
+			req := createRequest("9780306406157")
+			req.Pages = tt.pages
+
+			_, err := svc.CreateBook(context.Background(), req)
+			msg, _ := fieldError(err, "pages")
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("CreateBook(%d pages) error = %v", tt.pages, err)
+			}
+			if msg != tt.errorMsg {
+				t.Errorf("CreateBook(%d pages) pages error = %q, want %q", tt.pages, msg, tt.errorMsg)
+			}
+		})
+	}
+}
+
+func TestCreateBookNormalizesText(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService()
+
+	composed := createRequest("9780306406157")
+	composed.Title, composed.Author = "Caf\u00e9 Society", "Ren\u00e9e"
+	decomposed := createRequest("9780140449136")
+	decomposed.Title, decomposed.Author = "  Cafe\u0301 Society\n", "Rene\u0301e "
+
+	first, err := svc.CreateBook(ctx, composed)
+	if err != nil {
+		t.Fatalf("CreateBook(composed) error = %v", err)
+	}
+	second, err := svc.CreateBook(ctx, decomposed)
+	if err != nil {
+		t.Fatalf("CreateBook(decomposed) error = %v", err)
+	}
+	if second.Title != first.Title || second.Author != first.Author {
+		t.Errorf("stored %q by %q, want %q by %q", second.Title, second.Author, first.Title, first.Author)
+	}
+
+	// the same book entered both ways counts as a duplicate title
+	warnings, err := svc.DuplicateTitleWarnings(ctx, second)
+	if err != nil {
+		t.Fatalf("DuplicateTitleWarnings() error = %v", err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("DuplicateTitleWarnings() = %v, want one warning", warnings)
+	}
+}
+
+func TestCreateBookLengthOfNormalizedTitle(t *testing.T) {
+	svc, _ := newTestService()
+	// 200 characters once composed, though 400 code points as sent
+	req := createRequest("9780306406157")
+	req.Title = strings.Repeat("e\u0301", 200)
+
+	book, err := svc.CreateBook(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+	if want := strings.Repeat("\u00e9", 200); book.Title != want {
+		t.Errorf("Title = %q, want 200 composed characters", book.Title)
+	}
+
+	req = createRequest("9780140449136")
+	req.Title = strings.Repeat("e\u0301", 201)
+	if _, err := svc.CreateBook(context.Background(), req); err == nil {
+		t.Error("CreateBook() of a 201 character title succeeded")
+	} else if _, ok := fieldError(err, "title"); !ok {
+		t.Errorf("CreateBook() error = %v, want a title validation error", err)
+	}
+}
+
+// blockingRepository counts GetByBookID calls and holds each one until
+// release is closed.
+type blockingRepository struct {
+	repositories.BookRepository
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *blockingRepository) GetByBookID(ctx context.Context, id int) (*models.Book, error) {
+	r.calls.Add(1)
+	<-r.release
+	return r.BookRepository.GetByBookID(ctx, id)
+}
+
+func TestGetByBookIDCoalescesConcurrentReads(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	repo := &blockingRepository{BookRepository: memory.NewBookRepository(store), release: make(chan struct{})}
+	book := &models.Book{
+		Title:     "Title",
+		Author:    "Author",
+		Published: models.Date{Year: 2000, Month: time.January, Day: 1},
+		ISBN:      "9780306406157",
+		Pages:     100,
+	}
+	if err := repo.CreateBook(ctx, book); err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+	svc := services.NewBookService(repo, memory.NewAuditRepository(store), memory.NewTxManager(store), nil, nil)
+
+	const readers = 100
+	var started, done sync.WaitGroup
+	started.Add(readers)
+	done.Add(readers)
+	errs := make(chan error, readers)
+	for range readers {
+		go func() {
+			defer done.Done()
+			started.Done()
+			got, err := svc.GetByBookID(ctx, book.ID)
+			if err == nil && got.ID != book.ID {
+				err = errors.New("got book " + strconv.Itoa(got.ID))
+			}
+			errs <- err
+		}()
+	}
+	// give every reader time to join the query in flight before it returns
+	started.Wait()
+	time.Sleep(50 * time.Millisecond)
+	close(repo.release)
+	done.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetByBookID() error = %v", err)
+		}
+	}
+	if calls := repo.calls.Load(); calls != 1 {
+		t.Errorf("repository called %d times, want 1", calls)
+	}
+}
+
+func TestCreateBookMaxPages(t *testing.T) {
+	huge := []services.BookServiceOption{services.WithMaxPages(services.MaxStoredPages + 1)}
+	tests := []struct {
+		name     string
+		opts     []services.BookServiceOption
+		pages    int
+		errorMsg string
+	}{
+		{"default maximum", nil, services.DefaultMaxPages, ""},
+		{"above default maximum", nil, services.DefaultMaxPages + 1, "Must be at most 50000"},
+		{"largest storable", huge, services.MaxStoredPages, ""},
+		{"beyond the column range", huge, services.MaxStoredPages + 1, "Must be at most 2147483647, the largest page count that can be stored"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(tt.opts...)
+			req := createRequest("9780306406157")
+			req.Pages = tt.pages
+
+			_, err := svc.CreateBook(context.Background(), req)
+			msg, _ := fieldError(err, "pages")
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("CreateBook(%d pages) error = %v", tt.pages, err)
+			}
+			if msg != tt.errorMsg {
+				t.Errorf("CreateBook(%d pages) pages error = %q, want %q", tt.pages, msg, tt.errorMsg)
+			}
+		})
+	}
+}
+
+func TestUpdateBookMaxPages(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService()
+	book, err := svc.CreateBook(ctx, createRequest("9780306406157"))
+	if err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+
+	_, err = svc.UpdateBook(ctx, book.ID, &models.BookUpdateRequest{Pages: services.DefaultMaxPages + 1})
+	if msg, _ := fieldError(err, "pages"); msg != "Must be at most 50000" {
+		t.Errorf("UpdateBook() pages error = %q (%v), want %q", msg, err, "Must be at most 50000")
+	}
+}
+
+func TestBatchWritesValidateAlike(t *testing.T) {
+	reqs := func() []models.BookCreateRequest {
+		bad := *createRequest("9780140449136")
+		bad.Pages = 0
+		return []models.BookCreateRequest{
+			*createRequest("9780306406157"),
+			*createRequest("978-0-306-40615-7"),
+			bad,
+		}
+	}
+	writes := map[string]func(*services.BookService) error{
+		"upsert": func(svc *services.BookService) error {
+			_, err := svc.UpsertBooks(context.Background(), reqs())
+			return err
+		},
+		"import": func(svc *services.BookService) error {
+			_, err := svc.ImportBooks(context.Background(), reqs())
+			return err
+		},
+	}
+	for name, write := range writes {
+		t.Run(name, func(t *testing.T) {
+			svc, _ := newTestService()
+			err := write(svc)
+			if _, ok := fieldError(err, "books[1].isbn"); !ok {
+				t.Errorf("error = %v, want the repeated ISBN of books[1] rejected", err)
+			}
+			if _, ok := fieldError(err, "books[2].pages"); !ok {
+				t.Errorf("error = %v, want the pages of books[2] rejected", err)
+			}
+		})
+	}
+}
+
+func TestStrictAuthorCheckOnEveryCreatePath(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newTestService(services.WithAuthorCheck(services.AuthorCheckStrict))
+
+	// a book stored by a lenient service makes its author known
+	lenient := services.NewBookService(memory.NewBookRepository(store), memory.NewAuditRepository(store), memory.NewTxManager(store),
+		nil, nil, services.WithAuthorCheck(services.AuthorCheckLenient))
+	stored := createRequest("9780441013593")
+	stored.Author = "Ursula K. Le Guin"
+	if _, err := lenient.CreateBook(ctx, stored); err != nil {
+		t.Fatalf("lenient CreateBook() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		isbn  string
+		field string
+		write func(req *models.BookCreateRequest) error
+	}{
+		{"CreateBook", "9780306406157", "author", func(req *models.BookCreateRequest) error {
+			_, err := svc.CreateBook(ctx, req)
+			return err
+		}},
+		{"CreateBookIdempotent", "9780140449136", "author", func(req *models.BookCreateRequest) error {
+			_, _, err := svc.CreateBookIdempotent(ctx, "key", req)
+			return err
+		}},
+		{"UpsertBooks", "9780262033848", "books[0].author", func(req *models.BookCreateRequest) error {
+			_, err := svc.UpsertBooks(ctx, []models.BookCreateRequest{*req})
+			return err
+		}},
+		{"ImportBooks", "9780131103627", "books[0].author", func(req *models.BookCreateRequest) error {
+			_, err := svc.ImportBooks(ctx, []models.BookCreateRequest{*req})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		req := createRequest(tt.isbn)
+		req.Author = "Ursula K. Le Gwin"
+		err := tt.write(req)
+		var unknown *services.UnknownAuthorError
+		if !errors.As(err, &unknown) || !errors.Is(err, services.ErrInvalidInput) {
+			t.Fatalf("%s() error = %v, want an UnknownAuthorError", tt.name, err)
+		}
+		if msg, ok := fieldError(err, tt.field); !ok || !strings.Contains(msg, `"Ursula K. Le Guin"`) {
+			t.Errorf("%s() %s error = %q, want the known author suggested", tt.name, tt.field, msg)
+		}
+	}
+
+	if _, created, err := svc.AddAuthor(ctx, &models.AuthorCreateRequest{Name: " Octavia E. Butler "}); err != nil || !created {
+		t.Fatalf("AddAuthor() = %v, %v; want created", created, err)
+	}
+	for _, tt := range tests {
+		req := createRequest(tt.isbn)
+		req.Author = "Octavia E. Butler"
+		if err := tt.write(req); err != nil {
+			t.Errorf("%s() with an added author error = %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/tenant"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxCoverBytes is the largest cover image accepted for upload.
+	MaxCoverBytes = 5 << 20
+	// MinCoverSide and MaxCoverSide bound the width and height of a cover in
+	// pixels. The upper bound also keeps decoding memory in check.
+	MinCoverSide = 100
+	MaxCoverSide = 6000
+
+	coverJPEGQuality = 90
+	// coverAPIPath is where covers are served when the store has no public URL.
+	coverAPIPath = "/api/v1/books/%d/cover"
+)
+
+// Cover is a book cover ready to be served: either the image itself or, when
+// the store serves covers directly, the URL to send the client to.
+type Cover struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+	RedirectURL string
+}
+
+// CoverStore keeps cover images by key. Get returns an error matching
+// repositories.ErrObjectNotFound for a key that holds no image.
+type CoverStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns where clients can download key directly, or "" when the
+	// image is only reachable through the API.
+	URL(key string) string
+}
+
+// WithCoverStore keeps uploaded book covers in store. Without one, cover
+// uploads fail.
+func WithCoverStore(store CoverStore) BookServiceOption {
+	return func(s *BookService) {
+		s.covers = store
+	}
+}
+
+// SetCover replaces the cover of a book with the JPEG or PNG image in data.
+// The image is re-encoded, which drops EXIF and any other embedded metadata
+// such as GPS positions; orientation tags are not applied. The previous
+// cover is deleted once the new one is recorded.
+func (s *BookService) SetCover(ctx context.Context, id int, data []byte) (*models.Book, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid book ID", ErrInvalidInput)
+	}
+	if s.covers == nil {
+		return nil, errors.New("cover storage is not configured")
+	}
+
+	encoded, contentType, ext, err := reencodeCover(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// fail before uploading anything for a book that does not exist
+	if _, err := s.repo.GetByBookID(ctx, id); err != nil {
+		return nil, writeError(err)
+	}
+
+	sum := sha256.Sum256(encoded)
+	key := fmt.Sprintf("covers/%s/%d/%x%s", tenant.FromContext(ctx), id, sum[:8], ext)
+	url := s.covers.URL(key)
+	if url == "" {
+		url = fmt.Sprintf(coverAPIPath, id)
+	}
+	if err := s.covers.Put(ctx, key, contentType, encoded); err != nil {
+		return nil, fmt.Errorf("failed to store cover: %w", err)
+	}
+
+	var before, book *models.Book
+	err = s.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		if before, err = repos.Books.GetByBookIDForUpdate(ctx, id); err != nil {
+			return writeError(err)
+		}
+		if book, err = repos.Books.SetCover(ctx, id, key, url); err != nil {
+			return writeError(err)
+		}
+		if err := s.record(ctx, repos.Audit, models.AuditUpdate, book.ID, before, book); err != nil {
+			return err
+		}
+		return s.enqueue(ctx, repos.Outbox, models.BookUpdated, book)
+	})
+	if err != nil {
+		// re-uploading the same image reuses its key, which must survive
+		if before == nil || before.CoverKey != key {
+			s.deleteCover(ctx, key)
+		}
+		return nil, err
+	}
+	if before.CoverKey != "" && before.CoverKey != key {
+		s.deleteCover(ctx, before.CoverKey)
+	}
+
+	s.emit(ctx, models.BookUpdated, book)
+
+	return book, nil
+}
+
+// GetCover returns the cover of a book, or ErrNotFound when it has none.
+func (s *BookService) GetCover(ctx context.Context, id int) (*Cover, error) {
+	book, err := s.GetByBookID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if book.CoverKey == "" || s.covers == nil {
+		return nil, fmt.Errorf("%w: book has no cover", ErrNotFound)
+	}
+
+	if url := s.covers.URL(book.CoverKey); url != "" {
+		return &Cover{RedirectURL: url}, nil
+	}
+
+	data, err := s.covers.Get(ctx, book.CoverKey)
+	if errors.Is(err, repositories.ErrObjectNotFound) {
+		return nil, fmt.Errorf("%w: cover image is missing", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cover: %w", err)
+	}
+
+	contentType := "image/jpeg"
+	if path.Ext(book.CoverKey) == ".png" {
+		contentType = "image/png"
+	}
+
+	// the key is named after a hash of the image, so it is a stable validator
+	name := path.Base(book.CoverKey)
+	return &Cover{
+		Data:        data,
+		ContentType: contentType,
+		ETag:        `"` + strings.TrimSuffix(name, path.Ext(name)) + `"`,
+	}, nil
+}
+
+// deleteCover removes an object that is no longer referenced. A failure only
+// leaves an orphan behind, so it is logged rather than returned.
+func (s *BookService) deleteCover(ctx context.Context, key string) {
+	if err := s.covers.Delete(context.WithoutCancel(ctx), key); err != nil {
+		zap.L().Warn("failed to delete cover image", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// reencodeCover checks that data is a JPEG or PNG image of acceptable size and
+// dimensions and encodes it afresh, so nothing but pixels is kept. The header
+// is checked before the image is decoded, so oversized images are rejected
+// without allocating their pixels.
+func reencodeCover(data []byte) (out []byte, contentType, ext string, err error) {
+	if len(data) == 0 {
+		return nil, "", "", fmt.Errorf("%w: cover image is empty", ErrInvalidInput)
+	}
+	if len(data) > MaxCoverBytes {
+		return nil, "", "", fmt.Errorf("%w: cover image exceeds %d bytes", ErrInvalidInput, MaxCoverBytes)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return nil, "", "", fmt.Errorf("%w: cover must be a JPEG or PNG image", ErrInvalidInput)
+	}
+	if cfg.Width < MinCoverSide || cfg.Height < MinCoverSide || cfg.Width > MaxCoverSide || cfg.Height > MaxCoverSide {
+		return nil, "", "", fmt.Errorf("%w: cover must be between %d and %d pixels wide and high, got %dx%d",
+			ErrInvalidInput, MinCoverSide, MaxCoverSide, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", "", fmt.Errorf("%w: cover image is corrupt", ErrInvalidInput)
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		contentType, ext = "image/png", ".png"
+		err = png.Encode(&buf, img)
+	default:
+		contentType, ext = "image/jpeg", ".jpg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: coverJPEGQuality})
+	}
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to encode cover: %w", err)
+	}
+
+	return buf.Bytes(), contentType, ext, nil
+}
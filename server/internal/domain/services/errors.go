@@ -1,10 +1,17 @@
 package services
 
-import "errors"
+import (
+	"bf-api/internal/domain/repositories"
+	"errors"
+)
 
 var (
 	ErrNotFound         = errors.New("not found")
 	ErrInvalidInput     = errors.New("invalid input")
 	ErrPermissionDenied = errors.New("permission denied")
 	ErrConflict         = errors.New("conflict")
+	ErrPrecondition     = errors.New("precondition failed")
+	// ErrUnavailable means the request could not be served right now; it
+	// matches repository errors the caller may retry later.
+	ErrUnavailable = repositories.ErrPoolExhausted
 )
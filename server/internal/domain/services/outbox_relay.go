@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bf-api/internal/domain/repositories"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type OutboxRelayConfig struct {
+	PollInterval time.Duration // def: 1s
+	BatchSize    int           // entries published per poll; def: 100
+}
+
+// OutboxRelay publishes the events BookService writes to the outbox when
+// WithOutbox is set. Delivery is at least once: an entry is only marked sent
+// after the publisher accepted it, and a failed publish leaves it pending for
+// the next poll.
+type OutboxRelay struct {
+	cfg       OutboxRelayConfig
+	tx        repositories.TxManager
+	publisher EventPublisher
+}
+
+func NewOutboxRelay(cfg OutboxRelayConfig, tx repositories.TxManager, publisher EventPublisher) *OutboxRelay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &OutboxRelay{cfg: cfg, tx: tx, publisher: publisher}
+}
+
+// Run relays pending events until ctx is canceled. A full batch is followed
+// immediately by the next one so a backlog drains without waiting a poll.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := r.RelayPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			zap.L().Error("failed to relay outbox events", zap.Error(err))
+		}
+		if err == nil && sent == r.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes one batch of pending events, oldest first, and
+// returns how many were sent. The batch stops at the first failed publish so
+// events keep their order; the failure is recorded on the entry.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	var sent int
+	err := r.tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		entries, err := repos.Outbox.ClaimPending(ctx, r.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("repository error: %w", err)
+		}
+
+		for _, entry := range entries {
+			if err := r.publisher.Publish(ctx, entry.Event); err != nil {
+				zap.L().Warn("failed to publish outbox event; will retry",
+					zap.Error(err),
+					zap.Int64("outbox_id", entry.ID),
+					zap.String("event_type", string(entry.Event.EventType)),
+					zap.Int("attempts", entry.Attempts+1),
+				)
+				if err := repos.Outbox.MarkFailed(ctx, entry.ID, err.Error()); err != nil {
+					return fmt.Errorf("repository error: %w", err)
+				}
+				return nil
+			}
+			if err := repos.Outbox.MarkSent(ctx, entry.ID); err != nil {
+				return fmt.Errorf("repository error: %w", err)
+			}
+			sent++
+		}
+		return nil
+	})
+	if err != nil {
+		// the rollback returned the entries to pending, including published ones
+		return 0, err
+	}
+	return sent, nil
+}
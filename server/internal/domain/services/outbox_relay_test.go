@@ -0,0 +1,87 @@
+package services_test
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/services"
+	"bf-api/internal/infrastructure/db/memory"
+	"context"
+	"errors"
+	"testing"
+)
+
+// publisherFunc adapts a function to services.EventPublisher.
+type publisherFunc func(ctx context.Context, event models.BookEvent) error
+
+func (f publisherFunc) Publish(ctx context.Context, event models.BookEvent) error {
+	return f(ctx, event)
+}
+
+func pendingOutbox(t *testing.T, tx repositories.TxManager) []*models.OutboxEntry {
+	t.Helper()
+	var entries []*models.OutboxEntry
+	err := tx.WithTx(context.Background(), func(repos repositories.Repositories) error {
+		var err error
+		entries, err = repos.Outbox.ClaimPending(context.Background(), 100)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("ClaimPending() error = %v", err)
+	}
+	return entries
+}
+
+func TestOutboxRelayFailedPublishLeavesEntryPending(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	tx := memory.NewTxManager(store)
+
+	var brokerDown bool
+	var published []models.BookEvent
+	publisher := publisherFunc(func(_ context.Context, event models.BookEvent) error {
+		if brokerDown {
+			return errors.New("broker unavailable")
+		}
+		published = append(published, event)
+		return nil
+	})
+
+	brokerDown = true
+	svc := services.NewBookService(memory.NewBookRepository(store), memory.NewAuditRepository(store), tx, nil, publisher, services.WithOutbox())
+	book, err := svc.CreateBook(ctx, &models.BookCreateRequest{
+		Title:     "Title",
+		Author:    "Author",
+		Published: models.Date{Year: 2000, Month: 1, Day: 1},
+		ISBN:      "9780306406157",
+		Pages:     100,
+	})
+	if err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+
+	relay := services.NewOutboxRelay(services.OutboxRelayConfig{}, tx, publisher)
+	sent, err := relay.RelayPending(ctx)
+	if err != nil || sent != 0 {
+		t.Fatalf("RelayPending() = %d, %v; want 0, nil", sent, err)
+	}
+
+	pending := pendingOutbox(t, tx)
+	if len(pending) != 1 {
+		t.Fatalf("pending entries = %d, want 1", len(pending))
+	}
+	if got := pending[0]; got.Attempts != 1 || got.Event.EventType != models.BookCreated || got.Event.Book.ID != book.ID {
+		t.Errorf("pending entry = %+v, want one attempt at the created event", got)
+	}
+
+	brokerDown = false
+	sent, err = relay.RelayPending(ctx)
+	if err != nil || sent != 1 {
+		t.Fatalf("RelayPending() after recovery = %d, %v; want 1, nil", sent, err)
+	}
+	if len(published) != 1 || published[0].Book.ID != book.ID {
+		t.Errorf("published = %+v, want the created book", published)
+	}
+	if pending := pendingOutbox(t, tx); len(pending) != 0 {
+		t.Errorf("pending entries after recovery = %d, want 0", len(pending))
+	}
+}
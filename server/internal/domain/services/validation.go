@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationErrors collects every field that failed validation so clients can
+// fix them all at once. It matches ErrInvalidInput with errors.Is.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, fe := range v {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (v ValidationErrors) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// add records a failure for field unless that field already failed, so a
+// business rule never repeats a struct tag failure.
+func (v *ValidationErrors) add(field, message string) {
+	if v.has(field) {
+		return
+	}
+	*v = append(*v, FieldError{Field: field, Message: message})
+}
+
+func (v ValidationErrors) has(field string) bool {
+	for _, fe := range v {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// merge adds every failure in other that v does not already have.
+func (v *ValidationErrors) merge(other ValidationErrors) {
+	for _, fe := range other {
+		v.add(fe.Field, fe.Message)
+	}
+}
+
+// err returns v as an error, or nil when nothing failed.
+func (v ValidationErrors) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// UnknownAuthorError reports the authors rejected by the strict author check,
+// each message naming the close matches. Unlike other validation failures it
+// is sent as 400 over HTTP; the fields it wraps still match ValidationErrors
+// and ErrInvalidInput with errors.As and errors.Is.
+type UnknownAuthorError struct {
+	Fields ValidationErrors
+}
+
+func (e *UnknownAuthorError) Error() string {
+	return "unknown author: " + e.Fields.Error()
+}
+
+func (e *UnknownAuthorError) Unwrap() error {
+	return e.Fields
+}
+
+// requestValidator checks the struct tags on request models. Fields are
+// reported by their JSON names. The minpages and maxpages tags are aliases for
+// min and max with the configured page count limits, so every model shares the
+// one setting.
+type requestValidator struct {
+	validate *validator.Validate
+}
+
+func newRequestValidator(minPages, maxPages int) requestValidator {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterAlias("minpages", fmt.Sprintf("min=%d", minPages))
+	v.RegisterAlias("maxpages", fmt.Sprintf("max=%d", maxPages))
+	return requestValidator{validate: v}
+}
+
+// validateStruct runs the struct tag rules on s and returns every failure.
+func (rv requestValidator) validateStruct(s interface{}) ValidationErrors {
+	var errs ValidationErrors
+	var valErrs validator.ValidationErrors
+	if err := rv.validate.Struct(s); errors.As(err, &valErrs) {
+		for _, fe := range valErrs {
+			errs.add(fe.Field(), fieldMessage(fe))
+		}
+	}
+	return errs
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	// aliases such as minpages report the tag they expand to
+	switch fe.ActualTag() {
+	case "required":
+		return "This field is required"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("Must be at least %s characters", fe.Param())
+		}
+		return "Must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("Must be at most %s characters", fe.Param())
+		}
+		return "Must be at most " + fe.Param()
+	case "gt":
+		return "Must be greater than " + fe.Param()
+	case "datetime":
+		return "Must be a date in " + fe.Param() + " format"
+	default:
+		return fe.Error()
+	}
+}
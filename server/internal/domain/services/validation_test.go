@@ -0,0 +1,59 @@
+package services
+
+import (
+	"bf-api/internal/domain/models"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidateBooksISBNChecksum(t *testing.T) {
+	s := NewBookService(nil, nil, nil, nil, nil)
+	book := func(isbn string) models.BookCreateRequest {
+		return models.BookCreateRequest{
+			Title:     "Title",
+			Author:    "Author",
+			Published: models.Date{Year: 2000, Month: 1, Day: 1},
+			ISBN:      isbn,
+			Pages:     100,
+		}
+	}
+
+	resp, err := s.ValidateBooks([]models.BookCreateRequest{
+		book("978-0-306-40615-7"),
+		book("9780306406158"),
+		book("0-8044-2957-x"),
+	})
+	if err != nil {
+		t.Fatalf("ValidateBooks() error = %v", err)
+	}
+	if resp.Valid {
+		t.Error("Valid = true, want false")
+	}
+	for i, want := range []bool{true, false, true} {
+		if got := resp.Results[i].Valid; got != want {
+			t.Errorf("Results[%d].Valid = %v, want %v (errors %v)", i, got, want, resp.Results[i].Errors)
+		}
+	}
+	if errs := resp.Results[1].Errors; len(errs) != 1 || errs[0].Field != "isbn" {
+		t.Errorf("Results[1].Errors = %v, want one isbn error", errs)
+	}
+}
+
+func TestCreateBookRejectsISBNChecksum(t *testing.T) {
+	s := NewBookService(nil, nil, nil, nil, nil)
+	req := &models.BookCreateRequest{
+		Title:     "Title",
+		Author:    "Author",
+		Published: models.Date{Year: 2000, Month: 1, Day: 1},
+		ISBN:      "9780306406158",
+		Pages:     100,
+	}
+
+	// validation fails before the repositories are reached
+	_, err := s.CreateBook(context.Background(), req)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || !errs.has("isbn") {
+		t.Errorf("CreateBook() error = %v, want an isbn validation error", err)
+	}
+}
@@ -0,0 +1,69 @@
+// Package tenant carries the bookstore a request acts on. Every book query is
+// scoped to the tenant in its context.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// Default is the tenant of requests that name none, and of every book that
+// existed before tenancy was introduced.
+const Default = "default"
+
+var (
+	// ErrInvalid is returned for tenant IDs that are not 1-64 letters,
+	// digits, underscores or hyphens.
+	ErrInvalid = errors.New("invalid tenant ID")
+	// ErrMismatch is returned when a caller asks for a tenant it may not
+	// act on.
+	ErrMismatch = errors.New("caller may not act on the requested tenant")
+)
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Valid reports whether id is a well-formed tenant ID.
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// Resolve picks the tenant of a request. A caller whose API key is bound to
+// a tenant always acts on it, and may only repeat it in requested. Unbound
+// callers that may choose act on requested, or on Default when it is empty.
+// Every other caller, anonymous ones included, acts on Default and may only
+// repeat it.
+func Resolve(bound string, mayChoose bool, requested string) (string, error) {
+	if requested != "" && !Valid(requested) {
+		return "", ErrInvalid
+	}
+	switch {
+	case bound != "":
+		if requested != "" && requested != bound {
+			return "", ErrMismatch
+		}
+		return bound, nil
+	case mayChoose && requested != "":
+		return requested, nil
+	case requested != "" && requested != Default:
+		return "", ErrMismatch
+	}
+	return Default, nil
+}
+
+type contextKey string
+
+const tenantKey contextKey = "tenant"
+
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey, id)
+}
+
+// FromContext returns the tenant stored in ctx, or Default when there is
+// none, as for background jobs.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(tenantKey).(string); ok {
+		return id
+	}
+	return Default
+}
@@ -0,0 +1,40 @@
+package tenant
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		bound     string
+		mayChoose bool
+		requested string
+		want      string
+		wantErr   error
+	}{
+		{name: "anonymous", want: Default},
+		{name: "anonymous repeating default", requested: Default, want: Default},
+		{name: "anonymous choosing", requested: "acme", wantErr: ErrMismatch},
+		{name: "unbound editor choosing", requested: "acme", wantErr: ErrMismatch},
+		{name: "unbound admin", mayChoose: true, want: Default},
+		{name: "unbound admin choosing", mayChoose: true, requested: "acme", want: "acme"},
+		{name: "bound", bound: "acme", want: "acme"},
+		{name: "bound repeating", bound: "acme", requested: "acme", want: "acme"},
+		{name: "bound choosing", bound: "acme", requested: "globex", wantErr: ErrMismatch},
+		{name: "bound choosing default", bound: "acme", requested: Default, wantErr: ErrMismatch},
+		{name: "invalid", mayChoose: true, requested: "a:b", wantErr: ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.bound, tt.mayChoose, tt.requested)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"bf-api/internal/domain/tenant"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+)
+
+type Role string
+
+const (
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Name string
+	Role Role
+	// Tenant binds the caller to one tenant. Unbound admins may choose any;
+	// other unbound callers act on tenant.Default.
+	Tenant string
+}
+
+// MayChooseTenant reports whether p may act on a tenant other than the one
+// it is bound to, which only unbound admins may.
+func (p Principal) MayChooseTenant() bool {
+	return p.Tenant == "" && p.Role == RoleAdmin
+}
+
+// HasRole reports whether p holds one of roles; admins hold every role.
+func (p Principal) HasRole(roles ...Role) bool {
+	if p.Role == RoleAdmin {
+		return true
+	}
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+type Config struct {
+	APIKeys []APIKey
+}
+
+type APIKey struct {
+	Key       string
+	Principal Principal
+}
+
+// Lookup returns the principal owning key. Every configured key is compared
+// in constant time so the response time does not leak key prefixes.
+func (c Config) Lookup(key string) (Principal, bool) {
+	var found Principal
+	var ok bool
+	for _, k := range c.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+			found, ok = k.Principal, true
+		}
+	}
+	return found, ok
+}
+
+// ParseAPIKeys parses entries of the form key:name:role or
+// key:name:role:tenant, the latter binding the key to one tenant.
+func ParseAPIKeys(entries []string) ([]APIKey, error) {
+	keys := make([]APIKey, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 && len(parts) != 4 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("malformed API key entry, want key:name:role[:tenant]")
+		}
+		role := Role(parts[2])
+		switch role {
+		case RoleEditor, RoleAdmin:
+		default:
+			return nil, fmt.Errorf("unknown role %q for API key %q", role, parts[1])
+		}
+		principal := Principal{Name: parts[1], Role: role}
+		if len(parts) == 4 {
+			if !tenant.Valid(parts[3]) {
+				return nil, fmt.Errorf("invalid tenant %q for API key %q", parts[3], parts[1])
+			}
+			principal.Tenant = parts[3]
+		}
+		keys = append(keys, APIKey{Key: parts[0], Principal: principal})
+	}
+	return keys, nil
+}
+
+type contextKey string
+
+const principalKey contextKey = "principal"
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	return p, ok
+}
@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"bf-api/internal/infrastructure/tracing"
+	"context"
+)
+
+// RequestInfo tells the book service who made a request and which trace it
+// belongs to, from the principal stored by the auth middleware and the trace
+// ID stored by the tracing middleware.
+type RequestInfo struct{}
+
+func (RequestInfo) Actor(ctx context.Context) (string, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	return principal.Name, ok
+}
+
+func (RequestInfo) TraceID(ctx context.Context) (string, bool) {
+	return tracing.TraceIDFromContext(ctx)
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/tenant"
+	"context"
+)
+
+type AuditRepository struct {
+	conn
+}
+
+func NewAuditRepository(store *Store) repositories.AuditRepository {
+	return &AuditRepository{conn: conn{store: store}}
+}
+
+type auditRow struct {
+	entry    models.AuditEntry
+	tenantID string
+}
+
+// Record appends entry to the audit log and fills in its ID.
+func (r *AuditRepository) Record(ctx context.Context, entry *models.AuditEntry) error {
+	defer r.lock()()
+
+	r.store.lastAuditID++
+	entry.ID = r.store.lastAuditID
+	n := len(r.store.audit)
+	r.onRollback(func() { r.store.audit = r.store.audit[:n] })
+	r.store.audit = append(r.store.audit, auditRow{entry: *entry, tenantID: tenant.FromContext(ctx)})
+
+	return nil
+}
+
+// ListByBook returns every audit entry for a book of the tenant, oldest first.
+func (r *AuditRepository) ListByBook(ctx context.Context, bookID int) ([]*models.AuditEntry, error) {
+	defer r.lock()()
+
+	tenantID := tenant.FromContext(ctx)
+	entries := []*models.AuditEntry{}
+	for _, row := range r.store.audit {
+		if row.entry.BookID == bookID && row.tenantID == tenantID {
+			entry := row.entry
+			entries = append(entries, &entry)
+		}
+	}
+
+	return entries, nil
+}
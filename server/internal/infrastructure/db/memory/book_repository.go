@@ -0,0 +1,747 @@
+package memory
+
+import (
+	"bf-api/internal/domain/filterexpr"
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/tenant"
+	"bf-api/internal/infrastructure/db/postgres"
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type BookRepository struct {
+	conn
+
+	importSkipsDuplicates bool
+	importBatchSize       int
+}
+
+type BookRepositoryOption func(*BookRepository)
+
+// WithImport sets how ImportBooks treats ISBNs already in use, following
+// the postgres import modes, and how many books it writes per chunk. An
+// empty mode keeps postgres.ImportModeCopy and a batchSize below 1 keeps
+// postgres.DefaultImportBatchSize.
+func WithImport(mode string, batchSize int) BookRepositoryOption {
+	return func(r *BookRepository) {
+		r.importSkipsDuplicates = mode == postgres.ImportModeBatch
+		if batchSize > 0 {
+			r.importBatchSize = batchSize
+		}
+	}
+}
+
+func NewBookRepository(store *Store, opts ...BookRepositoryOption) repositories.BookRepository {
+	return newBookRepository(conn{store: store}, opts...)
+}
+
+func newBookRepository(c conn, opts ...BookRepositoryOption) *BookRepository {
+	r := &BookRepository{conn: c, importBatchSize: postgres.DefaultImportBatchSize}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// active returns the active book id of the tenant in ctx.
+func (r *BookRepository) active(ctx context.Context, id int) (bookRow, bool) {
+	row, ok := r.store.books[id]
+	if !ok || row.tenantID != tenant.FromContext(ctx) || row.deletedAt != nil {
+		return bookRow{}, false
+	}
+	return row, true
+}
+
+// activeBooks returns the active books of the tenant in ctx in ID order.
+func (r *BookRepository) activeBooks(ctx context.Context) []bookRow {
+	tenantID := tenant.FromContext(ctx)
+	var rows []bookRow
+	for _, row := range r.store.books {
+		if row.tenantID == tenantID && row.deletedAt == nil {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b bookRow) int { return cmp.Compare(a.book.ID, b.book.ID) })
+	return rows
+}
+
+// isbnTaken reports whether an active book of the tenant other than
+// excludeID has isbn, mirroring the partial unique index.
+func (r *BookRepository) isbnTaken(tenantID, isbn string, excludeID int) bool {
+	for id, row := range r.store.books {
+		if id != excludeID && row.tenantID == tenantID && row.deletedAt == nil && row.book.ISBN == isbn {
+			return true
+		}
+	}
+	return false
+}
+
+// stored returns a copy of book as it is stored, dropping the per-listing
+// fields.
+func stored(book models.Book) *models.Book {
+	book.Relevance = nil
+	book.Deleted = false
+	return &book
+}
+
+func (r *BookRepository) CreateBook(ctx context.Context, book *models.Book) error {
+	defer r.lock()()
+	return r.insertBook(ctx, book)
+}
+
+func (r *BookRepository) insertBook(ctx context.Context, book *models.Book) error {
+	tenantID := tenant.FromContext(ctx)
+	if r.isbnTaken(tenantID, book.ISBN, 0) {
+		return repositories.ErrDuplicateISBN
+	}
+
+	r.store.lastBookID++
+	book.ID = r.store.lastBookID
+	book.Stock = 0
+	book.CreatedAt = r.now()
+	book.UpdatedAt = book.CreatedAt
+	book.CoverKey, book.CoverURL = "", ""
+	r.putBook(bookRow{book: *stored(*book), tenantID: tenantID})
+	return nil
+}
+
+// CreateBookIdempotent inserts book unless key was already used within its
+// TTL, in which case book is filled with the originally created record and
+// replayed is true. Keys are scoped to the tenant, so tenants may pick the
+// same ones.
+func (r *BookRepository) CreateBookIdempotent(ctx context.Context, key string, ttl time.Duration, book *models.Book) (bool, error) {
+	defer r.lock()()
+
+	id := idempotencyKeyID{tenantID: tenant.FromContext(ctx), key: key}
+	now := r.now()
+	if prev, ok := r.store.idempotencyKeys[id]; ok && prev.expiresAt.After(now) {
+		if row, ok := r.active(ctx, prev.bookID); ok {
+			*book = *stored(row.book)
+			return true, nil
+		}
+	}
+
+	if err := r.insertBook(ctx, book); err != nil {
+		return false, err
+	}
+
+	// a stale key, or one whose book was deleted, is replaced
+	prev, hadPrev := r.store.idempotencyKeys[id]
+	r.onRollback(func() {
+		if hadPrev {
+			r.store.idempotencyKeys[id] = prev
+		} else {
+			delete(r.store.idempotencyKeys, id)
+		}
+	})
+	r.store.idempotencyKeys[id] = idempotencyKey{bookID: book.ID, expiresAt: now.Add(ttl)}
+
+	return false, nil
+}
+
+func (r *BookRepository) GetByBookID(ctx context.Context, id int) (*models.Book, error) {
+	defer r.lock()()
+	row, ok := r.active(ctx, id)
+	if !ok {
+		return nil, repositories.ErrBookNotFound
+	}
+	return stored(row.book), nil
+}
+
+// GetByBookIDForUpdate reads an active book. Transactions already hold the
+// whole store, so there is no row to lock.
+func (r *BookRepository) GetByBookIDForUpdate(ctx context.Context, id int) (*models.Book, error) {
+	return r.GetByBookID(ctx, id)
+}
+
+func (r *BookRepository) GetBookMeta(ctx context.Context, id int) (*models.BookMeta, error) {
+	defer r.lock()()
+	row, ok := r.active(ctx, id)
+	if !ok {
+		return nil, repositories.ErrBookNotFound
+	}
+	return &models.BookMeta{ID: id, UpdatedAt: row.book.UpdatedAt}, nil
+}
+
+func (r *BookRepository) GetByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	defer r.lock()()
+	for _, row := range r.activeBooks(ctx) {
+		if row.book.ISBN == isbn {
+			return stored(row.book), nil
+		}
+	}
+	return nil, repositories.ErrBookNotFound
+}
+
+// GetByIDs returns the active books among ids; missing IDs are simply
+// absent.
+func (r *BookRepository) GetByIDs(ctx context.Context, ids []int) ([]*models.Book, error) {
+	defer r.lock()()
+	books := []*models.Book{}
+	for _, id := range ids {
+		if row, ok := r.active(ctx, id); ok && !slices.ContainsFunc(books, func(b *models.Book) bool { return b.ID == id }) {
+			books = append(books, stored(row.book))
+		}
+	}
+	return books, nil
+}
+
+// EachBook calls fn for every active book, in ID order, and stops at the
+// first error fn returns. fn runs on a snapshot taken up front, so it may
+// take its time without holding up writers.
+func (r *BookRepository) EachBook(ctx context.Context, fn func(*models.Book) error) error {
+	unlock := r.lock()
+	rows := r.activeBooks(ctx)
+	unlock()
+
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(stored(row.book)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ISBNTaken reports whether an active book of the tenant other than
+// excludeID has isbn.
+func (r *BookRepository) ISBNTaken(ctx context.Context, isbn string, excludeID int) (bool, error) {
+	defer r.lock()()
+	return r.isbnTaken(tenant.FromContext(ctx), isbn, excludeID), nil
+}
+
+// FindByTitleAuthor returns up to limit active books of the tenant other than
+// excludeID whose title and author match, ignoring case, oldest first.
+func (r *BookRepository) FindByTitleAuthor(ctx context.Context, title, author string, excludeID, limit int) ([]*models.Book, error) {
+	defer r.lock()()
+
+	books := []*models.Book{}
+	for _, row := range r.activeBooks(ctx) {
+		if len(books) == limit {
+			break
+		}
+		if row.book.ID != excludeID && strings.EqualFold(row.book.Title, title) && strings.EqualFold(row.book.Author, author) {
+			books = append(books, stored(row.book))
+		}
+	}
+	return books, nil
+}
+
+// FetchAllBook returns a page of the books matching filter, ordered like the
+// postgres listing. Full-text search is approximated: every search word must
+// appear as a whole word in the title or author, without stemming.
+func (r *BookRepository) FetchAllBook(ctx context.Context, page, pageSize int, filter models.BookFilter) ([]*models.Book, int, error) {
+	defer r.lock()()
+
+	matches := r.filterBooks(ctx, filter)
+	switch {
+	case filter.UpdatedSince != nil:
+		// sync consumers checkpoint on the last updated_at they have seen
+		slices.SortStableFunc(matches, func(a, b *models.Book) int {
+			return cmp.Or(a.UpdatedAt.Compare(b.UpdatedAt), cmp.Compare(a.ID, b.ID))
+		})
+	default:
+		slices.SortStableFunc(matches, func(a, b *models.Book) int {
+			var byRelevance int
+			if a.Relevance != nil && b.Relevance != nil {
+				byRelevance = cmp.Compare(*b.Relevance, *a.Relevance)
+			}
+			return cmp.Or(byRelevance, b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+		})
+	}
+
+	offset := (page - 1) * pageSize
+	books := []*models.Book{}
+	if offset >= 0 && offset < len(matches) {
+		books = matches[offset:min(offset+pageSize, len(matches))]
+	}
+	return books, len(matches), nil
+}
+
+func (r *BookRepository) CountBooks(ctx context.Context, filter models.BookFilter) (int, error) {
+	defer r.lock()()
+	return len(r.filterBooks(ctx, filter)), nil
+}
+
+// filterBooks returns the tenant's books matching filter in ID order, with
+// Deleted set and, for full-text searches, Relevance.
+func (r *BookRepository) filterBooks(ctx context.Context, filter models.BookFilter) []*models.Book {
+	tenantID := tenant.FromContext(ctx)
+	// like an empty tsquery, a search of stop words only falls back to the
+	// prefix match
+	var terms []string
+	prefixSearch := filter.SearchMode == models.SearchModePrefix
+	if filter.Search != "" && !prefixSearch {
+		terms = slices.DeleteFunc(words(filter.Search), isStopWord)
+		prefixSearch = len(terms) == 0
+	}
+
+	books := []*models.Book{}
+	for _, row := range r.store.books {
+		if row.tenantID != tenantID {
+			continue
+		}
+		switch filter.Deleted {
+		case models.DeletedFilterAll:
+		case models.DeletedFilterOnly:
+			if row.deletedAt == nil {
+				continue
+			}
+		default:
+			if row.deletedAt != nil {
+				continue
+			}
+		}
+
+		book := stored(row.book)
+		book.Deleted = row.deletedAt != nil
+		if filter.Search != "" {
+			if prefixSearch {
+				prefix := strings.ToLower(filter.Search)
+				if !strings.HasPrefix(strings.ToLower(book.Title), prefix) && !strings.HasPrefix(strings.ToLower(book.Author), prefix) {
+					continue
+				}
+				if filter.SearchMode != models.SearchModePrefix {
+					var relevance float32
+					book.Relevance = &relevance
+				}
+			} else {
+				relevance, ok := rank(terms, book)
+				if !ok {
+					continue
+				}
+				book.Relevance = &relevance
+			}
+		}
+		if filter.AuthorExact != "" && book.Author != filter.AuthorExact {
+			continue
+		}
+		if !inRange(book.CreatedAt, filter.CreatedAfter, filter.CreatedBefore) ||
+			!inRange(book.UpdatedAt, filter.UpdatedAfter, filter.UpdatedBefore) {
+			continue
+		}
+		if filter.UpdatedSince != nil && !book.UpdatedAt.After(*filter.UpdatedSince) {
+			continue
+		}
+		if filter.SnapshotAt != nil && book.CreatedAt.After(*filter.SnapshotAt) {
+			continue
+		}
+		if filter.Expr != nil && !matchExpr(filter.Expr, book) {
+			continue
+		}
+		books = append(books, book)
+	}
+
+	slices.SortFunc(books, func(a, b *models.Book) int { return cmp.Compare(a.ID, b.ID) })
+	return books
+}
+
+// matchExpr evaluates a filter expression against book.
+func matchExpr(expr filterexpr.Expr, book *models.Book) bool {
+	switch e := expr.(type) {
+	case *filterexpr.Group:
+		for _, term := range e.Terms {
+			if matchExpr(term, book) == (e.Op == filterexpr.Or) {
+				return e.Op == filterexpr.Or
+			}
+		}
+		return e.Op == filterexpr.And
+	case *filterexpr.Condition:
+		var c int
+		switch e.Field {
+		case filterexpr.FieldTitle:
+			c = strings.Compare(book.Title, e.Value.(string))
+		case filterexpr.FieldAuthor:
+			c = strings.Compare(book.Author, e.Value.(string))
+		case filterexpr.FieldISBN:
+			c = strings.Compare(book.ISBN, e.Value.(string))
+		case filterexpr.FieldPages:
+			c = cmp.Compare(book.Pages, e.Value.(int))
+		case filterexpr.FieldStock:
+			c = cmp.Compare(book.Stock, e.Value.(int))
+		case filterexpr.FieldPublished:
+			// like NULL in SQL, a missing date matches no comparison
+			if book.Published.IsZero() {
+				return false
+			}
+			c = book.Published.Time().Compare(e.Value.(time.Time))
+		}
+		return e.Op.Compare(c)
+	}
+	panic(fmt.Sprintf("unknown filter expression %T", expr))
+}
+
+// inRange reports whether t lies in [after, before); nil bounds are open.
+func inRange(t time.Time, after, before *time.Time) bool {
+	return (after == nil || !t.Before(*after)) && (before == nil || t.Before(*before))
+}
+
+// words splits s into lowercase words.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// stopWords mirrors the english stop word list of Postgres' text search
+// configuration.
+var stopWords = func() map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(`
+		i me my myself we our ours ourselves you your yours yourself yourselves
+		he him his himself she her hers herself it its itself they them their
+		theirs themselves what which who whom this that these those am is are
+		was were be been being have has had having do does did doing a an the
+		and but if or because as until while of at by for with about against
+		between into through during before after above below to from up down in
+		out on off over under again further then once here there when where why
+		how all any both each few more most other some such no nor not only own
+		same so than too very s t can will just don should now`) {
+		set[w] = true
+	}
+	return set
+}()
+
+// isStopWord reports whether full-text search ignores w.
+func isStopWord(w string) bool {
+	return stopWords[w]
+}
+
+// rank matches the search terms against the title and author of book. Like
+// the weights of search_vector, a term found in the title counts more than
+// one found in the author.
+func rank(terms []string, book *models.Book) (float32, bool) {
+	if len(terms) == 0 {
+		return 0, false
+	}
+	title, author := words(book.Title), words(book.Author)
+	var relevance float32
+	for _, term := range terms {
+		switch {
+		case slices.Contains(title, term):
+			relevance += 1
+		case slices.Contains(author, term):
+			relevance += 0.4
+		default:
+			return 0, false
+		}
+	}
+	return relevance / float32(len(terms)), true
+}
+
+func (r *BookRepository) UpdateBook(ctx context.Context, book *models.Book) error {
+	defer r.lock()()
+
+	row, ok := r.active(ctx, book.ID)
+	if !ok {
+		return repositories.ErrBookNotFound
+	}
+	if book.ISBN != row.book.ISBN && r.isbnTaken(row.tenantID, book.ISBN, book.ID) {
+		return repositories.ErrDuplicateISBN
+	}
+
+	row.book.Title = book.Title
+	row.book.Author = book.Author
+	row.book.Published = book.Published
+	row.book.ISBN = book.ISBN
+	row.book.Pages = book.Pages
+	row.book.AcquisitionCost = book.AcquisitionCost
+	row.book.SupplierNotes = book.SupplierNotes
+	row.book.UpdatedAt = r.now()
+	r.putBook(row)
+
+	*book = *stored(row.book)
+	return nil
+}
+
+// UpsertBooks inserts each book or, when an active book of the tenant already
+// has its ISBN, overwrites the stored title, author, published date and
+// pages, and the confidential fields when given. Books are filled with the
+// stored rows.
+func (r *BookRepository) UpsertBooks(ctx context.Context, books []*models.Book) ([]*models.Book, []*models.Book, error) {
+	defer r.lock()()
+
+	tenantID := tenant.FromContext(ctx)
+	var inserted, updated []*models.Book
+	for _, book := range books {
+		existing, found := bookRow{}, false
+		for _, row := range r.store.books {
+			if row.tenantID == tenantID && row.deletedAt == nil && row.book.ISBN == book.ISBN {
+				existing, found = row, true
+				break
+			}
+		}
+		if !found {
+			if err := r.insertBook(ctx, book); err != nil {
+				return nil, nil, fmt.Errorf("failed to upsert book %s: %w", book.ISBN, err)
+			}
+			inserted = append(inserted, book)
+			continue
+		}
+
+		existing.book.Title = book.Title
+		existing.book.Author = book.Author
+		existing.book.Published = book.Published
+		existing.book.Pages = book.Pages
+		if book.AcquisitionCost != "" {
+			existing.book.AcquisitionCost = book.AcquisitionCost
+		}
+		if book.SupplierNotes != "" {
+			existing.book.SupplierNotes = book.SupplierNotes
+		}
+		existing.book.UpdatedAt = r.now()
+		r.putBook(existing)
+
+		*book = *stored(existing.book)
+		updated = append(updated, book)
+	}
+
+	return inserted, updated, nil
+}
+
+// ImportBooks inserts books in chunks of the configured batch size, filling
+// in each inserted book from its new row. progress, when not nil, is called
+// after every chunk with the number of books processed so far. Existing
+// ISBNs fail the import or are skipped, as in the configured import mode.
+func (r *BookRepository) ImportBooks(ctx context.Context, books []*models.Book, progress func(processed int)) ([]*models.Book, []string, error) {
+	defer r.lock()()
+
+	tenantID := tenant.FromContext(ctx)
+	if !r.importSkipsDuplicates {
+		// the import is all or nothing, so check before writing anything
+		seen := make(map[string]bool, len(books))
+		for _, book := range books {
+			if seen[book.ISBN] || r.isbnTaken(tenantID, book.ISBN, 0) {
+				return nil, nil, fmt.Errorf("%w: %s", repositories.ErrDuplicateISBN, book.ISBN)
+			}
+			seen[book.ISBN] = true
+		}
+	}
+
+	inserted := make([]*models.Book, 0, len(books))
+	var skipped []string
+	for start := 0; start < len(books); start += r.importBatchSize {
+		chunk := books[start:min(start+r.importBatchSize, len(books))]
+		for _, book := range chunk {
+			if r.isbnTaken(tenantID, book.ISBN, 0) {
+				skipped = append(skipped, book.ISBN)
+				continue
+			}
+			if err := r.insertBook(ctx, book); err != nil {
+				return nil, nil, fmt.Errorf("failed to insert book %s: %w", book.ISBN, err)
+			}
+			inserted = append(inserted, book)
+		}
+
+		if progress != nil {
+			progress(start + len(chunk))
+		}
+	}
+
+	return inserted, skipped, nil
+}
+
+// DeleteBook soft-deletes a book and returns it as it was deleted.
+func (r *BookRepository) DeleteBook(ctx context.Context, id int) (*models.Book, error) {
+	defer r.lock()()
+	row, ok := r.active(ctx, id)
+	if !ok {
+		return nil, repositories.ErrBookNotFound
+	}
+	return r.softDelete(row), nil
+}
+
+// DeleteBooks soft-deletes every active book in ids and returns the books
+// that were actually deleted.
+func (r *BookRepository) DeleteBooks(ctx context.Context, ids []int) ([]*models.Book, error) {
+	defer r.lock()()
+	books := []*models.Book{}
+	for _, id := range ids {
+		if row, ok := r.active(ctx, id); ok {
+			books = append(books, r.softDelete(row))
+		}
+	}
+	return books, nil
+}
+
+func (r *BookRepository) softDelete(row bookRow) *models.Book {
+	now := r.now()
+	row.deletedAt = &now
+	row.book.UpdatedAt = now
+	r.putBook(row)
+	return stored(row.book)
+}
+
+// PurgeDeleted permanently removes books soft-deleted before deletedBefore,
+// along with their idempotency keys, and returns their IDs.
+func (r *BookRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]int, error) {
+	defer r.lock()()
+
+	tenantID := tenant.FromContext(ctx)
+	ids := []int{}
+	for id, row := range r.store.books {
+		if row.tenantID == tenantID && row.deletedAt != nil && row.deletedAt.Before(deletedBefore) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		row := r.store.books[id]
+		r.onRollback(func() { r.store.books[id] = row })
+		delete(r.store.books, id)
+	}
+	for key, idempotency := range r.store.idempotencyKeys {
+		if slices.Contains(ids, idempotency.bookID) {
+			r.onRollback(func() { r.store.idempotencyKeys[key] = idempotency })
+			delete(r.store.idempotencyKeys, key)
+		}
+	}
+
+	return ids, nil
+}
+
+// RestockBook adds amount to the stock of an active book and returns the
+// updated row.
+func (r *BookRepository) RestockBook(ctx context.Context, id, amount int) (*models.Book, error) {
+	defer r.lock()()
+
+	row, ok := r.active(ctx, id)
+	if !ok {
+		return nil, repositories.ErrBookNotFound
+	}
+	// stock is an INT column
+	stock := int64(row.book.Stock) + int64(amount)
+	if stock < math.MinInt32 || stock > math.MaxInt32 {
+		return nil, fmt.Errorf("%w: stock out of range", repositories.ErrInvalidData)
+	}
+
+	row.book.Stock = int(stock)
+	row.book.UpdatedAt = r.now()
+	r.putBook(row)
+	return stored(row.book), nil
+}
+
+// SetCover records the object key and URL of the cover of an active book and
+// returns the updated row.
+func (r *BookRepository) SetCover(ctx context.Context, id int, key, url string) (*models.Book, error) {
+	defer r.lock()()
+
+	row, ok := r.active(ctx, id)
+	if !ok {
+		return nil, repositories.ErrBookNotFound
+	}
+	row.book.CoverKey, row.book.CoverURL = key, url
+	row.book.UpdatedAt = r.now()
+	r.putBook(row)
+	return stored(row.book), nil
+}
+
+// FetchLowStock returns up to limit active books whose stock is below
+// threshold, lowest stock first.
+func (r *BookRepository) FetchLowStock(ctx context.Context, threshold, limit int) ([]*models.Book, error) {
+	defer r.lock()()
+
+	books := []*models.Book{}
+	for _, row := range r.activeBooks(ctx) {
+		if row.book.Stock < threshold {
+			books = append(books, stored(row.book))
+		}
+	}
+	slices.SortStableFunc(books, func(a, b *models.Book) int { return cmp.Compare(a.Stock, b.Stock) })
+	return books[:min(limit, len(books))], nil
+}
+
+// SuggestAuthors returns up to limit distinct authors of active books whose
+// name starts with prefix, ignoring case, in alphabetical order.
+func (r *BookRepository) SuggestAuthors(ctx context.Context, prefix string, limit int) ([]string, error) {
+	defer r.lock()()
+
+	prefix = strings.ToLower(prefix)
+	authors := []string{}
+	for _, row := range r.activeBooks(ctx) {
+		if strings.HasPrefix(strings.ToLower(row.book.Author), prefix) && !slices.Contains(authors, row.book.Author) {
+			authors = append(authors, row.book.Author)
+		}
+	}
+	slices.Sort(authors)
+	return authors[:min(limit, len(authors))], nil
+}
+
+// MatchAuthor reports whether name is one of the tenant's known authors and,
+// when it is not, returns up to limit known authors within a few edits of
+// it, closest first. Like the authors table, the known authors are those of
+// every book ever stored plus the ones added with AddAuthor.
+func (r *BookRepository) MatchAuthor(ctx context.Context, name string, limit int) (bool, []string, error) {
+	defer r.lock()()
+
+	tenantID := tenant.FromContext(ctx)
+	if r.store.authors[authorID{tenantID: tenantID, name: name}] {
+		return true, nil, nil
+	}
+
+	type match struct {
+		name     string
+		distance int
+	}
+	var matches []match
+	// about as lenient as the trigram similarity threshold postgres uses
+	maxDistance := max(1, len([]rune(name))/3)
+	for author := range r.store.authors {
+		if author.tenantID != tenantID {
+			continue
+		}
+		if d := levenshtein(strings.ToLower(author.name), strings.ToLower(name)); d <= maxDistance {
+			matches = append(matches, match{author.name, d})
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(a.distance, b.distance), cmp.Compare(a.name, b.name))
+	})
+
+	similar := []string{}
+	for _, m := range matches[:min(limit, len(matches))] {
+		similar = append(similar, m.name)
+	}
+	return false, similar, nil
+}
+
+// AddAuthor records name as a known author of the tenant and reports whether
+// it was not known before.
+func (r *BookRepository) AddAuthor(ctx context.Context, name string) (bool, error) {
+	defer r.lock()()
+
+	return r.putAuthor(authorID{tenantID: tenant.FromContext(ctx), name: name}), nil
+}
+
+// levenshtein returns the number of single-rune insertions, deletions and
+// substitutions turning a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
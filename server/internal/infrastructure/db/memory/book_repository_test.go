@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/tenant"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFetchAllBookStopWordsOnly(t *testing.T) {
+	repo := NewBookRepository(NewStore())
+	ctx := context.Background()
+	for _, book := range []*models.Book{
+		{Title: "The Who", Author: "Author", ISBN: "9780306406157", Pages: 100, Published: models.Date{Year: 2000, Month: time.January, Day: 1}},
+		{Title: "Dune", Author: "Frank Herbert", ISBN: "9780140449136", Pages: 100, Published: models.Date{Year: 1965, Month: time.August, Day: 1}},
+	} {
+		if err := repo.CreateBook(ctx, book); err != nil {
+			t.Fatalf("CreateBook() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		// every word is a stop word, so the search matches by prefix
+		{"the who", []string{"The Who"}},
+		{"dune", []string{"Dune"}},
+		{"the dune", []string{"Dune"}},
+	}
+	for _, tt := range tests {
+		books, total, err := repo.FetchAllBook(ctx, 1, 10, models.BookFilter{
+			Search:     tt.search,
+			SearchMode: models.SearchModeFullText,
+		})
+		if err != nil {
+			t.Fatalf("FetchAllBook(%q) error = %v", tt.search, err)
+		}
+		var got []string
+		for _, book := range books {
+			got = append(got, book.Title)
+		}
+		if total != len(tt.want) || len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Errorf("FetchAllBook(%q) = %v (total %d), want %v", tt.search, got, total, tt.want)
+		}
+	}
+}
+
+func TestKnownAuthors(t *testing.T) {
+	store := NewStore()
+	repo, txm := NewBookRepository(store), NewTxManager(store)
+	ctx := context.Background()
+	book := func(isbn, author string) *models.Book {
+		return &models.Book{Title: "Title", Author: author, ISBN: isbn, Pages: 100, Published: models.Date{Year: 2000, Month: time.January, Day: 1}}
+	}
+	known := func(name string) bool {
+		t.Helper()
+		ok, _, err := repo.MatchAuthor(ctx, name, 3)
+		if err != nil {
+			t.Fatalf("MatchAuthor(%q) error = %v", name, err)
+		}
+		return ok
+	}
+
+	errRollback := errors.New("rollback")
+	err := txm.WithTx(ctx, func(repos repositories.Repositories) error {
+		if err := repos.Books.CreateBook(ctx, book("9780306406157", "Frank Herbert")); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("WithTx() error = %v, want the rollback", err)
+	}
+	if known("Frank Herbert") {
+		t.Error("the author of a rolled back book is known")
+	}
+
+	// every write path records the author
+	created := book("9780306406157", "Frank Herbert")
+	if err := repo.CreateBook(ctx, created); err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+	if _, _, err := repo.UpsertBooks(ctx, []*models.Book{book("9780140449136", "Homer")}); err != nil {
+		t.Fatalf("UpsertBooks() error = %v", err)
+	}
+	if _, _, err := repo.ImportBooks(ctx, []*models.Book{book("9780441013593", "Octavia E. Butler")}, nil); err != nil {
+		t.Fatalf("ImportBooks() error = %v", err)
+	}
+	if _, err := repo.DeleteBook(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	for _, name := range []string{"Frank Herbert", "Homer", "Octavia E. Butler"} {
+		if !known(name) {
+			t.Errorf("author %q is not known", name)
+		}
+	}
+	if ok, _, err := repo.MatchAuthor(tenant.WithTenant(ctx, "other"), "Homer", 3); err != nil || ok {
+		t.Errorf("MatchAuthor() in another tenant = %v, %v; want false", ok, err)
+	}
+
+	for _, want := range []bool{true, false} {
+		if got, err := repo.AddAuthor(ctx, "Ursula K. Le Guin"); err != nil || got != want {
+			t.Errorf("AddAuthor() = %v, %v; want %v", got, err, want)
+		}
+	}
+	ok, similar, err := repo.MatchAuthor(ctx, "Ursula K. Le Gwin", 3)
+	if err != nil || ok || len(similar) != 1 || similar[0] != "Ursula K. Le Guin" {
+		t.Errorf("MatchAuthor() = %v, %v, %v; want the added author suggested", ok, similar, err)
+	}
+}
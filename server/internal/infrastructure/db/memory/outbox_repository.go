@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"bf-api/internal/domain/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OutboxRepository is only available through TxManager: enqueuing outside
+// the write's transaction would defeat its purpose.
+type OutboxRepository struct {
+	conn
+}
+
+type outboxRow struct {
+	id        int64
+	payload   []byte // the event as the relay will publish it
+	createdAt time.Time
+	attempts  int
+	sentAt    *time.Time
+	lastError string
+}
+
+func (r *OutboxRepository) Enqueue(ctx context.Context, event models.BookEvent) error {
+	defer r.lock()()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+
+	r.store.lastOutboxID++
+	n := len(r.store.outbox)
+	r.onRollback(func() { r.store.outbox = r.store.outbox[:n] })
+	r.store.outbox = append(r.store.outbox, outboxRow{
+		id:        r.store.lastOutboxID,
+		payload:   payload,
+		createdAt: event.Timestamp,
+	})
+
+	return nil
+}
+
+// ClaimPending returns up to limit unsent entries, oldest first. The
+// transaction holds the whole store, so no other relay sees them meanwhile.
+func (r *OutboxRepository) ClaimPending(ctx context.Context, limit int) ([]*models.OutboxEntry, error) {
+	defer r.lock()()
+
+	var entries []*models.OutboxEntry
+	for _, row := range r.store.outbox {
+		if len(entries) == limit {
+			break
+		}
+		if row.sentAt != nil {
+			continue
+		}
+		entry := models.OutboxEntry{ID: row.id, CreatedAt: row.createdAt, Attempts: row.attempts}
+		if err := json.Unmarshal(row.payload, &entry.Event); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox entry %d: %w", entry.ID, err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, nil
+}
+
+func (r *OutboxRepository) MarkSent(ctx context.Context, id int64) error {
+	defer r.lock()()
+	r.update(id, func(row *outboxRow) {
+		now := r.now()
+		row.sentAt = &now
+		row.attempts++
+		row.lastError = ""
+	})
+	return nil
+}
+
+// MarkFailed records a failed delivery attempt; the entry stays pending.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	defer r.lock()()
+	r.update(id, func(row *outboxRow) {
+		row.attempts++
+		row.lastError = reason
+	})
+	return nil
+}
+
+// update applies fn to the entry with id, if there is one.
+func (r *OutboxRepository) update(id int64, fn func(row *outboxRow)) {
+	for i := range r.store.outbox {
+		if r.store.outbox[i].id != id {
+			continue
+		}
+		prev := r.store.outbox[i]
+		r.onRollback(func() { r.store.outbox[i] = prev })
+		fn(&r.store.outbox[i])
+		return
+	}
+}
@@ -0,0 +1,174 @@
+// Package memory keeps books, audit entries and outbox events in process
+// memory. It mirrors the postgres repositories, including their error
+// semantics, tenant scoping and ordering, for tests and local demos that run
+// without a database. Nothing is persisted across restarts.
+package memory
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store holds the data of the in-memory repositories. Repositories built on
+// the same Store share it, like repositories on the same database.
+type Store struct {
+	mu sync.Mutex
+
+	books           map[int]bookRow
+	idempotencyKeys map[idempotencyKeyID]idempotencyKey
+	authors         map[authorID]bool
+	audit           []auditRow
+	outbox          []outboxRow
+
+	// like database sequences, IDs are never reused, even when the
+	// transaction that took one rolls back
+	lastBookID   int
+	lastAuditID  int
+	lastOutboxID int64
+}
+
+func NewStore() *Store {
+	return &Store{
+		books:           make(map[int]bookRow),
+		idempotencyKeys: make(map[idempotencyKeyID]idempotencyKey),
+		authors:         make(map[authorID]bool),
+	}
+}
+
+type bookRow struct {
+	book      models.Book
+	tenantID  string
+	deletedAt *time.Time
+}
+
+// idempotencyKeyID scopes a key to its tenant, so tenants may pick the same
+// ones.
+type idempotencyKeyID struct {
+	tenantID string
+	key      string
+}
+
+// authorID is a known author of a tenant.
+type authorID struct {
+	tenantID string
+	name     string
+}
+
+type idempotencyKey struct {
+	bookID    int
+	expiresAt time.Time
+}
+
+// conn is what a repository works on: the store directly, or a transaction
+// opened by TxManager, which holds the store's lock until it ends.
+type conn struct {
+	store *Store
+	tx    *txLog
+}
+
+// txLog records how to undo the changes of a transaction.
+type txLog struct {
+	now  time.Time
+	undo []func()
+}
+
+// lock locks the store for a single method call and returns the function
+// releasing it. Inside a transaction the lock is already held.
+func (c conn) lock() func() {
+	if c.tx != nil {
+		return func() {}
+	}
+	c.store.mu.Lock()
+	return c.store.mu.Unlock
+}
+
+// now is the timestamp of writes; like NOW() it stays the same for a whole
+// transaction.
+func (c conn) now() time.Time {
+	if c.tx != nil {
+		return c.tx.now
+	}
+	return timestamp()
+}
+
+// timestamp returns the current time in UTC, as the postgres repositories
+// return times, rounded to the microsecond precision of timestamptz.
+func timestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// onRollback registers undo to run if the surrounding transaction rolls
+// back. Outside a transaction every change is final.
+func (c conn) onRollback(undo func()) {
+	if c.tx != nil {
+		c.tx.undo = append(c.tx.undo, undo)
+	}
+}
+
+// putBook stores row under its book's ID and records its author as known,
+// like the trigger on the books table.
+func (c conn) putBook(row bookRow) {
+	id := row.book.ID
+	if prev, ok := c.store.books[id]; ok {
+		c.onRollback(func() { c.store.books[id] = prev })
+	} else {
+		c.onRollback(func() { delete(c.store.books, id) })
+	}
+	c.store.books[id] = row
+	c.putAuthor(authorID{tenantID: row.tenantID, name: row.book.Author})
+}
+
+// putAuthor records author as known and reports whether it was new.
+func (c conn) putAuthor(author authorID) bool {
+	if c.store.authors[author] {
+		return false
+	}
+	c.onRollback(func() { delete(c.store.authors, author) })
+	c.store.authors[author] = true
+	return true
+}
+
+// TxManager runs service operations in a single transaction. Transactions
+// are serialized: one holds the store until it commits or rolls back.
+type TxManager struct {
+	store    *Store
+	bookOpts []BookRepositoryOption
+}
+
+// NewTxManager returns a TxManager whose transaction-scoped book repositories
+// are built with opts, matching the ones passed to NewBookRepository.
+func NewTxManager(store *Store, opts ...BookRepositoryOption) repositories.TxManager {
+	return &TxManager{store: store, bookOpts: opts}
+}
+
+// WithTx hands fn repositories bound to a new transaction and keeps their
+// changes when fn returns nil. Any error from fn rolls everything back. fn
+// must only use the repositories it is given; the others would wait for
+// the transaction to end.
+func (m *TxManager) WithTx(ctx context.Context, fn func(repos repositories.Repositories) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	tx := &txLog{now: timestamp()}
+	c := conn{store: m.store, tx: tx}
+	repos := repositories.Repositories{
+		Books:  newBookRepository(c, m.bookOpts...),
+		Audit:  &AuditRepository{conn: c},
+		Outbox: &OutboxRepository{conn: c},
+	}
+	if err := fn(repos); err != nil {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/tenant"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository struct {
+	db dbtx
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) repositories.AuditRepository {
+	return &AuditRepository{db: poolDB{pool}}
+}
+
+// Record appends entry to the audit log and fills in its ID.
+func (r *AuditRepository) Record(ctx context.Context, entry *models.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor, action, book_id, before, after, trace_id, created_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		entry.Actor,
+		entry.Action,
+		entry.BookID,
+		nullJSON(entry.Before),
+		nullJSON(entry.After),
+		entry.TraceID,
+		entry.CreatedAt,
+		tenant.FromContext(ctx),
+	).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListByBook returns every audit entry for a book of the tenant, oldest first.
+func (r *AuditRepository) ListByBook(ctx context.Context, bookID int) ([]*models.AuditEntry, error) {
+	query := `
+		SELECT id, actor, action, book_id, before, after, trace_id, created_at
+		FROM audit_log
+		WHERE book_id = $1 AND tenant_id = $2
+		ORDER BY id ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, bookID, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.BookID,
+			&entry.Before,
+			&entry.After,
+			&entry.TraceID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// nullJSON stores an absent snapshot as NULL rather than the JSON literal.
+func nullJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return raw
+}
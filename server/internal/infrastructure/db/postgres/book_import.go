@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/tenant"
+	"bf-api/internal/infrastructure/fieldcrypt"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Import modes select how ImportBooks writes rows. Both write every chunk in
+// one transaction, so an import is applied in full or not at all; they differ
+// in how ISBNs that already belong to an active book are treated.
+const (
+	// ImportModeCopy streams each chunk with COPY, the fastest way to load
+	// many rows. COPY cannot skip rows, so a single ISBN already in use
+	// fails the whole import with repositories.ErrDuplicateISBN.
+	ImportModeCopy = "copy"
+	// ImportModeBatch sends each chunk as a pipelined batch of INSERT ... ON
+	// CONFLICT DO NOTHING statements. Books whose ISBN is already in use are
+	// skipped and reported; any other error fails the whole import.
+	ImportModeBatch = "batch"
+
+	// DefaultImportBatchSize is the number of books written per chunk
+	// unless configured otherwise with WithImport.
+	DefaultImportBatchSize = 1000
+)
+
+// ImportModes are the accepted import modes.
+var ImportModes = []string{ImportModeCopy, ImportModeBatch}
+
+// WithImport sets how ImportBooks writes rows and how many books it writes
+// per chunk. An empty mode keeps ImportModeCopy and a batchSize below 1
+// keeps DefaultImportBatchSize.
+func WithImport(mode string, batchSize int) BookRepositoryOption {
+	return func(r *BookRepository) {
+		if mode != "" {
+			r.importMode = mode
+		}
+		if batchSize > 0 {
+			r.importBatchSize = batchSize
+		}
+	}
+}
+
+// importColumns are the books columns an import writes; the rest keep their
+// defaults.
+var importColumns = []string{"title", "author", "published", "isbn", "pages", "acquisition_cost", "supplier_notes", "tenant_id"}
+
+// ImportBooks inserts books in chunks of the configured batch size within one
+// transaction, filling in each inserted book from its new row. progress, when
+// not nil, is called after every chunk with the number of books processed so
+// far. How existing ISBNs are handled depends on the import mode.
+func (r *BookRepository) ImportBooks(ctx context.Context, books []*models.Book, progress func(processed int)) ([]*models.Book, []string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	importChunk := copyBooks
+	if r.importMode == ImportModeBatch {
+		importChunk = batchInsertBooks
+	}
+
+	inserted := make([]*models.Book, 0, len(books))
+	var skipped []string
+	for start := 0; start < len(books); start += r.importBatchSize {
+		chunk := books[start:min(start+r.importBatchSize, len(books))]
+		chunkInserted, chunkSkipped, err := importChunk(ctx, tx, chunk)
+		if err != nil {
+			return nil, nil, err
+		}
+		inserted = append(inserted, chunkInserted...)
+		skipped = append(skipped, chunkSkipped...)
+
+		if progress != nil {
+			progress(start + len(chunk))
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return inserted, skipped, nil
+}
+
+// copyBooks writes chunk with COPY and reads the new rows back, since COPY
+// cannot return them.
+func copyBooks(ctx context.Context, tx pgx.Tx, chunk []*models.Book) ([]*models.Book, []string, error) {
+	tenantID := tenant.FromContext(ctx)
+	isbns := make([]string, len(chunk))
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"books"}, importColumns, pgx.CopyFromSlice(len(chunk), func(i int) ([]any, error) {
+		book := chunk[i]
+		isbns[i] = book.ISBN
+		return []any{book.Title, book.Author, book.Published, book.ISBN, book.Pages, fieldcrypt.EncryptedString(book.AcquisitionCost), fieldcrypt.EncryptedString(book.SupplierNotes), tenantID}, nil
+	}))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation, only raised by active books
+			return nil, nil, fmt.Errorf("%w: %s", repositories.ErrDuplicateISBN, pgErr.Detail)
+		}
+		return nil, nil, fmt.Errorf("failed to copy books: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT `+bookColumns+`
+		FROM books
+		WHERE isbn = ANY($1) AND tenant_id = $2 AND deleted_at IS NULL
+		ORDER BY id
+	`, isbns, tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read imported books: %w", err)
+	}
+
+	inserted, err := scanBooks(rows, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inserted, nil, nil
+}
+
+// batchInsertBooks writes chunk as one pipelined batch, skipping books whose
+// ISBN is already in use.
+func batchInsertBooks(ctx context.Context, tx pgx.Tx, chunk []*models.Book) ([]*models.Book, []string, error) {
+	query := `
+		INSERT INTO books (
+			title,
+			author,
+			published,
+			isbn,
+			pages,
+			acquisition_cost,
+			supplier_notes,
+			tenant_id,
+			created_at,
+			updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()
+		)
+		ON CONFLICT (tenant_id, isbn) WHERE deleted_at IS NULL DO NOTHING
+		RETURNING ` + bookColumns + `
+	`
+
+	tenantID := tenant.FromContext(ctx)
+	batch := &pgx.Batch{}
+	for _, book := range chunk {
+		batch.Queue(query,
+			book.Title,
+			book.Author,
+			book.Published,
+			book.ISBN,
+			book.Pages,
+			fieldcrypt.EncryptedString(book.AcquisitionCost),
+			fieldcrypt.EncryptedString(book.SupplierNotes),
+			tenantID,
+		)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	defer results.Close()
+
+	var inserted []*models.Book
+	var skipped []string
+	for _, book := range chunk {
+		err := results.QueryRow().Scan(bookDest(book)...)
+		switch {
+		case err == nil:
+			inserted = append(inserted, book)
+		case errors.Is(err, pgx.ErrNoRows):
+			skipped = append(skipped, book.ISBN)
+		default:
+			return nil, nil, fmt.Errorf("failed to insert book %s: %w", book.ISBN, err)
+		}
+	}
+
+	if err := results.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to insert books: %w", err)
+	}
+	return inserted, skipped, nil
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func BenchmarkImportBooks(b *testing.B) {
+	pool := newTestPool(b)
+	ctx := context.Background()
+
+	for _, mode := range ImportModes {
+		b.Run(mode, func(b *testing.B) {
+			repo := NewBookRepository(pool, WithImport(mode, DefaultImportBatchSize))
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if _, err := pool.Exec(ctx, "TRUNCATE books CASCADE"); err != nil {
+					b.Fatalf("failed to empty books: %v", err)
+				}
+				books := testBooks(10000)
+				b.StartTimer()
+
+				inserted, _, err := repo.ImportBooks(ctx, books, nil)
+				if err != nil {
+					b.Fatalf("ImportBooks() error = %v", err)
+				}
+				if len(inserted) != len(books) {
+					b.Fatalf("ImportBooks() inserted %d books, want %d", len(inserted), len(books))
+				}
+			}
+		})
+	}
+}
+
+func TestImportBooksModes(t *testing.T) {
+	for _, mode := range ImportModes {
+		t.Run(mode, func(t *testing.T) {
+			repo := NewBookRepository(newTestPool(t), WithImport(mode, 3))
+			ctx := context.Background()
+
+			var progress []int
+			books := testBooks(7)
+			inserted, skipped, err := repo.ImportBooks(ctx, books, func(processed int) {
+				progress = append(progress, processed)
+			})
+			if err != nil {
+				t.Fatalf("ImportBooks() error = %v", err)
+			}
+			if len(inserted) != len(books) || len(skipped) != 0 {
+				t.Errorf("ImportBooks() inserted %d and skipped %v, want %d and none", len(inserted), skipped, len(books))
+			}
+			if got := fmt.Sprint(progress); got != "[3 6 7]" {
+				t.Errorf("progress = %s, want [3 6 7]", got)
+			}
+		})
+	}
+}
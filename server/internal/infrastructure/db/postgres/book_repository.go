@@ -1,11 +1,15 @@
 package postgres
 
 import (
+	"bf-api/internal/domain/filterexpr"
 	"bf-api/internal/domain/models"
 	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/tenant"
+	"bf-api/internal/infrastructure/fieldcrypt"
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -14,14 +18,117 @@ import (
 )
 
 type BookRepository struct {
-	pool *pgxpool.Pool
+	db            dbtx
+	separateCount bool
+
+	importMode      string
+	importBatchSize int
+}
+
+type BookRepositoryOption func(*BookRepository)
+
+// WithSeparateCount makes FetchAllBook count matches with a separate query
+// instead of COUNT(*) OVER(). The window function saves a round trip but
+// must visit every matching row, which can cost more on very large results.
+func WithSeparateCount(enabled bool) BookRepositoryOption {
+	return func(r *BookRepository) {
+		r.separateCount = enabled
+	}
+}
+
+func NewBookRepository(pool *pgxpool.Pool, opts ...BookRepositoryOption) repositories.BookRepository {
+	return newBookRepository(poolDB{pool}, opts...)
+}
+
+func newBookRepository(db dbtx, opts ...BookRepositoryOption) *BookRepository {
+	r := &BookRepository{db: db, importMode: ImportModeCopy, importBatchSize: DefaultImportBatchSize}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-func NewBookRepository(pool *pgxpool.Pool) repositories.BookRepository {
-	return &BookRepository{pool: pool}
+// querier is satisfied by both the pool and a transaction so queries can be
+// shared between the two.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// dbtx is what a repository runs its queries on: the pool, or a transaction
+// opened by TxManager. Begin on a transaction starts a savepoint, so methods
+// that open their own transaction still nest inside an outer one.
+type dbtx interface {
+	querier
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 }
 
 func (r *BookRepository) CreateBook(ctx context.Context, book *models.Book) error {
+	return insertBook(ctx, r.db, book)
+}
+
+// CreateBookIdempotent inserts book unless key was already used within its
+// TTL, in which case book is filled with the originally created record and
+// replayed is true. Requests sharing a key are serialized by an advisory lock.
+// Keys are scoped to the tenant, so tenants may pick the same ones.
+func (r *BookRepository) CreateBookIdempotent(ctx context.Context, key string, ttl time.Duration, book *models.Book) (bool, error) {
+	tenantID := tenant.FromContext(ctx)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))", tenantID, key); err != nil {
+		return false, fmt.Errorf("failed to lock idempotency key: %w", err)
+	}
+
+	var bookID int
+	err = tx.QueryRow(ctx, `
+		SELECT k.book_id
+		FROM idempotency_keys k
+		JOIN books b ON b.id = k.book_id
+		WHERE k.tenant_id = $1 AND k.key = $2 AND k.expires_at > NOW() AND b.deleted_at IS NULL
+	`, tenantID, key).Scan(&bookID)
+	switch {
+	case err == nil:
+		existing, err := getBook(ctx, tx, bookID)
+		if err != nil {
+			return false, err
+		}
+		*book = *existing
+		return true, tx.Commit(ctx)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return false, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+
+	// a stale key, or one whose book was deleted, may still occupy the row
+	if _, err := tx.Exec(ctx, "DELETE FROM idempotency_keys WHERE tenant_id = $1 AND key = $2", tenantID, key); err != nil {
+		return false, fmt.Errorf("failed to clear expired idempotency key: %w", err)
+	}
+
+	if err := insertBook(ctx, tx, book); err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO idempotency_keys (tenant_id, key, book_id, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+	`, tenantID, key, book.ID, ttl.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return false, nil
+}
+
+func insertBook(ctx context.Context, q querier, book *models.Book) error {
 	query := `
 		INSERT INTO books (
 			title,
@@ -29,22 +136,29 @@ func (r *BookRepository) CreateBook(ctx context.Context, book *models.Book) erro
 			published,
 			isbn,
 			pages,
+			acquisition_cost,
+			supplier_notes,
+			tenant_id,
 			created_at,
 			updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, NOW(), NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()
 		)
-		RETURNING id, created_at, updated_at
+		RETURNING id, stock, created_at, updated_at
 	`
 
-	err := r.pool.QueryRow(ctx, query,
+	err := q.QueryRow(ctx, query,
 		book.Title,
 		book.Author,
 		book.Published,
 		book.ISBN,
 		book.Pages,
+		fieldcrypt.EncryptedString(book.AcquisitionCost),
+		fieldcrypt.EncryptedString(book.SupplierNotes),
+		tenant.FromContext(ctx),
 	).Scan(
 		&book.ID,
+		&book.Stock,
 		&book.CreatedAt,
 		&book.UpdatedAt,
 	)
@@ -53,7 +167,7 @@ func (r *BookRepository) CreateBook(ctx context.Context, book *models.Book) erro
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			switch pgErr.Code {
-			case "23505": // unique_violation
+			case "23505": // unique_violation, only raised by active books
 				return repositories.ErrDuplicateISBN
 			case "23503": // foreign_key_violation
 				return fmt.Errorf("%w: %s", repositories.ErrInvalidReference, pgErr.Message)
@@ -65,26 +179,115 @@ func (r *BookRepository) CreateBook(ctx context.Context, book *models.Book) erro
 	return nil
 }
 
-func (r *BookRepository) GetByBookID(ctx context.Context, id int) (*models.Book, error) {
-	query := `
-	SELECT
-		id, title, author, published, isbn, pages, created_at, updated_at
-	FROM books
-	WHERE id = $1
-	`
-	var book models.Book
-	var pubDate time.Time
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+// bookColumns lists the books columns read back into a models.Book, in the
+// order bookDest expects them.
+const bookColumns = "id, title, author, published, isbn, pages, stock, created_at, updated_at, acquisition_cost, supplier_notes, cover_key, cover_url"
+
+// bookDest returns the scan destinations for bookColumns.
+func bookDest(book *models.Book) []any {
+	return []any{
 		&book.ID,
 		&book.Title,
 		&book.Author,
-		&pubDate,
+		&book.Published,
 		&book.ISBN,
 		&book.Pages,
+		&book.Stock,
 		&book.CreatedAt,
 		&book.UpdatedAt,
-	)
-	book.Published = pubDate.Format("2006-01-02")
+		(*fieldcrypt.EncryptedString)(&book.AcquisitionCost),
+		(*fieldcrypt.EncryptedString)(&book.SupplierNotes),
+		&book.CoverKey,
+		&book.CoverURL,
+	}
+}
+
+func (r *BookRepository) GetByBookID(ctx context.Context, id int) (*models.Book, error) {
+	return getBook(ctx, r.db, id)
+}
+
+func (r *BookRepository) GetByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	query := `
+	SELECT ` + bookColumns + `
+	FROM books
+	WHERE isbn = $1 AND tenant_id = $2 AND deleted_at IS NULL
+	`
+	var book models.Book
+	err := r.db.QueryRow(ctx, query, isbn, tenant.FromContext(ctx)).Scan(bookDest(&book)...)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repositories.ErrBookNotFound
+		}
+		return nil, fmt.Errorf("failed to get book by isbn: %w", err)
+	}
+
+	return &book, nil
+}
+
+// GetByIDs returns the active books among ids in no particular order;
+// missing IDs are simply absent.
+func (r *BookRepository) GetByIDs(ctx context.Context, ids []int) ([]*models.Book, error) {
+	query := `
+	SELECT ` + bookColumns + `
+	FROM books
+	WHERE id = ANY($1) AND tenant_id = $2 AND deleted_at IS NULL
+	`
+	rows, err := r.db.Query(ctx, query, ids, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get books by ids: %w", err)
+	}
+
+	return scanBooks(rows, false)
+}
+
+// EachBook calls fn for every active book, in ID order, reading rows as fn
+// consumes them rather than loading them all first. It stops at the first
+// error fn returns.
+func (r *BookRepository) EachBook(ctx context.Context, fn func(*models.Book) error) error {
+	query := `
+	SELECT ` + bookColumns + `
+	FROM books
+	WHERE tenant_id = $1 AND deleted_at IS NULL
+	ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, tenant.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to read books: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var book models.Book
+		if err := rows.Scan(bookDest(&book)...); err != nil {
+			return fmt.Errorf("failed to scan book: %w", err)
+		}
+		if err := fn(&book); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+	return nil
+}
+
+// GetByBookIDForUpdate reads an active book and locks its row until the
+// surrounding transaction ends. Outside a TxManager transaction the lock is
+// released immediately.
+func (r *BookRepository) GetByBookIDForUpdate(ctx context.Context, id int) (*models.Book, error) {
+	return getBook(ctx, r.db, id, "FOR UPDATE")
+}
+
+func getBook(ctx context.Context, q querier, id int, lock ...string) (*models.Book, error) {
+	query := `
+	SELECT ` + bookColumns + `
+	FROM books
+	WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+	` + strings.Join(lock, " ")
+	var book models.Book
+	err := q.QueryRow(ctx, query, id, tenant.FromContext(ctx)).Scan(bookDest(&book)...)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -96,54 +299,264 @@ func (r *BookRepository) GetByBookID(ctx context.Context, id int) (*models.Book,
 	return &book, nil
 }
 
-func (r *BookRepository) FetchAllBook(ctx context.Context, page, pageSize int) ([]*models.Book, int, error) {
-	var total int
-	countQuery := `SELECT COUNT(*) FROM books`
-	err := r.pool.QueryRow(ctx, countQuery).Scan(&total)
+func (r *BookRepository) GetBookMeta(ctx context.Context, id int) (*models.BookMeta, error) {
+	meta := models.BookMeta{ID: id}
+	err := r.db.QueryRow(ctx,
+		"SELECT updated_at FROM books WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL",
+		id, tenant.FromContext(ctx),
+	).Scan(&meta.UpdatedAt)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count books: %s", err.Error())
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repositories.ErrBookNotFound
+		}
+		return nil, fmt.Errorf("failed to get book meta: %w", err)
+	}
+
+	return &meta, nil
+}
+
+// ISBNTaken reports whether an active book of the tenant other than
+// excludeID has isbn.
+func (r *BookRepository) ISBNTaken(ctx context.Context, isbn string, excludeID int) (bool, error) {
+	var taken bool
+	err := r.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM books WHERE isbn = $1 AND id <> $2 AND tenant_id = $3 AND deleted_at IS NULL)",
+		isbn, excludeID, tenant.FromContext(ctx),
+	).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("failed to check isbn: %w", err)
 	}
 
+	return taken, nil
+}
+
+// FindByTitleAuthor returns up to limit active books of the tenant other than
+// excludeID whose title and author match, ignoring case, oldest first.
+func (r *BookRepository) FindByTitleAuthor(ctx context.Context, title, author string, excludeID, limit int) ([]*models.Book, error) {
 	query := `
-		SELECT
-			id, title, author, published, isbn, pages, created_at, updated_at
-		FROM books
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+	SELECT ` + bookColumns + `
+	FROM books
+	WHERE lower(title) = lower($1) AND lower(author) = lower($2) AND id <> $3 AND tenant_id = $4 AND deleted_at IS NULL
+	ORDER BY id
+	LIMIT $5
 	`
+	rows, err := r.db.Query(ctx, query, title, author, excludeID, tenant.FromContext(ctx), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find books by title and author: %w", err)
+	}
+
+	return scanBooks(rows, false)
+}
+
+func (r *BookRepository) FetchAllBook(ctx context.Context, page, pageSize int, filter models.BookFilter) ([]*models.Book, int, error) {
+	var total int
+	totalColumn := ""
+	if r.separateCount {
+		var err error
+		if total, err = r.CountBooks(ctx, filter); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		totalColumn = ", COUNT(*) OVER() AS total_count"
+	}
+
+	where, args, rank := buildBookFilter(tenant.FromContext(ctx), filter)
+
+	// id breaks ties between books created together, e.g. by a bulk upsert,
+	// so pages neither repeat nor skip them
+	relevance, orderBy := "NULL::real", "created_at DESC, id DESC"
+	if rank != "" {
+		relevance, orderBy = rank, "relevance DESC, created_at DESC, id DESC"
+	}
+	if filter.UpdatedSince != nil {
+		// sync consumers checkpoint on the last updated_at they have seen
+		orderBy = "updated_at ASC, id ASC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s,
+			%s AS relevance, deleted_at IS NOT NULL AS deleted%s
+		FROM books%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, bookColumns, relevance, totalColumn, where, orderBy, len(args)+1, len(args)+2)
+
+	var extra []any
+	if !r.separateCount {
+		extra = append(extra, &total)
+	}
 
 	offset := (page - 1) * pageSize
-	rows, err := r.pool.Query(ctx, query, pageSize, offset)
+	rows, err := r.db.Query(ctx, query, append(args, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to fetch books: %w", err)
 	}
+	books, err := scanBooks(rows, true, extra...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// a page past the end has no rows to carry the window count
+	if !r.separateCount && len(books) == 0 && offset > 0 {
+		if total, err = r.CountBooks(ctx, filter); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return books, total, nil
+}
+
+func (r *BookRepository) CountBooks(ctx context.Context, filter models.BookFilter) (int, error) {
+	where, args, _ := buildBookFilter(tenant.FromContext(ctx), filter)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM books` + where
+	err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count books: %w", err)
+	}
+
+	return total, nil
+}
+
+// scanBooks reads every row of bookColumns, followed by relevance and deleted
+// when listing is set and then any extra per-row columns, and closes rows.
+// extra destinations are overwritten by each row in turn.
+func scanBooks(rows pgx.Rows, listing bool, extra ...any) ([]*models.Book, error) {
 	defer rows.Close()
 
-	var books []*models.Book
+	// never nil, so an empty listing encodes as [] rather than null
+	books := []*models.Book{}
 	for rows.Next() {
 		var book models.Book
-		var pubDate time.Time
-		if err := rows.Scan(
-			&book.ID,
-			&book.Title,
-			&book.Author,
-			&pubDate,
-			&book.ISBN,
-			&book.Pages,
-			&book.CreatedAt,
-			&book.UpdatedAt,
-		); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan book: %w", err)
-		}
-		book.Published = pubDate.Format("2006-01-02")
+		dest := bookDest(&book)
+		if listing {
+			dest = append(dest, &book.Relevance, &book.Deleted)
+		}
+		dest = append(dest, extra...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan book: %w", err)
+		}
 		books = append(books, &book)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("rows error: %w", err)
+		return nil, fmt.Errorf("rows error: %w", err)
 	}
 
-	return books, total, nil
+	return books, nil
+}
+
+// likeEscaper escapes LIKE wildcards so user input only ever matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// buildBookFilter renders the filter, scoped to tenantID, as a WHERE clause
+// with numbered placeholders; only the argument list ever carries user input.
+// For full-text searches it also returns the ts_rank expression to order by.
+func buildBookFilter(tenantID string, filter models.BookFilter) (string, []interface{}, string) {
+	var conditions []string
+	switch filter.Deleted {
+	case models.DeletedFilterAll:
+	case models.DeletedFilterOnly:
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	default:
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	var args []interface{}
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	add("tenant_id = $%d", tenantID)
+
+	var rank string
+	if filter.Search != "" {
+		switch filter.SearchMode {
+		case models.SearchModePrefix:
+			add("(title ILIKE $%[1]d OR author ILIKE $%[1]d)", likeEscaper.Replace(filter.Search)+"%")
+		default:
+			// a search of stop words only parses to an empty tsquery, which
+			// matches nothing, so it falls back to the prefix match
+			args = append(args, filter.Search, likeEscaper.Replace(filter.Search)+"%")
+			query := fmt.Sprintf("websearch_to_tsquery('english', $%d)", len(args)-1)
+			conditions = append(conditions, fmt.Sprintf(
+				"(search_vector @@ %[1]s OR (numnode(%[1]s) = 0 AND (title ILIKE $%[2]d OR author ILIKE $%[2]d)))",
+				query, len(args)))
+			rank = "ts_rank(search_vector, " + query + ")"
+		}
+	}
+	if filter.AuthorExact != "" {
+		add("author = $%d", filter.AuthorExact)
+	}
+	if filter.CreatedAfter != nil {
+		add("created_at >= $%d", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		add("created_at < $%d", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		add("updated_at >= $%d", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		add("updated_at < $%d", *filter.UpdatedBefore)
+	}
+	if filter.UpdatedSince != nil {
+		add("updated_at > $%d", *filter.UpdatedSince)
+	}
+	if filter.SnapshotAt != nil {
+		add("created_at <= $%d", *filter.SnapshotAt)
+	}
+	if filter.Expr != nil {
+		conditions = append(conditions, compileFilterExpr(filter.Expr, func(value any) string {
+			args = append(args, value)
+			return fmt.Sprintf("$%d", len(args))
+		}))
+	}
+
+	if len(conditions) == 0 {
+		return "", args, rank
+	}
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args, rank
+}
+
+// filterColumns and filterOps map the fields and operators of filter
+// expressions to SQL; nothing else from an expression reaches the query text.
+var (
+	filterColumns = map[filterexpr.Field]string{
+		filterexpr.FieldTitle:     "title",
+		filterexpr.FieldAuthor:    "author",
+		filterexpr.FieldISBN:      "isbn",
+		filterexpr.FieldPages:     "pages",
+		filterexpr.FieldStock:     "stock",
+		filterexpr.FieldPublished: "published",
+	}
+	filterOps = map[filterexpr.Op]string{
+		filterexpr.OpEq: "=",
+		filterexpr.OpNe: "<>",
+		filterexpr.OpGt: ">",
+		filterexpr.OpGe: ">=",
+		filterexpr.OpLt: "<",
+		filterexpr.OpLe: "<=",
+	}
+)
+
+// compileFilterExpr renders expr as a parenthesized condition, passing every
+// value through placeholder, which returns the parameter to use in its place.
+func compileFilterExpr(expr filterexpr.Expr, placeholder func(value any) string) string {
+	switch e := expr.(type) {
+	case *filterexpr.Group:
+		terms := make([]string, len(e.Terms))
+		for i, term := range e.Terms {
+			terms[i] = compileFilterExpr(term, placeholder)
+		}
+		return "(" + strings.Join(terms, " "+string(e.Op)+" ") + ")"
+	case *filterexpr.Condition:
+		return "(" + filterColumns[e.Field] + " " + filterOps[e.Op] + " " + placeholder(e.Value) + ")"
+	}
+	panic(fmt.Sprintf("unknown filter expression %T", expr))
 }
 
 func (r *BookRepository) UpdateBook(ctx context.Context, book *models.Book) error {
@@ -155,48 +568,322 @@ func (r *BookRepository) UpdateBook(ctx context.Context, book *models.Book) erro
 			published = $3,
 			isbn = $4,
 			pages = $5,
+			acquisition_cost = $6,
+			supplier_notes = $7,
 			updated_at = NOW()
-		WHERE id = $6
-		RETURNING updated_at
+		WHERE id = $8 AND tenant_id = $9 AND deleted_at IS NULL
+		RETURNING ` + bookColumns + `
 	`
 
-	err := r.pool.QueryRow(ctx, query,
+	// scan the stored row back so triggers or defaults are reflected
+	err := r.db.QueryRow(ctx, query,
 		book.Title,
 		book.Author,
 		book.Published,
 		book.ISBN,
 		book.Pages,
+		fieldcrypt.EncryptedString(book.AcquisitionCost),
+		fieldcrypt.EncryptedString(book.SupplierNotes),
 		book.ID,
-	).Scan(&book.UpdatedAt)
+		tenant.FromContext(ctx),
+	).Scan(bookDest(book)...)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return repositories.ErrBookNotFound
 		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			return repositories.ErrDuplicateISBN
+		}
 		return fmt.Errorf("failed to update book: %w", err)
 	}
 
 	return nil
 }
-func (r *BookRepository) DeleteBook(ctx context.Context, id int) error {
-	tx, err := r.pool.Begin(ctx)
+
+// UpsertBooks inserts each book or, when an active book already has its ISBN,
+// overwrites the stored title, author, published date and pages, and the
+// confidential fields when given. All books are written in one transaction
+// and filled with the stored rows. ISBNs must be unique within books, and
+// only conflict within the tenant.
+func (r *BookRepository) UpsertBooks(ctx context.Context, books []*models.Book) ([]*models.Book, []*models.Book, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO books (
+			title,
+			author,
+			published,
+			isbn,
+			pages,
+			acquisition_cost,
+			supplier_notes,
+			tenant_id,
+			created_at,
+			updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()
+		)
+		ON CONFLICT (tenant_id, isbn) WHERE deleted_at IS NULL DO UPDATE SET
+			title = EXCLUDED.title,
+			author = EXCLUDED.author,
+			published = EXCLUDED.published,
+			pages = EXCLUDED.pages,
+			acquisition_cost = COALESCE(EXCLUDED.acquisition_cost, books.acquisition_cost),
+			supplier_notes = COALESCE(EXCLUDED.supplier_notes, books.supplier_notes),
+			updated_at = NOW()
+		RETURNING ` + bookColumns + `, xmax = 0 AS inserted
+	`
+
+	tenantID := tenant.FromContext(ctx)
+	var inserted, updated []*models.Book
+	for _, book := range books {
+		var wasInserted bool
+		err := tx.QueryRow(ctx, query,
+			book.Title,
+			book.Author,
+			book.Published,
+			book.ISBN,
+			book.Pages,
+			fieldcrypt.EncryptedString(book.AcquisitionCost),
+			fieldcrypt.EncryptedString(book.SupplierNotes),
+			tenantID,
+		).Scan(append(bookDest(book), &wasInserted)...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to upsert book %s: %w", book.ISBN, err)
+		}
+
+		if wasInserted {
+			inserted = append(inserted, book)
+		} else {
+			updated = append(updated, book)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return inserted, updated, nil
+}
+
+// DeleteBook soft-deletes a book by stamping deleted_at and returns the row as
+// it was deleted; deleted books are hidden from every read. The check that
+// the book exists and the delete are one statement, so concurrent deletes of
+// the same book see exactly one success.
+func (r *BookRepository) DeleteBook(ctx context.Context, id int) (*models.Book, error) {
+	query := `
+		UPDATE books
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+		RETURNING ` + bookColumns + `
+	`
+
+	var book models.Book
+	err := r.db.QueryRow(ctx, query, id, tenant.FromContext(ctx)).Scan(bookDest(&book)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repositories.ErrBookNotFound
+		}
+		return nil, fmt.Errorf("failed to delete book: %w", err)
+	}
+
+	return &book, nil
+}
+
+// DeleteBooks soft-deletes every active book in ids in one transaction and
+// returns the books that were actually deleted.
+func (r *BookRepository) DeleteBooks(ctx context.Context, ids []int) ([]*models.Book, error) {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	result, err := tx.Exec(ctx, "DELETE FROM books WHERE id = $1", id)
+	query := `
+		UPDATE books
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = ANY($1) AND tenant_id = $2 AND deleted_at IS NULL
+		RETURNING ` + bookColumns + `
+	`
+
+	rows, err := tx.Query(ctx, query, ids, tenant.FromContext(ctx))
 	if err != nil {
-		return fmt.Errorf("failed to delete book: %w", err)
+		return nil, fmt.Errorf("failed to delete books: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return repositories.ErrBookNotFound
+	books, err := scanBooks(rows, false)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return books, nil
+}
+
+// PurgeDeleted permanently removes books soft-deleted before deletedBefore and
+// returns their IDs.
+func (r *BookRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) ([]int, error) {
+	rows, err := r.db.Query(ctx,
+		"DELETE FROM books WHERE tenant_id = $1 AND deleted_at IS NOT NULL AND deleted_at < $2 RETURNING id",
+		tenant.FromContext(ctx), deletedBefore,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted books: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted books: %w", err)
+	}
+
+	return ids, nil
+}
+
+// RestockBook atomically adds amount to the stock of an active book and
+// returns the updated row.
+func (r *BookRepository) RestockBook(ctx context.Context, id, amount int) (*models.Book, error) {
+	query := `
+		UPDATE books
+		SET stock = stock + $1, updated_at = NOW()
+		WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL
+		RETURNING ` + bookColumns + `
+	`
+
+	var book models.Book
+	err := r.db.QueryRow(ctx, query, amount, id, tenant.FromContext(ctx)).Scan(bookDest(&book)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repositories.ErrBookNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22003" { // numeric_value_out_of_range
+			return nil, fmt.Errorf("%w: stock out of range", repositories.ErrInvalidData)
+		}
+		return nil, fmt.Errorf("failed to restock book: %w", err)
+	}
+
+	return &book, nil
+}
+
+// SetCover records the object key and URL of the cover of an active book and
+// returns the updated row.
+func (r *BookRepository) SetCover(ctx context.Context, id int, key, url string) (*models.Book, error) {
+	query := `
+		UPDATE books
+		SET cover_key = $1, cover_url = $2, updated_at = NOW()
+		WHERE id = $3 AND tenant_id = $4 AND deleted_at IS NULL
+		RETURNING ` + bookColumns + `
+	`
+
+	var book models.Book
+	err := r.db.QueryRow(ctx, query, key, url, id, tenant.FromContext(ctx)).Scan(bookDest(&book)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repositories.ErrBookNotFound
+		}
+		return nil, fmt.Errorf("failed to set book cover: %w", err)
+	}
+
+	return &book, nil
+}
+
+// FetchLowStock returns up to limit active books whose stock is below
+// threshold, lowest stock first.
+func (r *BookRepository) FetchLowStock(ctx context.Context, threshold, limit int) ([]*models.Book, error) {
+	query := `
+		SELECT ` + bookColumns + `
+		FROM books
+		WHERE stock < $1 AND tenant_id = $3 AND deleted_at IS NULL
+		ORDER BY stock ASC, id ASC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, threshold, limit, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch low stock books: %w", err)
+	}
+
+	return scanBooks(rows, false)
+}
+
+// SuggestAuthors returns up to limit distinct authors of active books whose
+// name starts with prefix, ignoring case, in alphabetical order.
+func (r *BookRepository) SuggestAuthors(ctx context.Context, prefix string, limit int) ([]string, error) {
+	query := `
+		SELECT DISTINCT author
+		FROM books
+		WHERE author ILIKE $1 || '%' AND tenant_id = $2 AND deleted_at IS NULL
+		ORDER BY author
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, likeEscaper.Replace(prefix), tenant.FromContext(ctx), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest authors: %w", err)
+	}
+
+	authors, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest authors: %w", err)
+	}
+
+	return authors, nil
+}
+
+// MatchAuthor reports whether name is one of the tenant's known authors and,
+// when it is not, returns up to limit known authors with trigram-similar
+// names, most similar first.
+func (r *BookRepository) MatchAuthor(ctx context.Context, name string, limit int) (bool, []string, error) {
+	tenantID := tenant.FromContext(ctx)
+
+	var known bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM authors WHERE tenant_id = $1 AND name = $2)`, tenantID, name).Scan(&known)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to match author: %w", err)
+	}
+	if known {
+		return true, nil, nil
+	}
+
+	query := `
+		SELECT name
+		FROM authors
+		WHERE tenant_id = $1 AND name % $2
+		ORDER BY similarity(name, $2) DESC, name
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, tenantID, name, limit)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to match author: %w", err)
+	}
+
+	similar, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to match author: %w", err)
+	}
+
+	return false, similar, nil
+}
+
+// AddAuthor records name as a known author of the tenant and reports whether
+// it was not known before.
+func (r *BookRepository) AddAuthor(ctx context.Context, name string) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		`INSERT INTO authors (tenant_id, name) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		tenant.FromContext(ctx), name)
+	if err != nil {
+		return false, fmt.Errorf("failed to add author: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
 }
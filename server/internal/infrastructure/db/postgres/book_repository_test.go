@@ -0,0 +1,378 @@
+package postgres
+
+import (
+	"bf-api/internal/domain/filterexpr"
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"bf-api/internal/domain/tenant"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildBookFilter(t *testing.T) {
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	where, args, rank := buildBookFilter("acme", models.BookFilter{
+		Search:        "50%_off",
+		SearchMode:    models.SearchModePrefix,
+		AuthorExact:   "Jane Doe",
+		CreatedAfter:  &after,
+		CreatedBefore: &before,
+	})
+
+	wantWhere := "\n\t\tWHERE deleted_at IS NULL AND tenant_id = $1" +
+		" AND (title ILIKE $2 OR author ILIKE $2)" +
+		" AND author = $3 AND created_at >= $4 AND created_at < $5"
+	if where != wantWhere {
+		t.Errorf("where = %q, want %q", where, wantWhere)
+	}
+	// values travel as arguments only; LIKE wildcards in the search are escaped
+	wantArgs := []interface{}{"acme", `50\%\_off%`, "Jane Doe", after, before}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
+	}
+	if rank != "" {
+		t.Errorf("rank = %q, want none for a prefix search", rank)
+	}
+}
+
+func TestBuildBookFilterFullText(t *testing.T) {
+	where, args, rank := buildBookFilter("default", models.BookFilter{
+		Search:      "'; DROP TABLE books; --",
+		AuthorExact: "Jane Doe",
+	})
+
+	wantWhere := "\n\t\tWHERE deleted_at IS NULL AND tenant_id = $1" +
+		" AND (search_vector @@ websearch_to_tsquery('english', $2)" +
+		" OR (numnode(websearch_to_tsquery('english', $2)) = 0 AND (title ILIKE $3 OR author ILIKE $3)))" +
+		" AND author = $4"
+	if where != wantWhere {
+		t.Errorf("where = %q, want %q", where, wantWhere)
+	}
+	wantArgs := []interface{}{"default", "'; DROP TABLE books; --", "'; DROP TABLE books; --%", "Jane Doe"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
+	}
+	if want := "ts_rank(search_vector, websearch_to_tsquery('english', $2))"; rank != want {
+		t.Errorf("rank = %q, want %q", rank, want)
+	}
+}
+
+func TestBuildBookFilterExpr(t *testing.T) {
+	expr, err := filterexpr.Parse(`author="x' OR '1'='1" AND (pages>300 OR published>2020)`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	where, args, _ := buildBookFilter("default", models.BookFilter{Expr: expr})
+
+	wantWhere := "\n\t\tWHERE deleted_at IS NULL AND tenant_id = $1" +
+		" AND ((author = $2) AND ((pages > $3) OR (published >= $4)))"
+	if where != wantWhere {
+		t.Errorf("where = %q, want %q", where, wantWhere)
+	}
+	wantArgs := []interface{}{"default", "x' OR '1'='1", 300, time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
+	}
+}
+
+func testBook(isbn string) *models.Book {
+	return &models.Book{
+		Title:     "Title",
+		Author:    "Author",
+		Published: models.Date{Year: 2000, Month: time.January, Day: 1},
+		ISBN:      isbn,
+		Pages:     100,
+	}
+}
+
+// testBooks returns n unsaved books with distinct ISBNs.
+func testBooks(n int) []*models.Book {
+	books := make([]*models.Book, n)
+	for i := range books {
+		books[i] = testBook(fmt.Sprintf("979%010d", i))
+	}
+	return books
+}
+
+// createTestBooks stores a book for each of isbns and returns them in order.
+func createTestBooks(t *testing.T, repo repositories.BookRepository, isbns ...string) []*models.Book {
+	t.Helper()
+	books := make([]*models.Book, len(isbns))
+	for i, isbn := range isbns {
+		books[i] = testBook(isbn)
+		if err := repo.CreateBook(context.Background(), books[i]); err != nil {
+			t.Fatalf("CreateBook(%s) error = %v", isbn, err)
+		}
+	}
+	return books
+}
+
+func TestUpdateBookDuplicateISBN(t *testing.T) {
+	repo := NewBookRepository(newTestPool(t))
+	books := createTestBooks(t, repo, "9780306406157", "9780140449136")
+
+	update := *books[1]
+	update.ISBN = books[0].ISBN
+	if err := repo.UpdateBook(context.Background(), &update); !errors.Is(err, repositories.ErrDuplicateISBN) {
+		t.Errorf("UpdateBook() error = %v, want ErrDuplicateISBN", err)
+	}
+}
+
+func TestFetchAllBookStopWordsOnly(t *testing.T) {
+	repo := NewBookRepository(newTestPool(t))
+	books := createTestBooks(t, repo, "9780306406157", "9780140449136")
+	books[0].Title = "The Who"
+	if err := repo.UpdateBook(context.Background(), books[0]); err != nil {
+		t.Fatalf("UpdateBook() error = %v", err)
+	}
+
+	// "the who" parses to an empty tsquery, so the search matches by prefix
+	got, total, err := repo.FetchAllBook(context.Background(), 1, 10, models.BookFilter{
+		Search:     "the who",
+		SearchMode: models.SearchModeFullText,
+	})
+	if err != nil {
+		t.Fatalf("FetchAllBook() error = %v", err)
+	}
+	if total != 1 || len(got) != 1 || got[0].ID != books[0].ID {
+		t.Errorf("FetchAllBook() = %d books of %d, want only book %d", len(got), total, books[0].ID)
+	}
+}
+
+func TestUpdateBookReturnsStoredRow(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+	// the trigger changes the row behind the repository's back
+	if _, err := pool.Exec(ctx, `
+		CREATE FUNCTION round_pages() RETURNS trigger AS $$
+		BEGIN
+			NEW.pages := (NEW.pages + 9) / 10 * 10;
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql;
+		CREATE TRIGGER round_pages BEFORE UPDATE ON books
+			FOR EACH ROW EXECUTE FUNCTION round_pages();
+	`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+
+	repo := NewBookRepository(pool)
+	book := createTestBooks(t, repo, "9780306406157")[0]
+	book.Pages = 123
+	if err := repo.UpdateBook(ctx, book); err != nil {
+		t.Fatalf("UpdateBook() error = %v", err)
+	}
+	if book.Pages != 130 {
+		t.Errorf("Pages = %d, want 130 as stored by the trigger", book.Pages)
+	}
+	if !book.UpdatedAt.After(book.CreatedAt) {
+		t.Errorf("UpdatedAt = %v, want after CreatedAt %v", book.UpdatedAt, book.CreatedAt)
+	}
+}
+
+func TestCreateBookReusesDeletedISBN(t *testing.T) {
+	repo := NewBookRepository(newTestPool(t))
+	ctx := context.Background()
+	book := createTestBooks(t, repo, "9780306406157")[0]
+
+	if err := repo.CreateBook(ctx, testBook(book.ISBN)); !errors.Is(err, repositories.ErrDuplicateISBN) {
+		t.Fatalf("CreateBook() of an active ISBN error = %v, want ErrDuplicateISBN", err)
+	}
+	if _, err := repo.DeleteBook(ctx, book.ID); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	again := testBook(book.ISBN)
+	if err := repo.CreateBook(ctx, again); err != nil {
+		t.Fatalf("CreateBook() of a deleted ISBN error = %v", err)
+	}
+	if again.ID == book.ID {
+		t.Errorf("CreateBook() reused ID %d of the deleted book", book.ID)
+	}
+}
+
+func TestFetchAllBookEmpty(t *testing.T) {
+	repo := NewBookRepository(newTestPool(t))
+
+	books, total, err := repo.FetchAllBook(context.Background(), 1, 10, models.BookFilter{})
+	if err != nil {
+		t.Fatalf("FetchAllBook() error = %v", err)
+	}
+	// a nil slice would encode as null rather than []
+	if books == nil || len(books) != 0 || total != 0 {
+		t.Errorf("FetchAllBook() = %#v, %d, want an empty slice and 0", books, total)
+	}
+}
+
+func TestPublishedDateIgnoresTimeZone(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC-12", -12*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	repo := NewBookRepository(newTestPool(t))
+	book := testBook("9780306406157")
+	book.Published = models.Date{Year: 2024, Month: time.January, Day: 1}
+	if err := repo.CreateBook(context.Background(), book); err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+
+	got, err := repo.GetByBookID(context.Background(), book.ID)
+	if err != nil {
+		t.Fatalf("GetByBookID() error = %v", err)
+	}
+	if got.Published.String() != "2024-01-01" {
+		t.Errorf("Published = %s, want 2024-01-01", got.Published)
+	}
+}
+
+func TestFetchAllBookPagesThroughIdenticalCreatedAt(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewBookRepository(pool)
+	ctx := context.Background()
+	books := createTestBooks(t, repo,
+		"9780306406157", "9780140449136", "9780262033848",
+		"9780131103627", "9780201633610", "9780596007126", "9781491950357",
+	)
+	// as if the books had been inserted by one bulk statement
+	if _, err := pool.Exec(ctx, "UPDATE books SET created_at = '2024-01-01T00:00:00Z'"); err != nil {
+		t.Fatalf("failed to set created_at: %v", err)
+	}
+
+	seen := map[int]bool{}
+	for page := 1; page <= 4; page++ {
+		got, total, err := repo.FetchAllBook(ctx, page, 2, models.BookFilter{})
+		if err != nil {
+			t.Fatalf("FetchAllBook(page %d) error = %v", page, err)
+		}
+		if total != len(books) {
+			t.Errorf("FetchAllBook(page %d) total = %d, want %d", page, total, len(books))
+		}
+		for _, book := range got {
+			if seen[book.ID] {
+				t.Errorf("book %d repeated on page %d", book.ID, page)
+			}
+			seen[book.ID] = true
+		}
+	}
+	for _, book := range books {
+		if !seen[book.ID] {
+			t.Errorf("book %d skipped", book.ID)
+		}
+	}
+}
+
+func BenchmarkFetchAllBook(b *testing.B) {
+	pool := newTestPool(b)
+	ctx := context.Background()
+	if _, _, err := NewBookRepository(pool).ImportBooks(ctx, testBooks(10000), nil); err != nil {
+		b.Fatalf("ImportBooks() error = %v", err)
+	}
+
+	for _, bm := range []struct {
+		name     string
+		separate bool
+	}{
+		{"window", false},
+		{"separate", true},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			repo := NewBookRepository(pool, WithSeparateCount(bm.separate))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// a page deep into the table, where the window function must
+				// still count every matching row
+				if _, _, err := repo.FetchAllBook(ctx, 250, 20, models.BookFilter{}); err != nil {
+					b.Fatalf("FetchAllBook() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestTimestampsScannedInUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+7", 7*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	repo := NewBookRepository(newTestPool(t))
+	book := createTestBooks(t, repo, "9780306406157")[0]
+	got, err := repo.GetByBookID(context.Background(), book.ID)
+	if err != nil {
+		t.Fatalf("GetByBookID() error = %v", err)
+	}
+	for name, ts := range map[string]time.Time{"CreatedAt": got.CreatedAt, "UpdatedAt": got.UpdatedAt} {
+		if ts.Location() != time.UTC {
+			t.Errorf("%s location = %v, want UTC", name, ts.Location())
+		}
+	}
+}
+
+func TestKnownAuthors(t *testing.T) {
+	pool := newTestPool(t)
+	repo, txm := NewBookRepository(pool), NewTxManager(pool)
+	ctx := context.Background()
+	book := func(isbn, author string) *models.Book {
+		return &models.Book{Title: "Title", Author: author, ISBN: isbn, Pages: 100, Published: models.Date{Year: 2000, Month: time.January, Day: 1}}
+	}
+	known := func(name string) bool {
+		t.Helper()
+		ok, _, err := repo.MatchAuthor(ctx, name, 3)
+		if err != nil {
+			t.Fatalf("MatchAuthor(%q) error = %v", name, err)
+		}
+		return ok
+	}
+
+	errRollback := errors.New("rollback")
+	err := txm.WithTx(ctx, func(repos repositories.Repositories) error {
+		if err := repos.Books.CreateBook(ctx, book("9780306406157", "Frank Herbert")); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("WithTx() error = %v, want the rollback", err)
+	}
+	if known("Frank Herbert") {
+		t.Error("the author of a rolled back book is known")
+	}
+
+	// every write path records the author
+	created := book("9780306406157", "Frank Herbert")
+	if err := repo.CreateBook(ctx, created); err != nil {
+		t.Fatalf("CreateBook() error = %v", err)
+	}
+	if _, _, err := repo.UpsertBooks(ctx, []*models.Book{book("9780140449136", "Homer")}); err != nil {
+		t.Fatalf("UpsertBooks() error = %v", err)
+	}
+	if _, _, err := repo.ImportBooks(ctx, []*models.Book{book("9780441013593", "Octavia E. Butler")}, nil); err != nil {
+		t.Fatalf("ImportBooks() error = %v", err)
+	}
+	if _, err := repo.DeleteBook(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	for _, name := range []string{"Frank Herbert", "Homer", "Octavia E. Butler"} {
+		if !known(name) {
+			t.Errorf("author %q is not known", name)
+		}
+	}
+	if ok, _, err := repo.MatchAuthor(tenant.WithTenant(ctx, "other"), "Homer", 3); err != nil || ok {
+		t.Errorf("MatchAuthor() in another tenant = %v, %v; want false", ok, err)
+	}
+
+	for _, want := range []bool{true, false} {
+		if got, err := repo.AddAuthor(ctx, "Ursula K. Le Guin"); err != nil || got != want {
+			t.Errorf("AddAuthor() = %v, %v; want %v", got, err, want)
+		}
+	}
+	ok, similar, err := repo.MatchAuthor(ctx, "Ursula K. Le Gwin", 3)
+	if err != nil || ok || len(similar) != 1 || similar[0] != "Ursula K. Le Guin" {
+		t.Errorf("MatchAuthor() = %v, %v, %v; want the added author suggested", ok, similar, err)
+	}
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newTestPool connects to the database named by TEST_DATABASE_URL and
+// migrates a schema of its own, dropped when the test ends, so tests never
+// see each other's rows. Tests needing it are skipped when the variable is
+// unset. configure may adjust the pool's configuration.
+func newTestPool(t testing.TB, configure ...func(*DBConfig)) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	conn, err := pgconn.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("invalid TEST_DATABASE_URL: %v", err)
+	}
+
+	ctx := context.Background()
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	cfg := DBConfig{
+		Host:       conn.Host,
+		Port:       int(conn.Port),
+		User:       conn.User,
+		Password:   conn.Password,
+		DBName:     conn.Database,
+		SSLMode:    "prefer",
+		SearchPath: schema + ",public",
+	}
+	for _, fn := range configure {
+		fn(&cfg)
+	}
+
+	pool, err := NewPostgresDB(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), "DROP SCHEMA IF EXISTS "+schema+" CASCADE")
+		pool.Close()
+	})
+
+	if _, err := pool.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return pool
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// DefaultHealthCheckQuery is the query a HealthMonitor runs unless configured
+// otherwise.
+const DefaultHealthCheckQuery = "SELECT 1"
+
+// healthCheckTimeout bounds a single check; a shorter interval bounds it instead.
+const healthCheckTimeout = 5 * time.Second
+
+// HealthStatus is the outcome of the latest check of a HealthMonitor.
+type HealthStatus struct {
+	Healthy   bool      `json:"healthy" example:"true"`
+	CheckedAt time.Time `json:"checked_at"`
+	LatencyMs float64   `json:"latency_ms" example:"1.25"`
+}
+
+// HealthMonitor runs a query on the pool every interval in the background, so
+// a degraded database is noticed before requests start failing. Readiness
+// checks serve its latest outcome rather than querying on every probe, and
+// every change between healthy and unhealthy is logged.
+type HealthMonitor struct {
+	pool     *pgxpool.Pool
+	query    string
+	interval time.Duration
+
+	status atomic.Pointer[HealthStatus]
+}
+
+// NewHealthMonitor returns a HealthMonitor running query, or
+// DefaultHealthCheckQuery when it is empty, every interval once started with
+// Run.
+func NewHealthMonitor(pool *pgxpool.Pool, query string, interval time.Duration) *HealthMonitor {
+	if query == "" {
+		query = DefaultHealthCheckQuery
+	}
+	return &HealthMonitor{pool: pool, query: query, interval: interval}
+}
+
+// Status returns the outcome of the latest check; ok is false until the first
+// check has completed.
+func (m *HealthMonitor) Status() (status HealthStatus, ok bool) {
+	if s := m.status.Load(); s != nil {
+		return *s, true
+	}
+	return HealthStatus{}, false
+}
+
+// Run checks the pool right away and then every interval until ctx is done.
+func (m *HealthMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *HealthMonitor) check(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, min(m.interval, healthCheckTimeout))
+	defer cancel()
+
+	start := time.Now()
+	_, err := m.pool.Exec(checkCtx, m.query)
+	latency := time.Since(start)
+	if ctx.Err() != nil {
+		// stopped mid-check; the database is not to blame
+		return
+	}
+
+	status := &HealthStatus{
+		Healthy:   err == nil,
+		CheckedAt: start.UTC(),
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
+	prev := m.status.Swap(status)
+	switch {
+	case err != nil && (prev == nil || prev.Healthy):
+		zap.L().Error("database health check failed; database is unhealthy",
+			zap.Error(err),
+			zap.Duration("latency", latency),
+		)
+	case err == nil && prev != nil && !prev.Healthy:
+		zap.L().Info("database health check passed; database is healthy again",
+			zap.Duration("latency", latency),
+		)
+	}
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS books (
+    id         SERIAL PRIMARY KEY,
+    title      VARCHAR(200) NOT NULL,
+    author     VARCHAR(100) NOT NULL,
+    published  DATE         NOT NULL,
+    isbn       VARCHAR(20)  NOT NULL UNIQUE,
+    pages      INTEGER      NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+ALTER TABLE books ADD COLUMN IF NOT EXISTS stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_books_active_stock ON books (stock) WHERE deleted_at IS NULL;
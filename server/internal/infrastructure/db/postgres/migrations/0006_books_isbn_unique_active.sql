@@ -0,0 +1,5 @@
+-- Only active books reserve their ISBN, so a soft-deleted book no longer
+-- blocks re-creating one with the same ISBN.
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_isbn_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn_active ON books (isbn) WHERE deleted_at IS NULL;
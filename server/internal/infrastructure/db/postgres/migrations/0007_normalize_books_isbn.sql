@@ -0,0 +1,18 @@
+-- ISBNs are now stored without hyphens or whitespace and with an uppercase
+-- check digit. Rows whose canonical form would collide with another active
+-- book are left untouched for manual cleanup rather than failing the migration.
+UPDATE books b
+SET isbn = canonical.isbn
+FROM (
+    SELECT id, upper(regexp_replace(isbn, '[-[:space:]]', '', 'g')) AS isbn
+    FROM books
+) canonical
+WHERE canonical.id = b.id
+  AND canonical.isbn <> b.isbn
+  AND NOT EXISTS (
+      SELECT 1
+      FROM books other
+      WHERE other.id <> b.id
+        AND other.deleted_at IS NULL
+        AND upper(regexp_replace(other.isbn, '[-[:space:]]', '', 'g')) = canonical.isbn
+  );
@@ -0,0 +1,25 @@
+-- Append-only trail of book mutations. book_id deliberately has no foreign
+-- key so history outlives the book it describes.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id         BIGSERIAL PRIMARY KEY,
+    actor      VARCHAR(255) NOT NULL,
+    action     VARCHAR(32) NOT NULL,
+    book_id    INT NOT NULL,
+    before     JSONB,
+    after      JSONB,
+    trace_id   VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_book_id ON audit_log (book_id, id);
+
+CREATE OR REPLACE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_immutable ON audit_log;
+CREATE TRIGGER audit_log_immutable
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
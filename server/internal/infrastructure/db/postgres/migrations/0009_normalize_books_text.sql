@@ -0,0 +1,8 @@
+-- Titles and authors are now stored NFC-normalized and trimmed, matching
+-- models.NormalizeText. updated_at is left alone since the text did not
+-- change for readers.
+UPDATE books
+SET title = btrim(normalize(title, NFC), E' \t\r\n'),
+    author = btrim(normalize(author, NFC), E' \t\r\n')
+WHERE title <> btrim(normalize(title, NFC), E' \t\r\n')
+   OR author <> btrim(normalize(author, NFC), E' \t\r\n');
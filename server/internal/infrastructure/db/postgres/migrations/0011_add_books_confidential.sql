@@ -0,0 +1,5 @@
+-- Confidential acquisition details, stored as fieldcrypt ciphertexts. The
+-- acquisition cost is a decimal amount, encrypted as text.
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS acquisition_cost TEXT,
+    ADD COLUMN IF NOT EXISTS supplier_notes TEXT;
@@ -0,0 +1,14 @@
+-- Several bookstores share one deployment; every book and audit entry belongs
+-- to a tenant. Rows written before tenancy belong to the default tenant.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- ISBNs are only unique within a tenant
+DROP INDEX IF EXISTS idx_books_isbn_active;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_books_tenant_isbn_active ON books (tenant_id, isbn) WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS idx_books_active_created_at;
+CREATE INDEX IF NOT EXISTS idx_books_tenant_active_created_at ON books (tenant_id, created_at DESC) WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS idx_audit_log_book_id;
+CREATE INDEX IF NOT EXISTS idx_audit_log_tenant_book_id ON audit_log (tenant_id, book_id, id);
@@ -0,0 +1,5 @@
+-- Author autocomplete matches case-insensitive prefixes with ILIKE, which a
+-- trigram index can serve; a plain btree index cannot.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_books_active_author_trgm ON books USING GIN (author gin_trgm_ops) WHERE deleted_at IS NULL;
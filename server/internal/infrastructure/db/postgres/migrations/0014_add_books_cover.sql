@@ -0,0 +1,5 @@
+-- Cover images live in object storage; the row keeps the object key and the
+-- URL clients fetch the cover from.
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS cover_key TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS cover_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Listings order by id after created_at so books created in the same
+-- transaction, such as by a bulk upsert, keep their place between pages.
+DROP INDEX IF EXISTS idx_books_tenant_active_created_at;
+CREATE INDEX IF NOT EXISTS idx_books_tenant_active_created_at_id ON books (tenant_id, created_at DESC, id DESC) WHERE deleted_at IS NULL;
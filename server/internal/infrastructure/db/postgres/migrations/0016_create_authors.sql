@@ -0,0 +1,15 @@
+-- Known authors of each tenant, which new books can be checked against to
+-- catch misspelt names. Seeded with the authors of the active books; names
+-- are stored NFC-normalized and trimmed like books.author.
+CREATE TABLE IF NOT EXISTS authors (
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    name VARCHAR(100) NOT NULL,
+    PRIMARY KEY (tenant_id, name)
+);
+
+-- serves the similarity search suggesting known authors for unknown ones
+CREATE INDEX IF NOT EXISTS idx_authors_name_trgm ON authors USING GIN (name gin_trgm_ops);
+
+INSERT INTO authors (tenant_id, name)
+SELECT DISTINCT tenant_id, author FROM books WHERE deleted_at IS NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,11 @@
+-- Idempotency keys were scoped to their tenant by prefixing them with
+-- "<tenant>:", which let a legal 255 character key under a long tenant ID
+-- overflow the column. Scope them with their own column instead.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+
+UPDATE idempotency_keys
+SET tenant_id = split_part(key, ':', 1), key = substr(key, strpos(key, ':') + 1)
+WHERE key ~ '^[A-Za-z0-9_-]{1,64}:';
+
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (tenant_id, key);
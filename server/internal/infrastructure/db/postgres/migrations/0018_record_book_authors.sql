@@ -0,0 +1,22 @@
+-- Keeps authors up to date: the author of every book written is recorded in
+-- the same transaction, whichever path wrote it (create, upsert, COPY import
+-- or update). Authors are never removed, so deleting a book keeps its author
+-- known.
+CREATE OR REPLACE FUNCTION record_book_author() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO authors (tenant_id, name)
+    VALUES (NEW.tenant_id, NEW.author)
+    ON CONFLICT DO NOTHING;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS books_record_author ON books;
+CREATE TRIGGER books_record_author
+    AFTER INSERT OR UPDATE OF tenant_id, author ON books
+    FOR EACH ROW EXECUTE FUNCTION record_book_author();
+
+-- catch up on the books written since the authors table was seeded
+INSERT INTO authors (tenant_id, name)
+SELECT DISTINCT tenant_id, author FROM books
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"bf-api/internal/domain/models"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// OutboxRepository is only available through TxManager: enqueuing outside
+// the write's transaction would defeat its purpose.
+type OutboxRepository struct {
+	db dbtx
+}
+
+func (r *OutboxRepository) Enqueue(ctx context.Context, event models.BookEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox event: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx,
+		"INSERT INTO outbox (event_type, payload, created_at) VALUES ($1, $2, $3)",
+		event.EventType, payload, event.Timestamp,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimPending locks up to limit unsent entries, oldest first. Entries
+// locked by another relay are skipped rather than waited for.
+func (r *OutboxRepository) ClaimPending(ctx context.Context, limit int) ([]*models.OutboxEntry, error) {
+	query := `
+		SELECT id, payload, created_at, attempts
+		FROM outbox
+		WHERE sent_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.OutboxEntry
+	for rows.Next() {
+		var entry models.OutboxEntry
+		var payload []byte
+		if err := rows.Scan(&entry.ID, &payload, &entry.CreatedAt, &entry.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox entry: %w", err)
+		}
+		if err := json.Unmarshal(payload, &entry.Event); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox entry %d: %w", entry.ID, err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate outbox entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *OutboxRepository) MarkSent(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE outbox SET sent_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1",
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox entry sent: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed delivery attempt; the entry stays pending.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1",
+		id, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox entry failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"bf-api/internal/domain/repositories"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// poolDB is the pool as repositories see it. It marks errors caused by
+// waiting too long for a free connection with repositories.ErrPoolExhausted,
+// and retries statements that failed with a transient error. Each statement
+// runs on its own, so a retry never repeats part of a transaction.
+type poolDB struct {
+	*pgxpool.Pool
+}
+
+func (p poolDB) Begin(ctx context.Context) (tx pgx.Tx, err error) {
+	err = withRetry(ctx, func() error {
+		tx, err = p.Pool.Begin(ctx)
+		return acquireError(err)
+	})
+	return tx, err
+}
+
+func (p poolDB) Exec(ctx context.Context, sql string, args ...any) (tag pgconn.CommandTag, err error) {
+	err = withRetry(ctx, func() error {
+		tag, err = p.Pool.Exec(ctx, sql, args...)
+		return acquireError(err)
+	})
+	return tag, err
+}
+
+func (p poolDB) Query(ctx context.Context, sql string, args ...any) (rows pgx.Rows, err error) {
+	err = withRetry(ctx, func() error {
+		rows, err = p.Pool.Query(ctx, sql, args...)
+		return acquireError(err)
+	})
+	return rows, err
+}
+
+// QueryRow defers the query to Scan, where its error surfaces, so that a
+// failed attempt can be run again.
+func (p poolDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return retryRow{pool: p.Pool, ctx: ctx, sql: sql, args: args}
+}
+
+type retryRow struct {
+	pool *pgxpool.Pool
+	ctx  context.Context
+	sql  string
+	args []any
+}
+
+func (r retryRow) Scan(dest ...any) error {
+	return withRetry(r.ctx, func() error {
+		return acquireError(r.pool.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...))
+	})
+}
+
+// acquireError tags deadline errors raised while acquiring a connection.
+// pgxpool returns the context's error unwrapped when it gives up waiting,
+// whereas pgconn wraps deadlines hit by a running query in a timeout error,
+// which is how the two cases are told apart.
+func acquireError(err error) error {
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", repositories.ErrPoolExhausted, err)
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/repositories"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestPoolExhaustedUnderLoad holds every connection of a small pool while
+// many requests arrive, as under a traffic spike, and checks that each one
+// fails as pool exhaustion rather than as a generic error.
+func TestPoolExhaustedUnderLoad(t *testing.T) {
+	pool := newTestPool(t, func(cfg *DBConfig) {
+		cfg.PoolMaxConns = 2
+		cfg.PoolMinConns = 1
+	})
+	repo := NewBookRepository(pool)
+	ctx := context.Background()
+
+	held := make([]*pgxpool.Conn, 0, 2)
+	for range 2 {
+		conn, err := pool.Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		held = append(held, conn)
+	}
+
+	const requests = 50
+	errs := make(chan error, requests)
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			// both the single-row and the multi-row paths must be tagged
+			if i%2 == 0 {
+				_, err := repo.CountBooks(ctx, models.BookFilter{})
+				errs <- err
+			} else {
+				_, _, err := repo.FetchAllBook(ctx, 1, 10, models.BookFilter{})
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if !errors.Is(err, repositories.ErrPoolExhausted) {
+			t.Errorf("error = %v, want ErrPoolExhausted", err)
+		}
+	}
+
+	for _, conn := range held {
+		conn.Release()
+	}
+	if _, err := repo.CountBooks(ctx, models.BookFilter{}); err != nil {
+		t.Errorf("CountBooks() after release error = %v", err)
+	}
+}
+
+// TestSlowQueryIsNotPoolExhaustion checks that a query running past its
+// deadline on a free connection is not reported as pool exhaustion.
+func TestSlowQueryIsNotPoolExhaustion(t *testing.T) {
+	pool := newTestPool(t)
+	db := poolDB{pool}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := db.Exec(ctx, "SELECT pg_sleep(1)")
+	if err == nil {
+		t.Fatal("Exec() error = nil, want a timeout")
+	}
+	if errors.Is(err, repositories.ErrPoolExhausted) {
+		t.Errorf("error = %v, want a query timeout", err)
+	}
+}
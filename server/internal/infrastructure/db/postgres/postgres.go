@@ -2,11 +2,18 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
 )
 
 type DBConfig struct {
@@ -14,14 +21,25 @@ type DBConfig struct {
 	Port                int
 	User                string
 	Password            string
+	PasswordFile        string // file holding the password, e.g. a mounted secret; read instead of Password when set
 	DBName              string
-	SSLMode             string        // disable, allow, prefer, require, verify-ca, verify-full
+	SSLMode             string        // disable, allow, prefer, require, verify-ca, verify-full; def: disable
+	SSLRootCert         string        // CA bundle verifying the server for verify-ca and verify-full; system roots when empty
+	SSLCert             string        // client certificate, for servers that require one
+	SSLKey              string        // client certificate key; set together with SSLCert
 	PoolMaxConns        int           // def: 10
 	PoolMinConns        int           // def: 2
 	PoolMaxConnIdle     time.Duration // def: 30m
 	PoolMaxConnLifetime time.Duration // def: 1h
 	ConnTimeout         time.Duration // def: 5s
-
+	SearchPath          string        // comma-separated schemas, e.g. "app,public"; server default when empty
+	SeparateCount       bool          // count list totals with a second query instead of COUNT(*) OVER()
+	SlowQueryThreshold  time.Duration // queries taking longer are logged at warn level; 0 disables
+	SlowQueryLogArgs    bool          // include query arguments in slow query logs; may expose personal data
+	ImportMode          string        // copy or batch, see ImportModeCopy and ImportModeBatch; def: copy
+	ImportBatchSize     int           // books written per chunk of an import; def: 1000
+	HealthCheckQuery    string        // query run by the background health check; def: SELECT 1
+	HealthCheckInterval time.Duration // time between background health checks; 0 disables them; def: 15s
 }
 
 func NewPostgresDB(ctx context.Context, cfg DBConfig) (*pgxpool.Pool, error) {
@@ -37,18 +55,21 @@ func NewPostgresDB(ctx context.Context, cfg DBConfig) (*pgxpool.Pool, error) {
 	if cfg.ConnTimeout == 0 {
 		cfg.ConnTimeout = 5 * time.Second
 	}
-	if cfg.SSLMode == "" {
-		cfg.SSLMode = "disable"
+	password, fromFile, err := resolvePassword(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Password = password
+	if fromFile {
+		zap.L().Info("using the database password from a file", zap.String("path", cfg.PasswordFile))
+	} else {
+		zap.L().Info("using the database password from the configuration")
 	}
 
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host,
-		cfg.Port,
-		cfg.User,
-		cfg.Password,
-		cfg.DBName,
-		cfg.SSLMode,
-	)
+	connStr, err := ConnString(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	poolConfig, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
@@ -61,9 +82,36 @@ func NewPostgresDB(ctx context.Context, cfg DBConfig) (*pgxpool.Pool, error) {
 	poolConfig.MaxConnLifetime = cfg.PoolMaxConnLifetime
 	poolConfig.ConnConfig.ConnectTimeout = cfg.ConnTimeout
 
+	// installed even when disabled so SetSlowQueryThreshold can enable it
+	tracer := &slowQueryTracer{logArgs: cfg.SlowQueryLogArgs}
+	tracer.threshold.Store(int64(cfg.SlowQueryThreshold))
+	poolConfig.ConnConfig.Tracer = tracer
+
+	searchPath := searchPathIdentifiers(cfg.SearchPath)
 	poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
-		_, err := conn.Exec(ctx, "SET TIME ZONE'UTC'")
-		return err
+		if _, err := conn.Exec(ctx, "SET TIME ZONE 'UTC'"); err != nil {
+			zap.L().Error("failed to set session time zone", zap.Error(err))
+			return fmt.Errorf("failed to set time zone: %w", err)
+		}
+		// pgx returns timestamptz values in the server's local zone whatever
+		// the session's is; scan them in UTC so they serialize with a Z
+		conn.TypeMap().RegisterType(&pgtype.Type{
+			Name:  "timestamptz",
+			OID:   pgtype.TimestamptzOID,
+			Codec: &pgtype.TimestamptzCodec{ScanLocation: time.UTC},
+		})
+
+		if searchPath != "" {
+			if _, err := conn.Exec(ctx, "SET search_path TO "+searchPath); err != nil {
+				zap.L().Error("failed to set session search_path",
+					zap.Error(err),
+					zap.String("search_path", cfg.SearchPath),
+				)
+				return fmt.Errorf("failed to set search_path: %w", err)
+			}
+		}
+
+		return nil
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, cfg.ConnTimeout)
@@ -77,6 +125,82 @@ func NewPostgresDB(ctx context.Context, cfg DBConfig) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
+// resolvePassword returns the password to connect with: the contents of
+// PasswordFile without trailing newlines when it is set, else Password.
+// fromFile reports which one it is.
+func resolvePassword(cfg DBConfig) (password string, fromFile bool, err error) {
+	if cfg.PasswordFile == "" {
+		return cfg.Password, false, nil
+	}
+
+	data, err := os.ReadFile(cfg.PasswordFile)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read password file: %w", err)
+	}
+	password = strings.TrimRight(string(data), "\r\n")
+	if password == "" {
+		return "", false, fmt.Errorf("password file %s is empty", cfg.PasswordFile)
+	}
+	return password, true, nil
+}
+
+// SSLModes are the sslmode values understood by pgx, weakest first.
+var SSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// ConnString assembles a key/value connection string from cfg, quoting each
+// value so passwords and paths may contain spaces or quotes.
+func ConnString(cfg DBConfig) (string, error) {
+	if cfg.SSLMode == "" {
+		cfg.SSLMode = "disable"
+	}
+	if !slices.Contains(SSLModes, cfg.SSLMode) {
+		return "", fmt.Errorf("invalid sslmode %q; want one of %s", cfg.SSLMode, strings.Join(SSLModes, ", "))
+	}
+	if (cfg.SSLCert == "") != (cfg.SSLKey == "") {
+		return "", errors.New("sslcert and sslkey must be set together")
+	}
+
+	params := []struct{ key, value string }{
+		{"host", cfg.Host},
+		{"port", strconv.Itoa(cfg.Port)},
+		{"user", cfg.User},
+		{"password", cfg.Password},
+		{"dbname", cfg.DBName},
+		{"sslmode", cfg.SSLMode},
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+	}
+
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		if p.value == "" && p.key != "password" {
+			continue
+		}
+		parts = append(parts, p.key+"="+quoteConnValue(p.value))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// quoteConnValue quotes a connection string value as libpq expects.
+func quoteConnValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// searchPathIdentifiers quotes each schema in a comma-separated list so it can
+// be interpolated into SET search_path safely.
+func searchPathIdentifiers(searchPath string) string {
+	var schemas []string
+	for _, schema := range strings.Split(searchPath, ",") {
+		if schema = strings.TrimSpace(schema); schema != "" {
+			schemas = append(schemas, pgx.Identifier{schema}.Sanitize())
+		}
+	}
+	return strings.Join(schemas, ", ")
+}
+
 func HealthCheck(ctx context.Context, pool *pgxpool.Pool) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -94,6 +218,31 @@ func HealthCheck(ctx context.Context, pool *pgxpool.Pool) error {
 	return nil
 }
 
+// WarmUp establishes the pool's MinConns connections up front so the first
+// requests don't pay connection latency. The connections are held together,
+// forcing the pool to open distinct ones, and released once all are ready.
+func WarmUp(ctx context.Context, pool *pgxpool.Pool) (time.Duration, error) {
+	start := time.Now()
+	minConns := int(pool.Config().MinConns)
+
+	conns := make([]*pgxpool.Conn, 0, minConns)
+	defer func() {
+		for _, conn := range conns {
+			conn.Release()
+		}
+	}()
+
+	for len(conns) < minConns {
+		conn, err := pool.Acquire(ctx)
+		if err != nil {
+			return time.Since(start), fmt.Errorf("warm-up reached %d of %d connections: %w", len(conns), minConns, err)
+		}
+		conns = append(conns, conn)
+	}
+
+	return time.Since(start), nil
+}
+
 func CloseDB(pool *pgxpool.Pool) {
 	pool.Close()
 }
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestAfterConnectSetsSession(t *testing.T) {
+	pool := newTestPool(t, func(cfg *DBConfig) {
+		cfg.SearchPath += ", pg_catalog"
+	})
+	ctx := context.Background()
+
+	var timeZone, searchPath string
+	if err := pool.QueryRow(ctx, "SHOW TIME ZONE").Scan(&timeZone); err != nil {
+		t.Fatalf("SHOW TIME ZONE: %v", err)
+	}
+	if timeZone != "UTC" {
+		t.Errorf("session time zone = %q, want UTC", timeZone)
+	}
+
+	// a timestamp is rendered in the session zone, whatever the server's is
+	var rendered string
+	if err := pool.QueryRow(ctx, "SELECT '2024-06-01 12:00:00+00'::timestamptz::text").Scan(&rendered); err != nil {
+		t.Fatalf("render timestamp: %v", err)
+	}
+	if rendered != "2024-06-01 12:00:00+00" {
+		t.Errorf("rendered timestamp = %q, want it in UTC", rendered)
+	}
+
+	if err := pool.QueryRow(ctx, "SHOW search_path").Scan(&searchPath); err != nil {
+		t.Fatalf("SHOW search_path: %v", err)
+	}
+	if !strings.Contains(searchPath, "test_") || !strings.HasSuffix(searchPath, "public, pg_catalog") {
+		t.Errorf("session search_path = %q, want the test schema, public and pg_catalog", searchPath)
+	}
+}
+
+func TestConnString(t *testing.T) {
+	base := DBConfig{Host: "db.example.com", Port: 5432, User: "app", Password: `it's s\ecret`, DBName: "books"}
+	prefix := `host='db.example.com' port='5432' user='app' password='it\'s s\\ecret' dbname='books' `
+
+	for _, mode := range SSLModes {
+		t.Run(mode, func(t *testing.T) {
+			cfg := base
+			cfg.SSLMode = mode
+			got, err := ConnString(cfg)
+			if err != nil {
+				t.Fatalf("ConnString() error = %v", err)
+			}
+			if want := prefix + "sslmode='" + mode + "'"; got != want {
+				t.Errorf("ConnString() = %q, want %q", got, want)
+			}
+
+			parsed, err := pgconn.ParseConfig(got)
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			if parsed.Password != cfg.Password {
+				t.Errorf("parsed password = %q, want %q", parsed.Password, cfg.Password)
+			}
+			// only disable connects without trying TLS first
+			if tls := parsed.TLSConfig != nil; tls != (mode != "disable" && mode != "allow") {
+				t.Errorf("parsed TLS = %v for sslmode %s", tls, mode)
+			}
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		got, err := ConnString(base)
+		if err != nil {
+			t.Fatalf("ConnString() error = %v", err)
+		}
+		if !strings.HasSuffix(got, " sslmode='disable'") {
+			t.Errorf("ConnString() = %q, want sslmode disable", got)
+		}
+	})
+
+	t.Run("certificates", func(t *testing.T) {
+		cfg := base
+		cfg.SSLMode = "verify-full"
+		cfg.SSLRootCert = "/etc/ssl/db ca.pem"
+		cfg.SSLCert = "/etc/ssl/client.pem"
+		cfg.SSLKey = "/etc/ssl/client.key"
+		got, err := ConnString(cfg)
+		if err != nil {
+			t.Fatalf("ConnString() error = %v", err)
+		}
+		want := prefix + "sslmode='verify-full' sslrootcert='/etc/ssl/db ca.pem'" +
+			" sslcert='/etc/ssl/client.pem' sslkey='/etc/ssl/client.key'"
+		if got != want {
+			t.Errorf("ConnString() = %q, want %q", got, want)
+		}
+	})
+
+	for name, cfg := range map[string]DBConfig{
+		"unknown sslmode":  {SSLMode: "verify"},
+		"cert without key": {SSLMode: "require", SSLCert: "/etc/ssl/client.pem"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ConnString(cfg); err == nil {
+				t.Error("ConnString() error = nil, want an error")
+			}
+		})
+	}
+}
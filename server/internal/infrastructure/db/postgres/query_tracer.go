@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"bf-api/internal/infrastructure/tracing"
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql   string
+	args  []any
+	start time.Time
+}
+
+// slowQueryTracer logs queries that take longer than threshold, which 0
+// disables. Arguments are only logged when logArgs is set since they may
+// hold personal data.
+type slowQueryTracer struct {
+	threshold atomic.Int64 // a time.Duration, changed with SetSlowQueryThreshold
+	logArgs   bool
+}
+
+// SetSlowQueryThreshold changes the duration from which queries on pool are
+// logged as slow; 0 stops logging them.
+func SetSlowQueryThreshold(pool *pgxpool.Pool, d time.Duration) {
+	if t, ok := pool.Config().ConnConfig.Tracer.(*slowQueryTracer); ok {
+		t.threshold.Store(int64(d))
+	}
+}
+
+func (t *slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if t.threshold.Load() <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, queryStartKey{}, queryStart{
+		sql:   data.SQL,
+		args:  data.Args,
+		start: time.Now(),
+	})
+}
+
+func (t *slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	query, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(query.start)
+	threshold := time.Duration(t.threshold.Load())
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("sql", query.sql),
+		zap.Duration("duration", elapsed),
+		zap.Duration("threshold", threshold),
+	}
+	if traceID, ok := tracing.TraceIDFromContext(ctx); ok {
+		fields = append(fields, zap.String("trace_id", traceID))
+	}
+	if t.logArgs {
+		fields = append(fields, zap.Any("args", query.args))
+	}
+	if data.Err != nil {
+		fields = append(fields, zap.Error(data.Err))
+	}
+	zap.L().Warn("slow query", fields...)
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+const (
+	// retryAttempts bounds how often an operation is tried in total.
+	retryAttempts = 3
+	// retryBaseDelay doubles after every attempt, up to retryMaxDelay.
+	retryBaseDelay = 20 * time.Millisecond
+	retryMaxDelay  = 500 * time.Millisecond
+)
+
+// retryableCodes are SQLSTATEs after which the failed statement or
+// transaction is known to have been rolled back, so running it again cannot
+// apply it twice.
+var retryableCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"55P03": true, // lock_not_available
+	"57P01": true, // admin_shutdown, e.g. a server restart during a deploy
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now, the server is still starting
+}
+
+// retryable reports whether err is transient and the operation that failed
+// with it may safely run again. Network errors only qualify when pgx is sure
+// nothing reached the server; a connection lost mid-statement leaves its
+// outcome unknown.
+func retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return retryableCodes[pgErr.Code]
+	}
+	return pgconn.SafeToRetry(err)
+}
+
+// withRetry runs fn until it succeeds, fails with an error that is not
+// retryable, or has run retryAttempts times, backing off exponentially with
+// jitter in between. It gives up early rather than wait past ctx's deadline,
+// and returns fn's last error.
+func withRetry(ctx context.Context, fn func() error) error {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if attempt == retryAttempts || !retryable(err) {
+			return err
+		}
+
+		wait := delay/2 + rand.N(delay/2+1)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		zap.L().Warn("retrying transient database error",
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", wait),
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = min(2*delay, retryMaxDelay)
+	}
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"bf-api/internal/domain/repositories"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// failingThenSucceeding returns an operation that fails with err the first
+// failures times it runs and succeeds after that, and a count of its runs.
+func failingThenSucceeding(failures int, err error) (func() error, *int) {
+	calls := 0
+	return func() error {
+		calls++
+		if calls <= failures {
+			return err
+		}
+		return nil
+	}, &calls
+}
+
+func TestWithRetry(t *testing.T) {
+	serialization := &pgconn.PgError{Code: "40001"}
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		wantErr   bool
+		wantCalls int
+	}{
+		{"transient then success", retryAttempts - 1, serialization, false, retryAttempts},
+		{"transient every time", retryAttempts, serialization, true, retryAttempts},
+		{"unique violation", 1, &pgconn.PgError{Code: "23505"}, true, 1},
+		{"not found", 1, repositories.ErrBookNotFound, true, 1},
+		{"canceled", 1, context.Canceled, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, calls := failingThenSucceeding(tt.failures, tt.err)
+			err := withRetry(context.Background(), fn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("withRetry() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("withRetry() error = %v, want %v", err, tt.err)
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("operation ran %d times, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithRetryStopsAtDeadline(t *testing.T) {
+	// too little time is left for even the first backoff
+	ctx, cancel := context.WithTimeout(context.Background(), retryBaseDelay/4)
+	defer cancel()
+
+	fn, calls := failingThenSucceeding(1, &pgconn.PgError{Code: "40001"})
+	if err := withRetry(ctx, fn); err == nil {
+		t.Error("withRetry() error = nil, want the transient error")
+	}
+	if *calls != 1 {
+		t.Errorf("operation ran %d times, want 1", *calls)
+	}
+}
+
+func TestWithTxRetriesTransientFailure(t *testing.T) {
+	pool := newTestPool(t)
+	tx := NewTxManager(pool)
+	ctx := context.Background()
+
+	calls := 0
+	err := tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		calls++
+		if err := repos.Books.CreateBook(ctx, testBook("9780306406157")); err != nil {
+			return err
+		}
+		if calls == 1 {
+			// as if the commit lost a race with a concurrent transaction
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("transaction ran %d times, want 2", calls)
+	}
+
+	// the failed attempt was rolled back, so the book exists once
+	var count int
+	if err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM books").Scan(&count); err != nil {
+		t.Fatalf("count books: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("%d books stored, want 1", count)
+	}
+}
+
+func TestWithTxDoesNotRetryConstraintViolation(t *testing.T) {
+	tx := NewTxManager(newTestPool(t))
+	ctx := context.Background()
+
+	calls := 0
+	err := tx.WithTx(ctx, func(repos repositories.Repositories) error {
+		calls++
+		return &pgconn.PgError{Code: "23505"}
+	})
+	if err == nil {
+		t.Error("WithTx() error = nil, want the constraint violation")
+	}
+	if calls != 1 {
+		t.Errorf("transaction ran %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RequiredExtensions are the extensions the migrations and queries depend on.
+var RequiredExtensions = []string{"plpgsql", "pg_trgm"}
+
+// ServerDetails describes the connected Postgres server.
+type ServerDetails struct {
+	Version    string          `json:"version" example:"16.3"`
+	Extensions []ExtensionInfo `json:"extensions"`
+}
+
+// ExtensionInfo reports whether a required extension is installed in the
+// database, and at which version.
+type ExtensionInfo struct {
+	Name      string `json:"name" example:"plpgsql"`
+	Installed bool   `json:"installed" example:"true"`
+	Version   string `json:"version,omitempty" example:"1.0"`
+}
+
+// Missing returns the required extensions that are not installed.
+func (d ServerDetails) Missing() []string {
+	var missing []string
+	for _, ext := range d.Extensions {
+		if !ext.Installed {
+			missing = append(missing, ext.Name)
+		}
+	}
+	return missing
+}
+
+// ServerInfo reads the server version and the state of every extension in
+// RequiredExtensions.
+func ServerInfo(ctx context.Context, pool *pgxpool.Pool) (*ServerDetails, error) {
+	var info ServerDetails
+	if err := pool.QueryRow(ctx, "SHOW server_version").Scan(&info.Version); err != nil {
+		return nil, fmt.Errorf("failed to read server version: %w", err)
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT required.name, e.extversion
+		FROM unnest($1::text[]) WITH ORDINALITY AS required(name, ord)
+		LEFT JOIN pg_extension e ON e.extname = required.name
+		ORDER BY required.ord`,
+		RequiredExtensions,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installed extensions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ext ExtensionInfo
+		var version *string
+		if err := rows.Scan(&ext.Name, &version); err != nil {
+			return nil, fmt.Errorf("failed to scan extension: %w", err)
+		}
+		if version != nil {
+			ext.Installed, ext.Version = true, *version
+		}
+		info.Extensions = append(info.Extensions, ext)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read installed extensions: %w", err)
+	}
+
+	return &info, nil
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"bf-api/internal/domain/repositories"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TxManager runs service operations in a single database transaction.
+type TxManager struct {
+	pool     *pgxpool.Pool
+	bookOpts []BookRepositoryOption
+}
+
+// NewTxManager returns a TxManager whose transaction-scoped book repositories
+// are built with opts, matching the ones passed to NewBookRepository.
+func NewTxManager(pool *pgxpool.Pool, opts ...BookRepositoryOption) repositories.TxManager {
+	return &TxManager{pool: pool, bookOpts: opts}
+}
+
+// WithTx begins a transaction, hands fn repositories bound to it and commits
+// when fn returns nil. Any error from fn rolls everything back. A transaction
+// that fails with a transient error, such as a serialization failure, is run
+// again from the start, so fn may be called more than once.
+func (m *TxManager) WithTx(ctx context.Context, fn func(repos repositories.Repositories) error) error {
+	return withRetry(ctx, func() error {
+		return m.withTx(ctx, fn)
+	})
+}
+
+func (m *TxManager) withTx(ctx context.Context, fn func(repos repositories.Repositories) error) error {
+	tx, err := acquireTx(ctx, m.pool)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	repos := repositories.Repositories{
+		Books:  newBookRepository(tx, m.bookOpts...),
+		Audit:  &AuditRepository{db: tx},
+		Outbox: &OutboxRepository{db: tx},
+	}
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// acquireTx begins a transaction on the pool without poolDB's retries, which
+// WithTx already applies to the whole transaction.
+func acquireTx(ctx context.Context, pool *pgxpool.Pool) (pgx.Tx, error) {
+	tx, err := pool.Begin(ctx)
+	return tx, acquireError(err)
+}
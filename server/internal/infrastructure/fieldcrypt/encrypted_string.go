@@ -0,0 +1,56 @@
+package fieldcrypt
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// EncryptedString is a string stored encrypted with the default keyring. It
+// is written as ciphertext by Value and decrypted by Scan, so repositories
+// read and write it like a plain string. The empty string is stored as NULL.
+type EncryptedString string
+
+func (s EncryptedString) Value() (driver.Value, error) {
+	if s == "" {
+		return nil, nil
+	}
+	kr := Default()
+	if kr == nil {
+		return nil, ErrNoKeyring
+	}
+	return kr.Encrypt(string(s))
+}
+
+func (s *EncryptedString) Scan(src interface{}) error {
+	var ciphertext string
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		ciphertext = v
+	case []byte:
+		ciphertext = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into EncryptedString", src)
+	}
+
+	kr := Default()
+	if kr == nil {
+		return ErrNoKeyring
+	}
+	plaintext, err := kr.Decrypt(ciphertext)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// String redacts the value so it does not leak into logs.
+func (s EncryptedString) String() string {
+	if s == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
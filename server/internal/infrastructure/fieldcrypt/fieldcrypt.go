@@ -0,0 +1,200 @@
+// Package fieldcrypt encrypts individual database columns with envelope
+// encryption: every value is sealed with its own random data key, and the
+// data key is sealed with a key encryption key (KEK) from a Keyring. Both use
+// AES-256-GCM.
+//
+// Ciphertexts are self-describing, "v1:<kek id>:<sealed data key>:<sealed
+// value>" with base64 parts, so keys can be rotated: values are decrypted with
+// whichever KEK sealed them and re-encrypted with the primary one when next
+// written.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	formatV1 = "v1"
+	keySize  = 32
+)
+
+var (
+	ErrNoKeyring  = errors.New("field encryption keys are not configured")
+	ErrUnknownKey = errors.New("ciphertext sealed with an unknown key")
+	ErrMalformed  = errors.New("malformed ciphertext")
+)
+
+// Keyring holds the key encryption keys by ID. The primary key seals new
+// values; the others only open values sealed before a rotation.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring builds a Keyring from 32-byte keys by ID. primary must be one of
+// them.
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in the keyring", primary)
+	}
+
+	kr := &Keyring{primary: primary, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key ID %q", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		kr.keys[id] = aead
+	}
+	return kr, nil
+}
+
+// ParseKeys parses entries of the form id:base64key, as read from the
+// environment or fetched from a KMS. The first entry is the primary key.
+func ParseKeys(entries []string) (*Keyring, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	var primary string
+	keys := make(map[string][]byte, len(entries))
+	for i, entry := range entries {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, errors.New("malformed key entry, want id:base64key")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("duplicate key ID %q", id)
+		}
+		keys[id] = key
+		if i == 0 {
+			primary = id
+		}
+	}
+	return NewKeyring(primary, keys)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt seals plaintext with a fresh data key under the primary KEK.
+func (kr *Keyring) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	sealedKey, err := seal(kr.keys[kr.primary], dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealedValue, err := seal(dataAEAD, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		formatV1,
+		kr.primary,
+		base64.RawStdEncoding.EncodeToString(sealedKey),
+		base64.RawStdEncoding.EncodeToString(sealedValue),
+	}, ":"), nil
+}
+
+// Decrypt opens a ciphertext produced by Encrypt with any key in the ring.
+func (kr *Keyring) Decrypt(ciphertext string) (string, error) {
+	parts := strings.Split(ciphertext, ":")
+	if len(parts) != 4 || parts[0] != formatV1 {
+		return "", ErrMalformed
+	}
+
+	kek, ok := kr.keys[parts[1]]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, parts[1])
+	}
+	sealedKey, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrMalformed
+	}
+	sealedValue, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", ErrMalformed
+	}
+
+	dataKey, err := open(kek, sealedKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to open data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	plaintext, err := open(dataAEAD, sealedValue)
+	if err != nil {
+		return "", fmt.Errorf("failed to open value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether ciphertext was sealed with a KEK other than
+// the primary one.
+func (kr *Keyring) NeedsRotation(ciphertext string) bool {
+	parts := strings.SplitN(ciphertext, ":", 3)
+	return len(parts) < 3 || parts[0] != formatV1 || parts[1] != kr.primary
+}
+
+// seal encrypts data with a random nonce prepended to the result.
+func seal(aead cipher.AEAD, data []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, data := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, data, nil)
+}
+
+var defaultKeyring atomic.Pointer[Keyring]
+
+// SetDefault installs the keyring EncryptedString uses; call it at startup
+// before any encrypted column is read or written.
+func SetDefault(kr *Keyring) {
+	defaultKeyring.Store(kr)
+}
+
+// Default returns the installed keyring, or nil.
+func Default() *Keyring {
+	return defaultKeyring.Load()
+}
@@ -1,30 +1,96 @@
 package logger
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Log formats.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Outputs other than a file path.
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
 )
 
+// Config selects where logs go and how they are encoded.
+type Config struct {
+	Output string // stdout, stderr or the path of a file rotated by size; def: stderr
+	Format string // json or console; def: json
+
+	Level zapcore.Level // minimum level logged, until changed with SetLevel; def: info
+
+	// Rotation of a file output; ignored for stdout and stderr.
+	MaxSizeMB  int  // size at which the file is rotated; def: 100
+	MaxBackups int  // rotated files kept; 0 keeps all
+	MaxAgeDays int  // days rotated files are kept; 0 keeps them regardless of age
+	Compress   bool // gzip rotated files
+}
+
 var Logger *zap.Logger
 
-func Init(production bool) {
-	config := zap.NewProductionConfig()
-	if !production {
-		config := zap.NewDevelopmentConfig()
-		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	}
+// level is shared by every logger built by Init so it can be changed at runtime.
+var level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
 
-	config.EncoderConfig.TimeKey = "timestamp"
-	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+// Init builds Logger from cfg. Timestamps are ISO8601 and every entry carries
+// its caller.
+func Init(cfg Config) error {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "timestamp"
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	var encoder zapcore.Encoder
+	switch cfg.Format {
+	case "", FormatJSON:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case FormatConsole:
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return fmt.Errorf("unknown log format %q; use json or console", cfg.Format)
+	}
 
-	var err error
-	Logger, err = config.Build(zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
-	if err != nil {
-		panic("failed to initialize logger: " + err.Error())
+	var out zapcore.WriteSyncer
+	switch cfg.Output {
+	case "", OutputStderr:
+		out = zapcore.Lock(os.Stderr)
+	case OutputStdout:
+		out = zapcore.Lock(os.Stdout)
+	default:
+		// lumberjack serializes writes itself
+		out = zapcore.AddSync(&lumberjack.Logger{
+			Filename:   cfg.Output,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+		})
 	}
 
-	// flush logs on exit
-	defer Logger.Sync()
+	level.SetLevel(cfg.Level)
+
+	// sampled like zap's production config so a hot loop cannot flood the output
+	core := zapcore.NewSamplerWithOptions(zapcore.NewCore(encoder, out, level), time.Second, 100, 100)
+	Logger = zap.New(core,
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	)
+
+	return nil
+}
+
+// SetLevel changes the minimum level logged from now on.
+func SetLevel(l zapcore.Level) {
+	level.SetLevel(l)
 }
 
 func Sugar() *zap.SugaredLogger {
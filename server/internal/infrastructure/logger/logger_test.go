@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestInitJSONFileWritesJSONLines(t *testing.T) {
+	previous := Logger
+	t.Cleanup(func() { Logger = previous })
+
+	path := filepath.Join(t.TempDir(), "api.log")
+	if err := Init(Config{Output: path, Format: FormatJSON}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	Logger.Info("first", zap.String("title", "line one\nline \"two\""))
+	Logger.Warn("second", zap.Int("pages", 100))
+	Logger.Debug("dropped below the default level")
+	Logger.Sync()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	defer f.Close()
+
+	var messages []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry struct {
+			Timestamp string `json:"timestamp"`
+			Level     string `json:"level"`
+			Caller    string `json:"caller"`
+			Msg       string `json:"msg"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", scanner.Text(), err)
+		}
+		if _, err := time.Parse("2006-01-02T15:04:05.000Z0700", entry.Timestamp); err != nil {
+			t.Errorf("timestamp %q is not ISO8601: %v", entry.Timestamp, err)
+		}
+		if !strings.HasPrefix(entry.Caller, "logger/logger_test.go:") {
+			t.Errorf("caller = %q, want this test", entry.Caller)
+		}
+		messages = append(messages, entry.Level+" "+entry.Msg)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+
+	if got := strings.Join(messages, ", "); got != "info first, warn second" {
+		t.Errorf("logged %q, want info first, warn second", got)
+	}
+}
+
+func TestInitUnknownFormat(t *testing.T) {
+	if err := Init(Config{Format: "xml"}); err == nil {
+		t.Error("Init() error = nil, want an unknown format error")
+	}
+}
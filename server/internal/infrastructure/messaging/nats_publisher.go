@@ -0,0 +1,74 @@
+package messaging
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/infrastructure/tracing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+type NATSConfig struct {
+	URL           string
+	SubjectPrefix string        // def: events
+	ConnTimeout   time.Duration // def: 5s
+}
+
+// NATSPublisher publishes book events to subjects named
+// <prefix>.<event type>, e.g. events.book.created.
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewNATSPublisher(cfg NATSConfig) (*NATSPublisher, error) {
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = "events"
+	}
+	if cfg.ConnTimeout == 0 {
+		cfg.ConnTimeout = 5 * time.Second
+	}
+
+	conn, err := nats.Connect(cfg.URL,
+		nats.Name("bf-api"),
+		nats.Timeout(cfg.ConnTimeout),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	return &NATSPublisher{conn: conn, prefix: cfg.SubjectPrefix}, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event models.BookEvent) error {
+	if event.TraceID == "" {
+		event.TraceID, _ = tracing.TraceIDFromContext(ctx)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	msg := nats.NewMsg(p.prefix + "." + string(event.EventType))
+	msg.Data = data
+	msg.Header.Set("Content-Type", "application/json")
+	if event.TraceID != "" {
+		msg.Header.Set("X-Trace-ID", event.TraceID)
+	}
+
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	return nil
+}
+
+// Close flushes pending messages before closing the connection.
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
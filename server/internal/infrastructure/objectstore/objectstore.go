@@ -0,0 +1,49 @@
+// Package objectstore keeps binary objects, such as book covers, on the local
+// disk or in an S3-compatible bucket.
+package objectstore
+
+import (
+	"bf-api/internal/domain/repositories"
+	"context"
+	"fmt"
+)
+
+// ErrNotFound is returned by Get for a key that holds no object.
+var ErrNotFound = repositories.ErrObjectNotFound
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+type Config struct {
+	Backend  string // local or s3; def: local
+	LocalDir string // root directory of the local backend; def: ./data/covers
+	S3       S3Config
+}
+
+// Store reads and writes objects by key. Keys are slash-separated paths such
+// as covers/default/1/abc.jpg.
+type Store interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns where clients can download key directly, or "" when the
+	// object is only reachable through the API.
+	URL(key string) string
+}
+
+// New returns the Store selected by cfg.Backend.
+func New(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		if cfg.LocalDir == "" {
+			cfg.LocalDir = "./data/covers"
+		}
+		return NewLocalStore(cfg.LocalDir)
+	case BackendS3:
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown object store backend %q; use local or s3", cfg.Backend)
+	}
+}
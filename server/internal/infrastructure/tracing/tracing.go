@@ -0,0 +1,16 @@
+package tracing
+
+import "context"
+
+type contextKey string
+
+const traceIDKey contextKey = "trace_id"
+
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+func TraceIDFromContext(ctx context.Context) (string, bool) {
+	traceID, ok := ctx.Value(traceIDKey).(string)
+	return traceID, ok
+}
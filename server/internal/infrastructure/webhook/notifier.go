@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/services"
+	"bf-api/internal/domain/tenant"
+	"bf-api/internal/infrastructure/tracing"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+)
+
+type Config struct {
+	URLs       []string
+	Secret     string
+	Workers    int           // def: 4
+	QueueSize  int           // def: 100
+	MaxRetries int           // def: 3
+	Timeout    time.Duration // def: 5s
+}
+
+type delivery struct {
+	url     string
+	event   models.BookEventType
+	payload []byte
+}
+
+// Notifier POSTs book lifecycle events to every configured subscriber from a
+// pool of worker goroutines, so callers never wait on delivery.
+type Notifier struct {
+	cfg        Config
+	client     *http.Client
+	clock      services.Clock
+	logger     *zap.Logger
+	deliveries chan delivery
+	wg         sync.WaitGroup
+	closeOnce  sync.Once
+}
+
+// NewNotifier starts the delivery workers. Events are timestamped by clock,
+// the system clock when nil.
+func NewNotifier(cfg Config, clock services.Clock, logger *zap.Logger) *Notifier {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if clock == nil {
+		clock = services.RealClock{}
+	}
+
+	n := &Notifier{
+		cfg:        cfg,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		clock:      clock,
+		logger:     logger,
+		deliveries: make(chan delivery, cfg.QueueSize),
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		n.wg.Add(1)
+		go n.worker()
+	}
+
+	return n
+}
+
+func (n *Notifier) Notify(ctx context.Context, eventType models.BookEventType, book *models.Book) {
+	traceID, _ := tracing.TraceIDFromContext(ctx)
+	payload, err := json.Marshal(models.BookEvent{
+		EventType: eventType,
+		Book:      book,
+		TenantID:  tenant.FromContext(ctx),
+		Timestamp: n.clock.Now().UTC(),
+		TraceID:   traceID,
+	})
+	if err != nil {
+		n.logger.Error("failed to encode webhook event",
+			zap.Error(err),
+			zap.String("event_type", string(eventType)),
+		)
+		return
+	}
+
+	for _, url := range n.cfg.URLs {
+		select {
+		case n.deliveries <- delivery{url: url, event: eventType, payload: payload}:
+		default:
+			n.logger.Warn("webhook queue full, dropping event",
+				zap.String("url", url),
+				zap.String("event_type", string(eventType)),
+				zap.String("trace_id", traceID),
+			)
+		}
+	}
+}
+
+// Close stops accepting events and waits for queued deliveries to finish.
+func (n *Notifier) Close() {
+	n.closeOnce.Do(func() {
+		close(n.deliveries)
+	})
+	n.wg.Wait()
+}
+
+func (n *Notifier) worker() {
+	defer n.wg.Done()
+	for d := range n.deliveries {
+		n.deliver(d)
+	}
+}
+
+func (n *Notifier) deliver(d delivery) {
+	backoff := 500 * time.Millisecond
+
+	var err error
+	for attempt := 0; attempt <= n.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		var retry bool
+		if retry, err = n.send(d); err == nil || !retry {
+			break
+		}
+	}
+
+	if err != nil {
+		n.logger.Error("webhook delivery failed",
+			zap.Error(err),
+			zap.String("url", d.url),
+			zap.String("event_type", string(d.event)),
+		)
+	}
+}
+
+// send performs a single delivery attempt and reports whether a failure is
+// worth retrying.
+func (n *Notifier) send(d delivery) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(d.payload))
+	if err != nil {
+		return false, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(d.event))
+	if n.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(n.cfg.Secret, d.payload))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of payload, as sent in the
+// signature header.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"bf-api/internal/domain/models"
+	"bf-api/internal/domain/services"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestNotifyUsesClock(t *testing.T) {
+	type received struct {
+		body      []byte
+		signature string
+	}
+	requests := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{body: body, signature: r.Header.Get(SignatureHeader)}
+	}))
+	defer srv.Close()
+
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	n := NewNotifier(Config{URLs: []string{srv.URL}, Secret: "secret"}, services.NewFakeClock(now), zap.NewNop())
+	n.Notify(context.Background(), models.BookCreated, &models.Book{ID: 1})
+	n.Close()
+
+	req := <-requests
+	var event models.BookEvent
+	if err := json.Unmarshal(req.body, &event); err != nil {
+		t.Fatalf("invalid event %q: %v", req.body, err)
+	}
+	if !event.Timestamp.Equal(now) || event.Timestamp.Location() != time.UTC {
+		t.Errorf("Timestamp = %v, want %v in UTC", event.Timestamp, now.UTC())
+	}
+	if want := "sha256=" + Sign("secret", req.body); req.signature != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, req.signature, want)
+	}
+}